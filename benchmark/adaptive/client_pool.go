@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/rachitkumar205/acp-kv/api/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 // ClientPool manages a pool of ACP gRPC client connections with round-robin load balancing
@@ -18,8 +20,9 @@ type ClientPool struct {
 	mu      sync.RWMutex
 }
 
-// NewClientPool creates a new client pool with connections to all endpoints
-func NewClientPool(endpoints []string) (*ClientPool, error) {
+// NewClientPool creates a new client pool with connections to all endpoints.
+// Each endpoint must become reachable within dialTimeout or the pool fails
+func NewClientPool(endpoints []string, dialTimeout time.Duration) (*ClientPool, error) {
 	if len(endpoints) == 0 {
 		return nil, fmt.Errorf("no endpoints provided")
 	}
@@ -30,11 +33,15 @@ func NewClientPool(endpoints []string) (*ClientPool, error) {
 
 	// connect to all endpoints
 	for _, endpoint := range endpoints {
-		conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+		conn, err := grpc.DialContext(ctx, endpoint,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithBlock())
+		cancel()
 		if err != nil {
 			// close any already-opened connections
 			pool.Close()
-			return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+			return nil, fmt.Errorf("failed to connect to %s within %v: %w", endpoint, dialTimeout, err)
 		}
 		pool.clients = append(pool.clients, conn)
 	}
@@ -70,9 +77,14 @@ func (p *ClientPool) HealthCheck(ctx context.Context) error {
 	// test each connection with a dummy operation
 	for i, conn := range p.clients {
 		client := proto.NewACPServiceClient(conn)
-		_, err := client.Get(ctx, &proto.GetRequest{Key: "__health_check__"})
-		if err != nil && err.Error() != "rpc error: code = Unknown desc = key not found" {
-			return fmt.Errorf("health check failed for client %d: %w", i, err)
+		// a missing key is Found:false with no error, a missed read quorum
+		// is Unavailable
+		resp, err := client.Get(ctx, &proto.GetRequest{Key: "__health_check__"})
+		if err != nil {
+			return fmt.Errorf("health check failed for client %d (%s): %w", i, status.Code(err), err)
+		}
+		if resp.Error != "" && !resp.IsStale {
+			return fmt.Errorf("health check failed for client %d: %s", i, resp.Error)
 		}
 	}
 
@@ -25,6 +25,7 @@ type MetricsSnapshot struct {
 	Timestamp            time.Time
 	CCSRaw               float64
 	CCSSmoothed          float64
+	CCSWarmup            bool // some node's ccs window was still filling
 	CurrentR             int
 	CurrentW             int
 	QuorumAdjustments    int64
@@ -82,6 +83,10 @@ func (m *MetricsCollector) Update(ctx context.Context) error {
 	}
 
 	// fetch int metrics
+	// any node still warming up makes the snapshot's ccs unreliable
+	if val, err := m.queryMetric(ctx, "max(acp_ccs_warmup)"); err == nil {
+		snapshot.CCSWarmup = val > 0
+	}
 	if val, err := m.queryMetric(ctx, "acp_current_r"); err == nil {
 		snapshot.CurrentR = int(val)
 	}
@@ -216,6 +221,13 @@ func (m *MetricsCollector) GetCCSSmoothed() float64 {
 	return m.snapshot.CCSSmoothed
 }
 
+// InWarmup reports whether the last snapshot was taken during ccs warmup
+func (m *MetricsCollector) InWarmup() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.snapshot.CCSWarmup
+}
+
 // GetCurrentQuorum returns the current R and W values
 func (m *MetricsCollector) GetCurrentQuorum() (int, int) {
 	m.mu.RLock()
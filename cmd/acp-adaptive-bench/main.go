@@ -27,6 +27,9 @@ type Config struct {
 	Workload         string
 	TargetThroughput int
 	OutputFile       string
+	DialTimeout      time.Duration
+	Soak             bool          // sample benchmark memory and goroutines to a separate csv
+	SoakInterval     time.Duration // time between runtime samples in soak mode
 }
 
 type BenchmarkStats struct {
@@ -49,8 +52,11 @@ func main() {
 	flag.DurationVar(&cfg.Duration, "duration", 180*time.Second, "benchmark duration")
 	flag.IntVar(&cfg.Concurrency, "concurrency", 10, "number of concurrent clients")
 	flag.StringVar(&cfg.Workload, "workload", "mixed", "workload type: read-heavy, write-heavy, mixed")
-	flag.IntVar(&cfg.TargetThroughput, "target-throughput", 1000, "target throughput (ops/sec)")
+	flag.IntVar(&cfg.TargetThroughput, "target-throughput", 1000, "target throughput (ops/sec), 0 for unthrottled")
 	flag.StringVar(&cfg.OutputFile, "output", "results.csv", "output CSV file")
+	flag.DurationVar(&cfg.DialTimeout, "dial-timeout", 5*time.Second, "max time to connect to each endpoint")
+	flag.BoolVar(&cfg.Soak, "soak", false, "sample memory and goroutine counts for leak detection on long runs")
+	flag.DurationVar(&cfg.SoakInterval, "soak-interval", 10*time.Second, "interval between runtime samples in soak mode")
 	flag.Parse()
 
 	if err := run(cfg); err != nil {
@@ -80,7 +86,7 @@ func run(cfg Config) error {
 
 	// create client pool
 	fmt.Printf("connecting to %d endpoints...\n", len(endpoints))
-	pool, err := adaptive.NewClientPool(endpoints)
+	pool, err := adaptive.NewClientPool(endpoints, cfg.DialTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to create client pool: %w", err)
 	}
@@ -101,8 +107,8 @@ func run(cfg Config) error {
 		if err := metricsCollector.Update(ctx); err != nil {
 			return fmt.Errorf("failed to query prometheus: %w", err)
 		}
-		fmt.Printf("initial CCS: raw=%.3f, smoothed=%.3f\n",
-			metricsCollector.GetCCSRaw(), metricsCollector.GetCCSSmoothed())
+		fmt.Printf("initial CCS: raw=%.3f, smoothed=%.3f%s\n",
+			metricsCollector.GetCCSRaw(), metricsCollector.GetCCSSmoothed(), warmupNote(metricsCollector))
 	}
 
 	// determine workload proportions
@@ -118,28 +124,57 @@ func run(cfg Config) error {
 	fmt.Printf("  mode: %s\n", cfg.Mode)
 	fmt.Printf("  duration: %s\n", cfg.Duration)
 	fmt.Printf("  concurrency: %d\n", cfg.Concurrency)
-	fmt.Printf("  target throughput: %d ops/sec\n", cfg.TargetThroughput)
+	if cfg.TargetThroughput > 0 {
+		fmt.Printf("  target throughput: %d ops/sec\n", cfg.TargetThroughput)
+	} else {
+		fmt.Printf("  target throughput: unthrottled\n")
+	}
 	fmt.Println()
 
+	// open results up front so snapshots survive an interrupted run
+	results, err := newResultsWriter(cfg.OutputFile)
+	if err != nil {
+		return fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer results.Close()
+
 	// start metrics collection (if prometheus available)
-	snapshotsCh := make(chan adaptive.MetricsSnapshot, 1000)
-	metricsEnabled := metricsCollector != nil
 	var metricsWg sync.WaitGroup
-	if metricsEnabled {
+	if metricsCollector != nil {
+		metricsWg.Add(1)
+		go func() {
+			defer metricsWg.Done()
+			collectMetrics(ctx, metricsCollector, results)
+		}()
+	}
+
+	// sample the benchmark's own runtime for leak detection
+	if cfg.Soak {
+		runtimeResults, err := newCSVStream(runtimeFilename(cfg.OutputFile), runtimeHeader)
+		if err != nil {
+			return fmt.Errorf("failed to open runtime samples file: %w", err)
+		}
+		defer runtimeResults.Close()
+
 		metricsWg.Add(1)
 		go func() {
 			defer metricsWg.Done()
-			collectMetrics(ctx, metricsCollector, snapshotsCh)
+			sampleRuntimeLoop(ctx, cfg.SoakInterval, func(s runtimeSample) {
+				if err := runtimeResults.WriteRuntimeSample(s); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to write runtime sample: %v\n", err)
+				}
+			})
 		}()
 	}
 
-	// start benchmark workers
+	// start benchmark workers, sharing one pacer for the target throughput
 	var wg sync.WaitGroup
+	throttle := newPacer(cfg.TargetThroughput)
 	for i := 0; i < cfg.Concurrency; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			runWorker(ctx, cfg, pool, stats, readRatio, workerID)
+			runWorker(ctx, pool, stats, throttle, readRatio, workerID)
 		}(i)
 	}
 
@@ -156,24 +191,24 @@ func run(cfg Config) error {
 	// wait for workers to finish
 	wg.Wait()
 
-	// wait for metrics collection to finish, then collect all snapshots
-	snapshots := make([]adaptive.MetricsSnapshot, 0)
-	if metricsEnabled {
-		metricsWg.Wait() // wait for collectMetrics to finish
-		close(snapshotsCh)
-		for snapshot := range snapshotsCh {
-			snapshots = append(snapshots, snapshot)
-		}
-	}
+	// wait for metrics collection to finish
+	metricsWg.Wait()
 
 	// print final statistics
 	printFinalStats(stats, cfg.Duration)
 
-	// write results to CSV
-	if err := writeResults(cfg.OutputFile, snapshots, stats); err != nil {
+	// snapshots are already on disk, add the aggregate stats alongside
+	summaryFile := summaryFilename(cfg.OutputFile)
+	if err := writeSummary(summaryFile, stats, cfg.Duration); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	if err := results.Close(); err != nil {
 		return fmt.Errorf("failed to write results: %w", err)
 	}
-	fmt.Printf("\nresults written to %s\n", cfg.OutputFile)
+	fmt.Printf("\nresults written to %s (summary in %s)\n", cfg.OutputFile, summaryFile)
+	if cfg.Soak {
+		fmt.Printf("runtime samples written to %s\n", runtimeFilename(cfg.OutputFile))
+	}
 
 	return nil
 }
@@ -191,68 +226,60 @@ func getReadRatio(workload string) float64 {
 	}
 }
 
-func runWorker(ctx context.Context, cfg Config, pool *adaptive.ClientPool, stats *BenchmarkStats, readRatio float64, workerID int) {
-	// rate limit per worker
-	targetOpsPerSec := cfg.TargetThroughput / cfg.Concurrency
-	if targetOpsPerSec == 0 {
-		targetOpsPerSec = 1
-	}
-	throttle := time.NewTicker(time.Second / time.Duration(targetOpsPerSec))
-	defer throttle.Stop()
-
+// a nil throttle is unthrottled, measuring saturation throughput
+func runWorker(ctx context.Context, pool *adaptive.ClientPool, stats *BenchmarkStats, throttle *pacer, readRatio float64, workerID int) {
 	rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID)))
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-throttle.C:
-			// determine operation type
-			isRead := rng.Float64() < readRatio
-
-			// generate key (zipfian-like distribution)
-			key := fmt.Sprintf("key%d", zipfian(rng, 100000))
-
-			// execute operation
-			start := time.Now()
-			var err error
-			if isRead {
-				_, err = pool.Get().Get(ctx, key)
-				stats.readOps.Add(1)
-			} else {
-				value := []byte(fmt.Sprintf("value-%d-%d", workerID, time.Now().UnixNano()))
-				_, err = pool.Get().Put(ctx, key, value)
-				stats.writeOps.Add(1)
-			}
-			latency := time.Since(start)
+	for throttle.wait(ctx) == nil {
+		doOperation(ctx, pool, stats, rng, readRatio, workerID)
+	}
+}
 
-			// update statistics
-			stats.totalOps.Add(1)
-			if err != nil {
-				stats.failedOps.Add(1)
-			} else {
-				stats.successOps.Add(1)
-			}
+func doOperation(ctx context.Context, pool *adaptive.ClientPool, stats *BenchmarkStats, rng *rand.Rand, readRatio float64, workerID int) {
+	// determine operation type
+	isRead := rng.Float64() < readRatio
+
+	// generate key (zipfian-like distribution)
+	key := fmt.Sprintf("key%d", zipfian(rng, 100000))
+
+	// execute operation
+	start := time.Now()
+	var err error
+	if isRead {
+		_, err = pool.Get().Get(ctx, key)
+		stats.readOps.Add(1)
+	} else {
+		value := []byte(fmt.Sprintf("value-%d-%d", workerID, time.Now().UnixNano()))
+		_, err = pool.Get().Put(ctx, key, value)
+		stats.writeOps.Add(1)
+	}
+	latency := time.Since(start)
 
-			// update latency stats
-			latencyNs := latency.Nanoseconds()
-			stats.totalLatencyNs.Add(latencyNs)
+	// update statistics
+	stats.totalOps.Add(1)
+	if err != nil {
+		stats.failedOps.Add(1)
+	} else {
+		stats.successOps.Add(1)
+	}
 
-			// update min latency
-			for {
-				current := stats.minLatencyNs.Load()
-				if latencyNs >= current || stats.minLatencyNs.CompareAndSwap(current, latencyNs) {
-					break
-				}
-			}
+	// update latency stats
+	latencyNs := latency.Nanoseconds()
+	stats.totalLatencyNs.Add(latencyNs)
 
-			// update max latency
-			for {
-				current := stats.maxLatencyNs.Load()
-				if latencyNs <= current || stats.maxLatencyNs.CompareAndSwap(current, latencyNs) {
-					break
-				}
-			}
+	// update min latency
+	for {
+		current := stats.minLatencyNs.Load()
+		if latencyNs >= current || stats.minLatencyNs.CompareAndSwap(current, latencyNs) {
+			break
+		}
+	}
+
+	// update max latency
+	for {
+		current := stats.maxLatencyNs.Load()
+		if latencyNs <= current || stats.maxLatencyNs.CompareAndSwap(current, latencyNs) {
+			break
 		}
 	}
 }
@@ -263,7 +290,7 @@ func zipfian(rng *rand.Rand, max int) int {
 	return int(float64(max) * (1 - rng.ExpFloat64()/10.0))
 }
 
-func collectMetrics(ctx context.Context, collector *adaptive.MetricsCollector, snapshotsCh chan<- adaptive.MetricsSnapshot) {
+func collectMetrics(ctx context.Context, collector *adaptive.MetricsCollector, results *resultsWriter) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
@@ -276,11 +303,8 @@ func collectMetrics(ctx context.Context, collector *adaptive.MetricsCollector, s
 				// ignore errors during collection
 				continue
 			}
-			snapshot := collector.Snapshot()
-			select {
-			case snapshotsCh <- snapshot:
-			default:
-				// channel full, skip this snapshot
+			if err := results.WriteSnapshot(collector.Snapshot()); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write snapshot: %v\n", err)
 			}
 		}
 	}
@@ -314,9 +338,9 @@ func reportProgress(ctx context.Context, stats *BenchmarkStats, collector *adapt
 				ccsRaw := collector.GetCCSRaw()
 				ccsSmoothed := collector.GetCCSSmoothed()
 				r, w := collector.GetCurrentQuorum()
-				fmt.Printf("[%s] ops=%d (success=%d, failed=%d) throughput=%.0f ops/s avg_latency=%.2fms ccs=%.3f/%.3f r=%d w=%d remaining=%s\n",
+				fmt.Printf("[%s] ops=%d (success=%d, failed=%d) throughput=%.0f ops/s avg_latency=%.2fms ccs=%.3f/%.3f%s r=%d w=%d remaining=%s\n",
 					elapsed.Round(time.Second), total, success, failed, throughput, avgLatency,
-					ccsRaw, ccsSmoothed, r, w, remaining.Round(time.Second))
+					ccsRaw, ccsSmoothed, warmupNote(collector), r, w, remaining.Round(time.Second))
 			} else {
 				fmt.Printf("[%s] ops=%d (success=%d, failed=%d) throughput=%.0f ops/s avg_latency=%.2fms remaining=%s\n",
 					elapsed.Round(time.Second), total, success, failed, throughput, avgLatency, remaining.Round(time.Second))
@@ -325,6 +349,14 @@ func reportProgress(ctx context.Context, stats *BenchmarkStats, collector *adapt
 	}
 }
 
+// flags ccs read while a node's smoothing window was still filling
+func warmupNote(collector *adaptive.MetricsCollector) string {
+	if collector.InWarmup() {
+		return " (warmup)"
+	}
+	return ""
+}
+
 func printFinalStats(stats *BenchmarkStats, duration time.Duration) {
 	total := stats.totalOps.Load()
 	success := stats.successOps.Load()
@@ -349,7 +381,88 @@ func printFinalStats(stats *BenchmarkStats, duration time.Duration) {
 	}
 }
 
-func writeResults(filename string, snapshots []adaptive.MetricsSnapshot, stats *BenchmarkStats) error {
+// resultsWriter streams snapshots to the csv as they are collected, flushing
+// each row so a killed run keeps everything gathered up to that point
+type resultsWriter struct {
+	mu     sync.Mutex
+	f      *os.File
+	writer *csv.Writer
+	closed bool
+}
+
+func newResultsWriter(filename string) (*resultsWriter, error) {
+	return newCSVStream(filename, []string{
+		"timestamp", "ccs_raw", "ccs_smoothed", "current_r", "current_w",
+		"quorum_adjustments", "staleness_violations", "ccs_warmup",
+	})
+}
+
+// newCSVStream creates filename and writes header as its first row
+func newCSVStream(filename string, header []string) (*resultsWriter, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rw := &resultsWriter{f: f, writer: csv.NewWriter(f)}
+
+	if err := rw.write(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return rw, nil
+}
+
+// WriteSnapshot appends one snapshot row and flushes it to the file
+func (rw *resultsWriter) WriteSnapshot(snapshot adaptive.MetricsSnapshot) error {
+	record := []string{
+		snapshot.Timestamp.Format(time.RFC3339),
+		fmt.Sprintf("%.6f", snapshot.CCSRaw),
+		fmt.Sprintf("%.6f", snapshot.CCSSmoothed),
+		fmt.Sprintf("%d", snapshot.CurrentR),
+		fmt.Sprintf("%d", snapshot.CurrentW),
+		fmt.Sprintf("%d", snapshot.QuorumAdjustments),
+		fmt.Sprintf("%d", snapshot.StalenessViolations),
+		fmt.Sprintf("%t", snapshot.CCSWarmup),
+	}
+	return rw.write(record)
+}
+
+func (rw *resultsWriter) write(record []string) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.closed {
+		return fmt.Errorf("results writer closed")
+	}
+	if err := rw.writer.Write(record); err != nil {
+		return err
+	}
+	rw.writer.Flush()
+	return rw.writer.Error()
+}
+
+// Close closes the file, safe to call more than once
+func (rw *resultsWriter) Close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.closed {
+		return nil
+	}
+	rw.closed = true
+	return rw.f.Close()
+}
+
+// summaryFilename derives the companion file for aggregate stats,
+// results.csv -> results_summary.csv
+func summaryFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".csv") + "_summary.csv"
+}
+
+// writeSummary writes the final aggregate stats as metric,value rows
+func writeSummary(filename string, stats *BenchmarkStats, duration time.Duration) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -357,32 +470,27 @@ func writeResults(filename string, snapshots []adaptive.MetricsSnapshot, stats *
 	defer f.Close()
 
 	writer := csv.NewWriter(f)
-	defer writer.Flush()
 
-	// write header
-	header := []string{
-		"timestamp", "ccs_raw", "ccs_smoothed", "current_r", "current_w",
-		"quorum_adjustments", "staleness_violations",
+	rows := [][]string{
+		{"metric", "value"},
+		{"duration_seconds", fmt.Sprintf("%.0f", duration.Seconds())},
+		{"total_ops", fmt.Sprintf("%d", stats.totalOps.Load())},
+		{"success_ops", fmt.Sprintf("%d", stats.successOps.Load())},
+		{"failed_ops", fmt.Sprintf("%d", stats.failedOps.Load())},
+		{"read_ops", fmt.Sprintf("%d", stats.readOps.Load())},
+		{"write_ops", fmt.Sprintf("%d", stats.writeOps.Load())},
+		{"throughput_ops_per_sec", fmt.Sprintf("%.2f", float64(stats.totalOps.Load())/duration.Seconds())},
 	}
-	if err := writer.Write(header); err != nil {
-		return err
+	if success := stats.successOps.Load(); success > 0 {
+		rows = append(rows,
+			[]string{"avg_latency_ms", fmt.Sprintf("%.3f", float64(stats.totalLatencyNs.Load())/float64(success)/1e6)},
+			[]string{"min_latency_ms", fmt.Sprintf("%.3f", float64(stats.minLatencyNs.Load())/1e6)},
+			[]string{"max_latency_ms", fmt.Sprintf("%.3f", float64(stats.maxLatencyNs.Load())/1e6)},
+		)
 	}
 
-	// write snapshots
-	for _, snapshot := range snapshots {
-		record := []string{
-			snapshot.Timestamp.Format(time.RFC3339),
-			fmt.Sprintf("%.6f", snapshot.CCSRaw),
-			fmt.Sprintf("%.6f", snapshot.CCSSmoothed),
-			fmt.Sprintf("%d", snapshot.CurrentR),
-			fmt.Sprintf("%d", snapshot.CurrentW),
-			fmt.Sprintf("%d", snapshot.QuorumAdjustments),
-			fmt.Sprintf("%d", snapshot.StalenessViolations),
-		}
-		if err := writer.Write(record); err != nil {
-			return err
-		}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
 	}
-
-	return nil
+	return f.Close()
 }
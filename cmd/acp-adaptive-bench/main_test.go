@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/benchmark/adaptive"
+)
+
+func TestNewPacer_Unthrottled(t *testing.T) {
+	for _, target := range []int{0, -5} {
+		if p := newPacer(target); p != nil {
+			t.Errorf("newPacer(%d) = %v, want nil", target, p)
+		}
+	}
+
+	// a nil pacer never blocks
+	var p *pacer
+	if err := p.wait(context.Background()); err != nil {
+		t.Errorf("expected unthrottled wait to return immediately, got %v", err)
+	}
+}
+
+func TestPacer_SharedAcrossWorkersMatchesTarget(t *testing.T) {
+	const (
+		target   = 200
+		workers  = 16
+		duration = time.Second
+	)
+
+	p := newPacer(target)
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	var ops atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// stagger worker start like real workers connecting
+			time.Sleep(time.Duration(i) * time.Millisecond)
+			for p.wait(ctx) == nil {
+				ops.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	want := float64(target) * duration.Seconds()
+	if got := float64(ops.Load()); got < want*0.9 || got > want*1.1 {
+		t.Errorf("expected about %.0f ops across %d workers, got %.0f", want, workers, got)
+	}
+}
+
+func TestResultsWriter_PartialResultsSurviveInterruption(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "results.csv")
+
+	results, err := newResultsWriter(filename)
+	if err != nil {
+		t.Fatalf("failed to open results: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		snapshot := adaptive.MetricsSnapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			CCSRaw:    0.5,
+			CurrentR:  2,
+			CurrentW:  2,
+		}
+		if err := results.WriteSnapshot(snapshot); err != nil {
+			t.Fatalf("failed to write snapshot: %v", err)
+		}
+	}
+
+	// the run is interrupted here, before Close or the summary
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("failed to open partial results: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("partial results are not valid csv: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected header and 3 snapshots, got %d rows", len(records))
+	}
+	if records[0][0] != "timestamp" {
+		t.Errorf("expected header row first, got %v", records[0])
+	}
+	if records[3][0] != start.Add(2*time.Second).Format(time.RFC3339) {
+		t.Errorf("expected last snapshot at %v, got %v", start.Add(2*time.Second), records[3][0])
+	}
+
+	results.Close()
+	if err := results.WriteSnapshot(adaptive.MetricsSnapshot{}); err == nil {
+		t.Error("expected writes after close to fail")
+	}
+}
+
+func TestSummaryFilename(t *testing.T) {
+	if got := summaryFilename("out/results.csv"); got != "out/results_summary.csv" {
+		t.Errorf("unexpected summary filename %q", got)
+	}
+	if got := summaryFilename("results"); got != "results_summary.csv" {
+		t.Errorf("unexpected summary filename %q", got)
+	}
+}
+
+func TestSampleRuntimeLoop_IncreasingTimestamps(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	samples := make(chan runtimeSample, 10)
+	go sampleRuntimeLoop(ctx, 5*time.Millisecond, func(s runtimeSample) {
+		select {
+		case samples <- s:
+		default:
+		}
+	})
+
+	var prev time.Time
+	for i := 0; i < 3; i++ {
+		s := <-samples
+		if !s.Timestamp.After(prev) {
+			t.Errorf("sample %d: expected timestamp after %v, got %v", i, prev, s.Timestamp)
+		}
+		if s.Goroutines == 0 || s.Sys == 0 {
+			t.Errorf("sample %d: expected goroutine and memory readings, got %+v", i, s)
+		}
+		prev = s.Timestamp
+	}
+}
+
+func TestRuntimeFilename(t *testing.T) {
+	if got := runtimeFilename("out/results.csv"); got != "out/results_runtime.csv" {
+		t.Errorf("expected out/results_runtime.csv, got %s", got)
+	}
+}
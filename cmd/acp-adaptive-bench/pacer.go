@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pacer spaces operations evenly across all workers, so aggregate throughput
+// matches the target however many workers there are and whenever they
+// started. slots are handed out in the order workers ask for them, so no
+// worker is starved, and idle time is not banked into a later burst
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newpacer returns nil for an unthrottled run, where workers issue
+// operations back-to-back
+func newPacer(targetThroughput int) *pacer {
+	if targetThroughput <= 0 {
+		return nil
+	}
+	// divide in nanoseconds so the rate is kept for any target
+	return &pacer{interval: time.Duration(int64(time.Second) / int64(targetThroughput))}
+}
+
+// wait blocks until the caller's slot, or returns the context's error
+func (p *pacer) wait(ctx context.Context) error {
+	if p == nil {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	slot := p.next
+	p.next = p.next.Add(p.interval)
+	p.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
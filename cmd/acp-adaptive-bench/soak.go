@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runtimeSample is one reading of the benchmark process's memory and
+// goroutine counts. a steady upward trend over a soak run points at a leak
+type runtimeSample struct {
+	Timestamp  time.Time
+	Goroutines int
+	HeapAlloc  uint64 // bytes of live heap objects
+	HeapInuse  uint64
+	Sys        uint64 // total bytes obtained from the os
+	NumGC      uint32
+}
+
+func sampleRuntime() runtimeSample {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return runtimeSample{
+		Timestamp:  time.Now(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  m.HeapAlloc,
+		HeapInuse:  m.HeapInuse,
+		Sys:        m.Sys,
+		NumGC:      m.NumGC,
+	}
+}
+
+// sampleRuntimeLoop takes a sample immediately and then every interval until
+// ctx is done, handing each to emit
+func sampleRuntimeLoop(ctx context.Context, interval time.Duration, emit func(runtimeSample)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	emit(sampleRuntime())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			emit(sampleRuntime())
+		}
+	}
+}
+
+// runtimeFilename derives the soak output file, results.csv -> results_runtime.csv
+func runtimeFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".csv") + "_runtime.csv"
+}
+
+var runtimeHeader = []string{
+	"timestamp", "goroutines", "heap_alloc_bytes", "heap_inuse_bytes", "sys_bytes", "num_gc",
+}
+
+// WriteRuntimeSample appends one runtime sample row and flushes it
+func (rw *resultsWriter) WriteRuntimeSample(s runtimeSample) error {
+	return rw.write([]string{
+		s.Timestamp.Format(time.RFC3339Nano),
+		fmt.Sprintf("%d", s.Goroutines),
+		fmt.Sprintf("%d", s.HeapAlloc),
+		fmt.Sprintf("%d", s.HeapInuse),
+		fmt.Sprintf("%d", s.Sys),
+		fmt.Sprintf("%d", s.NumGC),
+	})
+}
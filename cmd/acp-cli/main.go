@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/rachitkumar205/acp-kv/api/proto"
 	"github.com/rachitkumar205/acp-kv/pkg/client"
 )
 
@@ -13,15 +16,22 @@ func main() {
 	if len(os.Args) < 3 {
 		fmt.Println("Usage:")
 		fmt.Println("	acp-cli <address> put <key> <value>")
-		fmt.Println("	acp-cli <address> get <key>")
+		fmt.Println("	acp-cli <address> get <key> [--from <peer>]")
 		fmt.Println("	acp-cli <address> health")
+		fmt.Println("	acp-cli <address> reconcile <peer>")
+		fmt.Println("	acp-cli <address> peers set <peer1,peer2,...>")
+		fmt.Println("	acp-cli <address> quorum set <r> <w> [reason]")
+		fmt.Println("	acp-cli <address> hotkeys [limit]")
+		fmt.Println("	acp-cli <address> keys [-l]")
+		fmt.Println("	acp-cli <address> diff <peer>")
+		fmt.Println("	acp-cli <address> flush --yes-wipe-all-data")
 		os.Exit(1)
 	}
 
 	addr := os.Args[1]
 	cmd := os.Args[2]
 
-	c, err := client.NewClient(addr)
+	c, err := client.DialClient(addr, 3*time.Second)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error connecting: %v\n", err)
 		os.Exit(1)
@@ -56,13 +66,22 @@ func main() {
 		}
 
 	case "get":
-		if len(os.Args) < 4 {
-			fmt.Println("Usage: acp-cli <address> get <key>")
+		if len(os.Args) < 4 || (len(os.Args) > 4 && (len(os.Args) != 6 || os.Args[4] != "--from")) {
+			fmt.Println("Usage: acp-cli <address> get <key> [--from <peer>]")
 			os.Exit(1)
 		}
 		key := os.Args[3]
+		from := ""
+		if len(os.Args) == 6 {
+			from = os.Args[5]
+		}
 
-		resp, err := c.Get(ctx, key)
+		var resp *proto.GetResponse
+		if from != "" {
+			resp, err = c.GetFrom(ctx, key, from)
+		} else {
+			resp, err = c.Get(ctx, key)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "GET failed: %v\n", err)
 			os.Exit(1)
@@ -73,6 +92,11 @@ func main() {
 			fmt.Printf("value: %s\n", string(resp.Value))
 			fmt.Printf("version: %d\n", resp.Version)
 			fmt.Printf("timestamp: %d\n", resp.Timestamp)
+			if from != "" {
+				fmt.Printf("node ID: %s\n", resp.NodeId)
+				fmt.Printf("hlc: %d.%d\n", resp.Hlc.GetPhysical(), resp.Hlc.GetLogical())
+				fmt.Printf("stale: %t\n", resp.IsStale)
+			}
 		} else {
 			fmt.Printf("key not found\n")
 			if resp.Error != "" {
@@ -90,6 +114,7 @@ func main() {
 
 		if resp.Healthy {
 			fmt.Printf("node healthy\n")
+			fmt.Printf("ready: %t\n", resp.Ready)
 			fmt.Printf("node ID: %s\n", resp.NodeId)
 			fmt.Printf("timestamp: %d\n", resp.Timestamp)
 		} else {
@@ -97,9 +122,186 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "reconcile":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: acp-cli <address> reconcile <peer>")
+			os.Exit(1)
+		}
+		peer := os.Args[3]
+
+		resp, err := c.TriggerReconcile(ctx, peer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reconcile failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Success {
+			fmt.Printf("reconcile successful\n")
+			fmt.Printf("keys reconciled: %d\n", resp.KeysReconciled)
+		} else {
+			fmt.Printf("reconcile failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+
+	case "peers":
+		if len(os.Args) < 5 || os.Args[3] != "set" {
+			fmt.Println("Usage: acp-cli <address> peers set <peer1,peer2,...>")
+			os.Exit(1)
+		}
+		peers := strings.Split(os.Args[4], ",")
+
+		resp, err := c.UpdatePeers(ctx, peers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "peers set failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Success {
+			fmt.Printf("peers updated\n")
+			fmt.Printf("cluster size: %d\n", resp.ClusterSize)
+		} else {
+			fmt.Printf("peers set failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+
+	case "quorum":
+		if len(os.Args) < 6 || os.Args[3] != "set" {
+			fmt.Println("Usage: acp-cli <address> quorum set <r> <w> [reason]")
+			os.Exit(1)
+		}
+		r, errR := strconv.Atoi(os.Args[4])
+		w, errW := strconv.Atoi(os.Args[5])
+		if errR != nil || errW != nil {
+			fmt.Println("r and w must be integers")
+			os.Exit(1)
+		}
+		reason := ""
+		if len(os.Args) >= 7 {
+			reason = os.Args[6]
+		}
+
+		resp, err := c.SetQuorum(ctx, r, w, reason)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "quorum set failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Success {
+			fmt.Printf("quorum set: r=%d w=%d\n", r, w)
+		} else {
+			fmt.Printf("quorum set failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+
+	case "hotkeys":
+		limit := 0
+		if len(os.Args) >= 4 {
+			limit, err = strconv.Atoi(os.Args[3])
+			if err != nil {
+				fmt.Println("Usage: acp-cli <address> hotkeys [limit]")
+				os.Exit(1)
+			}
+		}
+
+		resp, err := c.HotKeys(ctx, limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hotkeys failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Error != "" {
+			fmt.Printf("hotkeys failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+		for _, hk := range resp.Keys {
+			fmt.Printf("%s\t%d\n", hk.Key, hk.Count)
+		}
+
+	case "keys":
+		metadata := len(os.Args) >= 4 && os.Args[3] == "-l"
+
+		// page through every key, each page gets its own deadline
+		cursor := ""
+		for {
+			pageCtx, pageCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			resp, err := c.ListKeys(pageCtx, cursor, 0, metadata)
+			pageCancel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "keys failed: %v\n", err)
+				os.Exit(1)
+			}
+			if resp.Error != "" {
+				fmt.Printf("keys failed: %s\n", resp.Error)
+				os.Exit(1)
+			}
+
+			for _, k := range resp.Keys {
+				if metadata {
+					fmt.Printf("%s\t%d.%d\t%s\t%d\n", k.Key, k.Hlc.GetPhysical(), k.Hlc.GetLogical(), k.NodeId, k.Size)
+				} else {
+					fmt.Println(k.Key)
+				}
+			}
+
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+
+	case "diff":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: acp-cli <address> diff <peer>")
+			os.Exit(1)
+		}
+		peer := os.Args[3]
+
+		// the node pages through the peer's whole key set
+		diffCtx, diffCancel := context.WithTimeout(context.Background(), time.Minute)
+		defer diffCancel()
+		resp, err := c.MissingKeys(diffCtx, peer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "diff failed: %v\n", err)
+			os.Exit(1)
+		}
+		if resp.Error != "" {
+			fmt.Printf("diff failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+
+		for _, k := range resp.Keys {
+			if k.LocalHlc == nil {
+				fmt.Printf("missing\t%s\t%d.%d\n", k.Key, k.PeerHlc.GetPhysical(), k.PeerHlc.GetLogical())
+			} else {
+				fmt.Printf("stale\t%s\t%d.%d < %d.%d\n", k.Key, k.LocalHlc.GetPhysical(), k.LocalHlc.GetLogical(),
+					k.PeerHlc.GetPhysical(), k.PeerHlc.GetLogical())
+			}
+		}
+		fmt.Printf("keys compared: %d, behind peer: %d\n", resp.KeysCompared, len(resp.Keys))
+
+	case "flush":
+		if len(os.Args) < 4 || os.Args[3] != "--yes-wipe-all-data" {
+			fmt.Println("flush deletes every key on the node, confirm with:")
+			fmt.Println("	acp-cli <address> flush --yes-wipe-all-data")
+			os.Exit(1)
+		}
+
+		resp, err := c.Flush(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flush failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Success {
+			fmt.Printf("keys removed: %d\n", resp.KeysRemoved)
+		} else {
+			fmt.Printf("flush failed: %s\n", resp.Error)
+			os.Exit(1)
+		}
+
 	default:
 		fmt.Printf("unknown command: %s\n", cmd)
-		fmt.Println("valid commands: put, get, health")
+		fmt.Println("valid commands: put, get, health, reconcile, peers, quorum, hotkeys, keys, flush")
 		os.Exit(1)
 
 	}
@@ -7,22 +7,27 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rachitkumar205/acp-kv/api/proto"
 	"github.com/rachitkumar205/acp-kv/internal/adaptive"
+	"github.com/rachitkumar205/acp-kv/internal/audit"
 	"github.com/rachitkumar205/acp-kv/internal/config"
 	"github.com/rachitkumar205/acp-kv/internal/health"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/logging"
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/partition"
 	"github.com/rachitkumar205/acp-kv/internal/reconcile"
 	"github.com/rachitkumar205/acp-kv/internal/replication"
 	"github.com/rachitkumar205/acp-kv/internal/server"
 	"github.com/rachitkumar205/acp-kv/internal/staleness"
 	"github.com/rachitkumar205/acp-kv/internal/storage"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
 )
 
@@ -33,13 +38,20 @@ func main() {
 		fmt.Fprintf(os.Stderr, "failed to initialise logger: %v\n", err)
 		os.Exit(1)
 	}
-	defer logger.Sync()
+	// logger may be replaced by the buffered one below, sync whichever is current
+	defer func() { logger.Sync() }()
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		logger.Fatal("failed to load configuration", zap.Error(err))
 	}
 
+	if cfg.LogBufferSize > 0 {
+		var buffered *zapcore.BufferedWriteSyncer
+		logger, buffered = logging.NewBufferedProduction(zapcore.Lock(os.Stderr), cfg.LogBufferSize, cfg.LogFlushInterval)
+		defer buffered.Stop()
+	}
+
 	logger.Info("starting acp node",
 		zap.String("node_id", cfg.NodeID),
 		zap.String("listen_addr", cfg.ListenAddr),
@@ -54,17 +66,36 @@ func main() {
 	}
 
 	m := metrics.NewMetrics("acp")
+	m.SetHistogramSampling(cfg.HistogramSampleEvery)
+	m.SetAggregateReplicateLatency(cfg.AggregateReplicateLatency)
 	m.CurrentR.Set(float64(cfg.R))
 	m.CurrentW.Set(float64(cfg.W))
 
 	store := storage.NewStore()
-	logger.Info("storage initialised")
+	if cfg.BloomFilterEnabled {
+		store.EnableBloomFilter(cfg.BloomExpectedKeys, 0.01)
+	}
+	if cfg.HotKeyTracking {
+		store.EnableHotKeyTracking(cfg.HotKeyTopK)
+	}
+	if cfg.VersionHistory > 0 {
+		store.EnableVersionHistory(cfg.VersionHistory)
+	}
+	logger.Info("storage initialised",
+		zap.Bool("bloom_filter", cfg.BloomFilterEnabled),
+		zap.Bool("hot_key_tracking", cfg.HotKeyTracking),
+		zap.Int("version_history", cfg.VersionHistory))
 
 	// initialize hlc clock
 	hlcClock := hlc.NewClock(cfg.NodeID, cfg.HLCMaxDrift)
+	hlcClock.SetMaxLead(cfg.HLCMaxLead)
+	hlcClock.SetResolution(cfg.HLCResolution)
 	logger.Info("hlc clock initialized",
 		zap.String("node_id", cfg.NodeID),
-		zap.Duration("max_drift", cfg.HLCMaxDrift))
+		zap.Duration("max_drift", cfg.HLCMaxDrift),
+		zap.Duration("drift_warning", cfg.HLCDriftWarning),
+		zap.Duration("max_lead", cfg.HLCMaxLead),
+		zap.Duration("resolution", cfg.HLCResolution))
 
 	// initialize staleness detector
 	stalenessDetector := staleness.NewDetector(cfg.MaxStaleness, m)
@@ -76,13 +107,50 @@ func main() {
 		logger.Fatal("failed to initialise replication coordinator", zap.Error(err))
 	}
 	defer coordinator.Close()
-	logger.Info("replication coordinator initialised", zap.Int("peer_count", len(cfg.Peers)))
+	coordinator.SetConnectConcurrency(cfg.PeerConnectConcurrency)
+	if cfg.AdvertiseAddr != "" {
+		coordinator.SetSelfAddress(cfg.AdvertiseAddr)
+	}
+	coordinator.SetReplicateRetries(cfg.ReplicationRetries, cfg.ReplicationRetryBackoff)
+	coordinator.SetMaxFanOut(cfg.MaxFanOut)
+	coordinator.SetVerifyAcks(cfg.ReplicationVerifyAcks)
+	if len(cfg.ObserverPeers) > 0 {
+		coordinator.AddObservers(cfg.ObserverPeers)
+	}
+	var ring *partition.ConsistentHash
+	if cfg.ShardingEnabled {
+		ring = partition.NewConsistentHash(nil, cfg.ShardReplicas, cfg.ShardVNodes)
+		coordinator.SetPartitioner(ring, cfg.AdvertiseAddr)
+		logger.Info("sharding enabled",
+			zap.Int("shard_replicas", cfg.ShardReplicas),
+			zap.String("advertise_addr", cfg.AdvertiseAddr))
+	}
+	logger.Info("replication coordinator initialised",
+		zap.String("role", cfg.Role),
+		zap.Int("peer_count", len(cfg.Peers)),
+		zap.Int("observer_count", len(cfg.ObserverPeers)))
 
 	probe, err := health.NewProbe(cfg.NodeID, cfg.Peers, cfg.HealthProbeInterval, logger, m)
 	if err != nil {
 		logger.Fatal("failed to initalise health probe", zap.Error(err))
 	}
 	defer probe.Stop()
+	probe.SetConnectConcurrency(cfg.PeerConnectConcurrency)
+	probe.SetPayloadSize(cfg.HealthProbePayloadBytes)
+	probe.SetDiscoveryOnly(cfg.HealthProbeMode == config.HealthProbeDiscovery)
+
+	// optional audit log of concurrent writes discarded by lww
+	var conflictAudit *audit.ConflictLog
+	if cfg.ConflictAuditFile != "" {
+		conflictAudit, err = audit.NewConflictLog(cfg.ConflictAuditFile, int64(cfg.ConflictAuditMaxBytes), audit.DefaultBuffer, logger, m)
+		if err != nil {
+			logger.Fatal("failed to open conflict audit file", zap.String("path", cfg.ConflictAuditFile), zap.Error(err))
+		}
+		defer conflictAudit.Close()
+		logger.Info("conflict audit enabled",
+			zap.String("path", cfg.ConflictAuditFile),
+			zap.Int("max_bytes", cfg.ConflictAuditMaxBytes))
+	}
 
 	// initialize reconciliation engine
 	var reconciler *reconcile.Engine
@@ -95,9 +163,21 @@ func main() {
 			logger,
 			m,
 		)
+		if cfg.ReconcileLogCompaction {
+			reconciler.EnableLogCompaction()
+		}
+		if conflictAudit != nil {
+			reconciler.SetConflictAudit(conflictAudit)
+		}
+		if cfg.ReconcileConcurrency > 0 {
+			reconciler.SetReconcileConcurrency(cfg.ReconcileConcurrency)
+		}
+		reconciler.SetChunking(cfg.ReconcileChunkSize, int64(cfg.ReconcileBytesPerSec))
+		reconciler.SetHealingQueueSize(cfg.HealingQueueSize)
 		logger.Info("reconciliation engine initialized",
 			zap.Bool("enabled", cfg.ReconciliationEnabled),
-			zap.Duration("interval", cfg.ReconciliationInterval))
+			zap.Duration("interval", cfg.ReconciliationInterval),
+			zap.Bool("log_compaction", cfg.ReconcileLogCompaction))
 
 		// set reconciler as healing listener on probe
 		probe.SetHealingListener(reconciler)
@@ -113,6 +193,7 @@ func main() {
 
 	// initialize quorum provider (static or adaptive)
 	var quorumProvider adaptive.QuorumProvider = cfg
+	var writeSuspender *adaptive.WriteSuspender
 
 	if cfg.AdaptiveEnabled {
 		logger.Info("initializing adaptive quorum system",
@@ -127,11 +208,13 @@ func main() {
 			zap.Float64("tighten_threshold", cfg.CCSTightenThreshold))
 
 		// create adaptive quorum
+		// with sharding, quorums are formed among a key's owners
 		adaptiveQuorum := adaptive.NewAdaptiveQuorum(
-			cfg.R, cfg.W, cfg.N,
+			cfg.R, cfg.W, cfg.QuorumSize(),
 			cfg.MinR, cfg.MaxR, cfg.MinW, cfg.MaxW,
 			logger, m,
 		)
+		adaptiveQuorum.SetManualHold(cfg.QuorumManualHold)
 		quorumProvider = adaptiveQuorum
 
 		// create metrics reader
@@ -139,6 +222,19 @@ func main() {
 
 		// create ccs computer
 		ccsComputer := adaptive.NewCCSComputer(logger, m)
+		spikeAggregation, err := adaptive.ParseAggregation(cfg.CCSSpikeAggregation)
+		if err != nil {
+			logger.Fatal("invalid CCS_SPIKE_AGGREGATION", zap.Error(err))
+		}
+		ccsComputer.SetSpikeAggregation(spikeAggregation)
+
+		// size windows to span CCS_SMOOTHING_HORIZON of real time
+		windowSize := adaptive.WindowSizeForHorizon(cfg.CCSSmoothingHorizon, cfg.AdaptiveInterval)
+		ccsComputer.SetWindowSize(windowSize)
+		if err := adaptive.CheckHorizon(cfg.AdaptiveInterval, windowSize, cfg.CCSMinHorizon); err != nil {
+			logger.Warn("ccs smoothing horizon too short, quorum adjustments will follow noise",
+				zap.Error(err))
+		}
 
 		// create and start adjuster
 		adjuster := adaptive.NewAdjuster(
@@ -153,10 +249,36 @@ func main() {
 			m,
 		)
 
+		if cfg.CCSWriteSuspendThreshold > 0 {
+			writeSuspender = adaptive.NewWriteSuspender(cfg.CCSWriteSuspendThreshold, logger, m)
+			adjuster.SetWriteSuspender(writeSuspender)
+		}
+		adjuster.SetSkipWarmup(cfg.AdjusterSkipWarmup)
+		adjuster.SetMinReachablePeers(cfg.AdjusterMinPeers, cfg.R, cfg.W)
+		if cfg.CCSInputsFile != "" {
+			inputs, err := adaptive.OpenInputRecorder(cfg.CCSInputsFile)
+			if err != nil {
+				logger.Fatal("failed to open ccs inputs file", zap.String("path", cfg.CCSInputsFile), zap.Error(err))
+			}
+			defer inputs.Close()
+			adjuster.SetInputRecorder(inputs)
+			logger.Info("recording ccs inputs", zap.String("path", cfg.CCSInputsFile))
+		}
+		if cfg.StalenessCeiling > 0 {
+			adjuster.SetStalenessLoosener(adaptive.NewStalenessLoosener(stalenessDetector, cfg.StalenessLoosenCCS, cfg.StalenessCeiling, logger))
+			logger.Info("adaptive staleness bound enabled",
+				zap.Float64("loosen_below_ccs", cfg.StalenessLoosenCCS),
+				zap.Duration("ceiling", cfg.StalenessCeiling))
+		}
+
 		go adjuster.Start(ctx)
 		logger.Info("adaptive quorum adjuster started")
 	}
 
+	// retry statically configured peers that were not reachable at startup
+	go coordinator.StartPeerReconnect(ctx, 500*time.Millisecond, 30*time.Second)
+	go coordinator.StartWriteAmplification(ctx, 10*time.Second)
+
 	// start reconciliation engine if enabled
 	if reconciler != nil {
 		go reconciler.Start(ctx)
@@ -172,19 +294,113 @@ func main() {
 			zap.String("method", "dns"),
 			zap.String("headless_service", headlessSvc),
 			zap.String("namespace", namespace),
+			zap.Int("port", cfg.DiscoveryPort),
 			zap.Duration("interval", discoveryInterval))
 
 		// start discovery for coordinator
-		go coordinator.StartPeerDiscovery(ctx, cfg.NodeID, headlessSvc, namespace, discoveryInterval)
+		go coordinator.StartPeerDiscovery(ctx, cfg.NodeID, headlessSvc, namespace, cfg.DiscoveryPort, discoveryInterval)
 
-		// start discovery for health probe
-		go probe.StartPeerDiscovery(ctx, cfg.NodeID, headlessSvc, namespace, discoveryInterval)
+		// start discovery for health probe, static mode keeps the initial peers
+		if cfg.HealthProbeMode == config.HealthProbeDiscovery {
+			go probe.StartPeerDiscovery(ctx, cfg.NodeID, headlessSvc, namespace, cfg.DiscoveryPort, discoveryInterval)
+		}
 	}
 
-	grpcServer := grpc.NewServer()
+	// recovery runs inside the metrics interceptors so recovered panics are
+	// counted as Internal
+	unary := []grpc.UnaryServerInterceptor{server.UnaryMetricsInterceptor(m)}
+	stream := []grpc.StreamServerInterceptor{server.StreamMetricsInterceptor(m)}
+	if cfg.PanicRecovery {
+		unary = append(unary, server.UnaryRecoveryInterceptor(logger, m))
+		stream = append(stream, server.StreamRecoveryInterceptor(logger, m))
+	}
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
 	acpServer := server.NewServer(cfg.NodeID, store, coordinator, quorumProvider, logger, m, hlcClock, stalenessDetector, reconciler)
+	acpServer.SetRollbackOnFailure(cfg.PutFailureMode == config.PutFailureRollback)
+	acpServer.SetRequireValueReplicas(cfg.RequireDistinctValueReplicas)
+	acpServer.SetHealthProbe(probe)
+	acpServer.SetReadDivergence(cfg.ReadDivergenceThreshold, cfg.ReadDivergenceAnnotate)
+	acpServer.SetReadVerification(cfg.ReadVerification)
+	acpServer.SetExcludeStaleReplicas(cfg.ExcludeStaleReplicas)
+	acpServer.SetAgreementFastPath(cfg.ReadAgreementFastPath)
+	acpServer.SetDegradedReads(cfg.DegradedReads)
+	acpServer.SetObserver(cfg.Role == config.RoleObserver)
+	acpServer.SetFlushEnabled(cfg.FlushEnabled)
+	acpServer.SetListKeysEnabled(cfg.ListKeysEnabled)
+	acpServer.SetPinnedReads(cfg.PinnedReadsEnabled)
+	if cfg.ShardingEnabled {
+		acpServer.SetShardReplicas(cfg.ShardReplicas)
+	}
+	acpServer.SetStalenessBypass(cfg.StalenessBypass)
+	acpServer.SetWriteCoalescing(cfg.WriteCoalesceWindow)
+	acpServer.SetWriteLogLimits(int64(cfg.ReconcileLogMaxBytes), cfg.ReconcileLogMaxValue)
+	if cfg.ReconcileLogAsync {
+		acpServer.SetAsyncWriteLog(ctx, cfg.ReconcileAsyncBuffer)
+	}
+	if cfg.PeerBootstrap != config.PeerBootstrapOff {
+		acpServer.EnablePeerBootstrap(cfg.PeerBootstrap == config.PeerBootstrapFull)
+	}
+	if writeSuspender != nil {
+		acpServer.SetWriteSuspender(writeSuspender)
+	}
+	if conflictAudit != nil {
+		acpServer.SetConflictAudit(conflictAudit)
+	}
+	if cfg.FlushEnabled {
+		logger.Warn("flush rpc enabled, any client can wipe this node's data")
+	}
+	acpServer.EnableDriftWarnings(cfg.HLCDriftWarning)
+	acpServer.EnableBackwardJumpWarnings(cfg.HLCBackwardJumpThreshold)
+	acpServer.SetHealthCheckClockReadOnly(cfg.HealthCheckClockReadOnly)
+	if cfg.DriftQuarantineEnabled {
+		quarantine := replication.NewQuarantine(cfg.DriftQuarantineThreshold, cfg.DriftQuarantineWindow, logger, m)
+		coordinator.SetQuarantine(quarantine)
+		acpServer.SetQuarantine(quarantine)
+		logger.Info("drift quarantine enabled",
+			zap.Int("threshold", cfg.DriftQuarantineThreshold),
+			zap.Duration("window", cfg.DriftQuarantineWindow))
+	}
+	if cfg.StartupMinPeers > 0 {
+		acpServer.SetStartupBarrier(cfg.StartupMinPeers, cfg.StartupBarrierTimeout)
+		go acpServer.StartStartupBarrier(ctx)
+		logger.Info("holding client traffic until peers connect",
+			zap.Int("min_peers", cfg.StartupMinPeers),
+			zap.Duration("timeout", cfg.StartupBarrierTimeout))
+	}
 	proto.RegisterACPServiceServer(grpcServer, acpServer)
 
+	if cfg.HotKeyTracking {
+		go acpServer.StartHotKeyReporting(ctx, 10*time.Second)
+	}
+	if cfg.AgeEvictionThreshold > 0 {
+		go acpServer.StartAgeEviction(ctx, cfg.AgeEvictionThreshold, cfg.AgeEvictionInterval)
+		logger.Info("age eviction enabled",
+			zap.Duration("threshold", cfg.AgeEvictionThreshold),
+			zap.Duration("interval", cfg.AgeEvictionInterval))
+	}
+	if cfg.ShardMigration {
+		// before sharding every node in PEERS held every key
+		fullReplication := partition.AllNodes(append(slices.Clone(cfg.Peers), cfg.AdvertiseAddr))
+		go func() {
+			for {
+				err := acpServer.MigratePlacement(ctx, fullReplication, ring, cfg.AdvertiseAddr,
+					cfg.ShardMigrationKeysPerSec, cfg.ShardMigrationCheckpoint)
+				if err == nil || ctx.Err() != nil {
+					return
+				}
+				logger.Warn("placement migration stopped, retrying", zap.Error(err))
+				select {
+				case <-time.After(10 * time.Second):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
 	lis, err := net.Listen("tcp", cfg.ListenAddr)
 	if err != nil {
 		logger.Fatal("failed to listen", zap.String("addr", cfg.ListenAddr), zap.Error(err))
@@ -199,6 +415,9 @@ func main() {
 
 	//metrics http server
 	http.Handle("/metrics", promhttp.Handler())
+	if cfg.ConfigEndpoint {
+		http.Handle("/config", cfg.Handler())
+	}
 	metricsServer := &http.Server{
 		Addr: cfg.MetricsAddr,
 	}
@@ -216,9 +435,7 @@ func main() {
 	<-sigCh
 
 	logger.Info("shutting down gracefully")
-	cancel() // stop discovery goroutines
-	grpcServer.GracefulStop()
-	metricsServer.Close()
+	shutdown(logger, grpcServer, cancel, metricsServer, cfg.ShutdownTimeout)
 	logger.Info("shutdown complete")
 }
 
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// the parts of grpc.Server used by shutdown
+type grpcStopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// the parts of http.Server used by shutdown
+type httpShutdowner interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// shutdown stops the node in order:
+//  1. stop accepting rpcs and drain in-flight ones, forcing them closed if
+//     they take longer than timeout
+//  2. cancel background loops (discovery, reconcile, adjuster, sweeps)
+//  3. shut the metrics server down gracefully so in-flight scrapes finish,
+//     closing it if that takes longer than timeout
+//
+// metrics stay up until the end so the drain itself can be observed
+func shutdown(logger *zap.Logger, grpcServer grpcStopper, cancel context.CancelFunc, metricsServer httpShutdowner, timeout time.Duration) {
+	drained := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		logger.Info("grpc server drained")
+	case <-time.After(timeout):
+		logger.Warn("grpc drain timed out, closing remaining rpcs", zap.Duration("timeout", timeout))
+		grpcServer.Stop()
+		<-drained
+	}
+
+	cancel()
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), timeout)
+	defer cancelShutdown()
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		logger.Warn("metrics server did not shut down gracefully, closing", zap.Error(err))
+		metricsServer.Close()
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// records the order shutdown steps happen in
+type stepLog struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (l *stepLog) add(step string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.steps = append(l.steps, step)
+}
+
+type fakeGRPC struct {
+	log     *stepLog
+	release chan struct{} // nil drains immediately
+}
+
+func (g *fakeGRPC) GracefulStop() {
+	if g.release != nil {
+		<-g.release
+	}
+	g.log.add("grpc drained")
+}
+
+func (g *fakeGRPC) Stop() {
+	g.log.add("grpc stopped")
+	close(g.release)
+}
+
+type fakeHTTP struct{ log *stepLog }
+
+func (h *fakeHTTP) Shutdown(ctx context.Context) error {
+	h.log.add("metrics shutdown")
+	return nil
+}
+
+func (h *fakeHTTP) Close() error {
+	h.log.add("metrics closed")
+	return nil
+}
+
+func TestShutdown_Order(t *testing.T) {
+	log := &stepLog{}
+	shutdown(zap.NewNop(), &fakeGRPC{log: log}, func() { log.add("cancel") }, &fakeHTTP{log: log}, time.Second)
+
+	want := []string{"grpc drained", "cancel", "metrics shutdown"}
+	if !slices.Equal(log.steps, want) {
+		t.Errorf("expected %v, got %v", want, log.steps)
+	}
+}
+
+func TestShutdown_ForcesStuckDrain(t *testing.T) {
+	log := &stepLog{}
+	grpc := &fakeGRPC{log: log, release: make(chan struct{})}
+	shutdown(zap.NewNop(), grpc, func() { log.add("cancel") }, &fakeHTTP{log: log}, 20*time.Millisecond)
+
+	want := []string{"grpc stopped", "grpc drained", "cancel", "metrics shutdown"}
+	if !slices.Equal(log.steps, want) {
+		t.Errorf("expected %v, got %v", want, log.steps)
+	}
+}
+
+func TestShutdown_MetricsScrapeCompletes(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	scraping := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(scraping)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "acp_up 1\n")
+	})}
+	go srv.Serve(lis)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String() + "/metrics")
+		if err != nil {
+			body <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-scraping
+	shutdown(zap.NewNop(), &fakeGRPC{log: &stepLog{}}, func() {}, srv, time.Second)
+
+	if got := <-body; got != "acp_up 1\n" {
+		t.Errorf("expected in-flight scrape to complete, got %q", got)
+	}
+	if _, err := http.Get("http://" + lis.Addr().String() + "/metrics"); err == nil {
+		t.Error("expected metrics server to stop accepting after shutdown")
+	}
+}
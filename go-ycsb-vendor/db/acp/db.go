@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -21,6 +22,7 @@ type acpDB struct {
 	conns      []*grpc.ClientConn
 	mu         sync.Mutex
 	nextClient int
+	fieldMode  bool // store each field under its own key instead of one json blob
 }
 
 type acpCreator struct{}
@@ -35,8 +37,9 @@ func (c acpCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	addrs := strings.Split(endpoints, ",")
 
 	db := &acpDB{
-		clients: make([]pb.ACPServiceClient, len(addrs)),
-		conns:   make([]*grpc.ClientConn, len(addrs)),
+		clients:   make([]pb.ACPServiceClient, len(addrs)),
+		conns:     make([]*grpc.ClientConn, len(addrs)),
+		fieldMode: p.GetBool("acp.fieldgranular", false),
 	}
 
 	// establish grpc connections to all nodes
@@ -93,7 +96,112 @@ func getRowKey(table string, key string) string {
 	return fmt.Sprintf("%s:%s", table, key)
 }
 
+// getFieldKey creates composite key for a single field in field-granular mode
+func getFieldKey(table string, key string, field string) string {
+	return fmt.Sprintf("%s:%s:%s", table, key, field)
+}
+
+// get fetches a single key, found is false if the key does not exist
+func (db *acpDB) get(ctx context.Context, key string) ([]byte, bool, error) {
+	resp, err := db.getClient().Get(ctx, &pb.GetRequest{Key: key})
+	if err != nil {
+		return nil, false, fmt.Errorf("acp get failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, false, fmt.Errorf("acp get error: %s", resp.Error)
+	}
+	return resp.Value, resp.Found, nil
+}
+
+// put writes a single key
+func (db *acpDB) put(ctx context.Context, key string, value []byte) error {
+	resp, err := db.getClient().Put(ctx, &pb.PutRequest{
+		Key:   key,
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("acp put failed: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("acp put error: %s", resp.Error)
+	}
+	if !resp.Success {
+		return fmt.Errorf("acp put unsuccessful (no specific error provided)")
+	}
+	return nil
+}
+
+// readFields fetches only the requested fields, in parallel
+// in field-granular mode the row key holds the json list of field names,
+// used when the caller asks for all fields
+func (db *acpDB) readFields(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if len(fields) == 0 {
+		index, found, err := db.get(ctx, getRowKey(table, key))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return make(map[string][]byte), nil
+		}
+		if err := json.Unmarshal(index, &fields); err != nil {
+			return nil, fmt.Errorf("failed to decode field index: %w", err)
+		}
+	}
+
+	values := make([][]byte, len(fields))
+	found := make([]bool, len(fields))
+	errs := make([]error, len(fields))
+
+	var wg sync.WaitGroup
+	for i, field := range fields {
+		wg.Add(1)
+		go func(i int, field string) {
+			defer wg.Done()
+			values[i], found[i], errs[i] = db.get(ctx, getFieldKey(table, key, field))
+		}(i, field)
+	}
+	wg.Wait()
+
+	result := make(map[string][]byte, len(fields))
+	for i, field := range fields {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if found[i] {
+			result[field] = values[i]
+		}
+	}
+	return result, nil
+}
+
+// writeFields stores each field under its own key, and the field index
+// under the row key when withIndex is set
+func (db *acpDB) writeFields(ctx context.Context, table string, key string, values map[string][]byte, withIndex bool) error {
+	fields := make([]string, 0, len(values))
+	for field, value := range values {
+		if err := db.put(ctx, getFieldKey(table, key, field), value); err != nil {
+			return err
+		}
+		fields = append(fields, field)
+	}
+
+	if !withIndex {
+		return nil
+	}
+
+	sort.Strings(fields)
+	index, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode field index: %w", err)
+	}
+	return db.put(ctx, getRowKey(table, key), index)
+}
+
 func (db *acpDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if db.fieldMode {
+		return db.readFields(ctx, table, key, fields)
+	}
+
 	client := db.getClient()
 
 	resp, err := client.Get(ctx, &pb.GetRequest{
@@ -142,11 +250,20 @@ func (db *acpDB) Scan(ctx context.Context, table string, startKey string, count
 }
 
 func (db *acpDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	// in field-granular mode only the updated fields are rewritten
+	if db.fieldMode {
+		return db.writeFields(ctx, table, key, values, false)
+	}
+
 	// acp doesn't distinguish between insert and update
 	return db.Insert(ctx, table, key, values)
 }
 
 func (db *acpDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if db.fieldMode {
+		return db.writeFields(ctx, table, key, values, true)
+	}
+
 	client := db.getClient()
 
 	// encode field map as json
@@ -0,0 +1,100 @@
+package acp
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/rachitkumar205/acp-kv/api/proto"
+)
+
+// in-memory acp service that counts value bytes returned by get
+type fakeACP struct {
+	pb.ACPServiceClient
+	mu        sync.Mutex
+	data      map[string][]byte
+	bytesRead int
+}
+
+func (f *fakeACP) Put(ctx context.Context, req *pb.PutRequest, opts ...grpc.CallOption) (*pb.PutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[req.Key] = req.Value
+	return &pb.PutResponse{Success: true}, nil
+}
+
+func (f *fakeACP) Get(ctx context.Context, req *pb.GetRequest, opts ...grpc.CallOption) (*pb.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.data[req.Key]
+	f.bytesRead += len(value)
+	return &pb.GetResponse{Found: ok, Value: value}, nil
+}
+
+func newTestDB(fieldMode bool) (*acpDB, *fakeACP) {
+	fake := &fakeACP{data: make(map[string][]byte)}
+	return &acpDB{clients: []pb.ACPServiceClient{fake}, fieldMode: fieldMode}, fake
+}
+
+func testRecord() map[string][]byte {
+	record := make(map[string][]byte)
+	for _, field := range []string{"field0", "field1", "field2", "field3"} {
+		record[field] = make([]byte, 100)
+	}
+	return record
+}
+
+func TestFieldMode_StoresFieldsSeparately(t *testing.T) {
+	db, fake := newTestDB(true)
+	ctx := context.Background()
+
+	if err := db.Insert(ctx, "usertable", "user1", testRecord()); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	if _, ok := fake.data["usertable:user1:field2"]; !ok {
+		t.Fatal("expected field to be stored under composite key")
+	}
+
+	all, err := db.Read(ctx, "usertable", "user1", nil)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected all 4 fields, got %d", len(all))
+	}
+
+	if err := db.Update(ctx, "usertable", "user1", map[string][]byte{"field1": []byte("new")}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	got, _ := db.Read(ctx, "usertable", "user1", []string{"field1"})
+	if string(got["field1"]) != "new" {
+		t.Errorf("expected updated field, got %q", got["field1"])
+	}
+}
+
+func TestFieldMode_ProjectionFetchesFewerBytes(t *testing.T) {
+	ctx := context.Background()
+
+	blobDB, blobFake := newTestDB(false)
+	blobDB.Insert(ctx, "usertable", "user1", testRecord())
+	if _, err := blobDB.Read(ctx, "usertable", "user1", []string{"field0"}); err != nil {
+		t.Fatalf("blob read failed: %v", err)
+	}
+
+	fieldDB, fieldFake := newTestDB(true)
+	fieldDB.Insert(ctx, "usertable", "user1", testRecord())
+	got, err := fieldDB.Read(ctx, "usertable", "user1", []string{"field0"})
+	if err != nil {
+		t.Fatalf("field read failed: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected only the projected field, got %d", len(got))
+	}
+	if fieldFake.bytesRead >= blobFake.bytesRead {
+		t.Errorf("expected projection to read fewer bytes, field=%d blob=%d", fieldFake.bytesRead, blobFake.bytesRead)
+	}
+}
@@ -22,8 +22,36 @@ type Adjuster struct {
 	// thresholds for adjustment
 	relaxThreshold  float64 // ccs < 0.45 triggers relax (decrease w)
 	tightenThreshold float64 // ccs > 0.75 triggers tighten (increase w)
+
+	// optional read-only step-down below a critical ccs
+	suspender *WriteSuspender
+
+	// optional staleness bound loosened at low ccs
+	loosener *StalenessLoosener
+
+	// hold adjustments until the ccs smoothing window has filled
+	skipWarmup bool
+
+	// optional recording of each cycle's ccs inputs
+	inputs *InputRecorder
+
+	// below minPeers reachable peers ccs is noise: hold adjustments and
+	// return to the default quorum (disabled when zero)
+	minPeers           int
+	defaultR, defaultW int
 }
 
+// adjuster cycle outcomes, the label of acp_adjuster_cycle_outcome_total
+const (
+	outcomeAdjust  = "adjust"
+	outcomeLockout = "lockout"
+	outcomeStable  = "stable"
+	outcomeWarmup  = "warmup"
+	outcomeError   = "error"
+	outcomeTooFew  = "too_few_peers"
+	outcomeHeld    = "manual_hold"
+)
+
 // coordinatorinterface defines methods needed from coordinator
 type CoordinatorInterface interface {
 	GetPeerAddresses() []string
@@ -54,6 +82,37 @@ func NewAdjuster(
 	}
 }
 
+// setwritesuspender lets each cycle's smoothed ccs suspend or resume writes
+func (a *Adjuster) SetWriteSuspender(ws *WriteSuspender) {
+	a.suspender = ws
+}
+
+// setstalenessloosener lets each cycle's smoothed ccs loosen or restore the
+// staleness reject bound
+func (a *Adjuster) SetStalenessLoosener(sl *StalenessLoosener) {
+	a.loosener = sl
+}
+
+// setskipwarmup holds adjustments until the ccs smoothing window is full, so
+// the first cycles don't act on an average of a few samples
+func (a *Adjuster) SetSkipWarmup(skip bool) {
+	a.skipWarmup = skip
+}
+
+// setinputrecorder records the inputs of every cycle, see CCSInputs
+func (a *Adjuster) SetInputRecorder(r *InputRecorder) {
+	a.inputs = r
+}
+
+// setminreachablepeers holds adjustments while fewer than min peers are
+// reachable, since variance and availability over one or two peers say
+// little, and moves the quorum back to r, w instead
+func (a *Adjuster) SetMinReachablePeers(min, r, w int) {
+	a.minPeers = min
+	a.defaultR = r
+	a.defaultW = w
+}
+
 // start runs the adjuster control loop
 func (a *Adjuster) Start(ctx context.Context) {
 	ticker := time.NewTicker(a.interval)
@@ -75,8 +134,17 @@ func (a *Adjuster) Start(ctx context.Context) {
 	}
 }
 
-// adjustquorum performs a single adjustment cycle
+// adjustquorum performs a single adjustment cycle and records its duration
+// and outcome
 func (a *Adjuster) adjustQuorum() {
+	start := time.Now()
+	outcome := a.cycle()
+	a.metrics.AdjusterCycleDuration.Observe(time.Since(start).Seconds())
+	a.metrics.AdjusterCycleOutcome.WithLabelValues(outcome).Inc()
+}
+
+// cycle computes ccs and adjusts the quorum if needed, returning the outcome
+func (a *Adjuster) cycle() string {
 	// 1. gather metrics from prometheus registry
 	peers := a.coordinator.GetPeerAddresses()
 
@@ -87,7 +155,7 @@ func (a *Adjuster) adjustQuorum() {
 	latencyStats, err := a.metricsReader.GetAllPeersLatencyStats(peers)
 	if err != nil {
 		a.logger.Warn("failed to get latency stats", zap.Error(err))
-		return
+		return outcomeError
 	}
 
 	// calculate peer availability (fraction of peers reachable)
@@ -127,6 +195,19 @@ func (a *Adjuster) adjustQuorum() {
 
 	// 2. record metrics and compute ccs
 	a.ccsComputer.RecordMetrics(latencyStats.Avg, combinedAvailability, variance, errorRate, clockDrift)
+	if a.inputs != nil {
+		err := a.inputs.Record(CCSInputs{
+			Time:         time.Now(),
+			AvgRTT:       latencyStats.Avg,
+			Availability: combinedAvailability,
+			Variance:     variance,
+			ErrorRate:    errorRate,
+			ClockDrift:   clockDrift,
+		})
+		if err != nil {
+			a.logger.Warn("failed to record ccs inputs", zap.Error(err))
+		}
+	}
 
 	rawCCS, components := a.ccsComputer.ComputeCCS()
 	a.ccsComputer.AddToCCSHistory(rawCCS)
@@ -135,6 +216,13 @@ func (a *Adjuster) adjustQuorum() {
 	// 3. update prometheus gauges
 	a.ccsComputer.UpdateMetricsGauges(rawCCS, smoothedCCS, components)
 
+	if a.suspender != nil {
+		a.suspender.Update(smoothedCCS)
+	}
+	if a.loosener != nil {
+		a.loosener.Update(smoothedCCS)
+	}
+
 	// update hysteresis gauge
 	if a.quorum.IsInLockout() {
 		a.metrics.HysteresisActive.Set(1)
@@ -163,23 +251,40 @@ func (a *Adjuster) adjustQuorum() {
 		zap.Int("peer_count", len(peers)),
 		zap.Int("reachable_peers", int(latencyStats.Count)))
 
+	// a manual change outranks every automatic one, including the revert
+	if a.quorum.IsHeld() {
+		a.logger.Debug("skipping adjustment: quorum held by a manual change")
+		return outcomeHeld
+	}
+
+	if reachable := int(latencyStats.Count); reachable < a.minPeers {
+		a.logger.Info("skipping adjustment: too few reachable peers for a meaningful ccs",
+			zap.Int("reachable_peers", reachable),
+			zap.Int("min_peers", a.minPeers))
+		a.revertToDefault(currentR, currentW)
+		return outcomeTooFew
+	}
+
 	// 5. check hysteresis lockout
 	if a.quorum.IsInLockout() {
 		a.logger.Debug("skipping adjustment: in hysteresis lockout period")
-		return
+		return outcomeLockout
+	}
+
+	if a.skipWarmup && !a.ccsComputer.WarmedUp() {
+		a.logger.Debug("skipping adjustment: ccs smoothing window still filling")
+		return outcomeWarmup
 	}
 
 	// 6. evaluate thresholds and decide adjustment
 	var newR, newW int
 	var reason string
-	var shouldAdjust bool
 
 	if smoothedCCS < a.relaxThreshold {
 		// cluster unhealthy - relax consistency (decrease w, increase r)
 		newW = currentW - 1
 		newR = currentR + 1
 		reason = "relax"
-		shouldAdjust = true
 
 		a.logger.Info("ccs below relax threshold",
 			zap.Float64("smoothed_ccs", smoothedCCS),
@@ -190,7 +295,6 @@ func (a *Adjuster) adjustQuorum() {
 		newW = currentW + 1
 		newR = currentR - 1
 		reason = "tighten"
-		shouldAdjust = true
 
 		a.logger.Info("ccs above tighten threshold",
 			zap.Float64("smoothed_ccs", smoothedCCS),
@@ -199,11 +303,7 @@ func (a *Adjuster) adjustQuorum() {
 		// ccs in stable region - no adjustment needed
 		a.logger.Debug("ccs in stable region, no adjustment needed",
 			zap.Float64("smoothed_ccs", smoothedCCS))
-		return
-	}
-
-	if !shouldAdjust {
-		return
+		return outcomeStable
 	}
 
 	// 7. validate adjustment
@@ -213,7 +313,7 @@ func (a *Adjuster) adjustQuorum() {
 			zap.Int("attempted_w", newW),
 			zap.String("reason", reason),
 			zap.Error(err))
-		return
+		return outcomeError
 	}
 
 	// 8. apply adjustment
@@ -223,7 +323,7 @@ func (a *Adjuster) adjustQuorum() {
 			zap.Int("attempted_w", newW),
 			zap.String("reason", reason),
 			zap.Error(err))
-		return
+		return outcomeError
 	}
 
 	// 9. record adjustment in prometheus
@@ -238,6 +338,28 @@ func (a *Adjuster) adjustQuorum() {
 		zap.String("reason", reason),
 		zap.Float64("smoothed_ccs", smoothedCCS),
 		zap.Float64("raw_ccs", rawCCS))
+	return outcomeAdjust
+}
+
+// move the quorum back to the default, respecting the lockout
+func (a *Adjuster) revertToDefault(currentR, currentW int) {
+	if (currentR == a.defaultR && currentW == a.defaultW) || a.quorum.IsInLockout() {
+		return
+	}
+	if err := a.quorum.SetQuorum(a.defaultR, a.defaultW, outcomeTooFew); err != nil {
+		a.logger.Warn("failed to revert to default quorum",
+			zap.Int("default_r", a.defaultR),
+			zap.Int("default_w", a.defaultW),
+			zap.Error(err))
+		return
+	}
+	a.metrics.QuorumAdjustments.Inc()
+	a.metrics.QuorumAdjustmentReason.WithLabelValues(outcomeTooFew).Inc()
+	a.logger.Info("quorum reverted to default",
+		zap.Int("old_r", currentR),
+		zap.Int("new_r", a.defaultR),
+		zap.Int("old_w", currentW),
+		zap.Int("new_w", a.defaultW))
 }
 
 // ensure coordinator implements coordinatorinterface
@@ -0,0 +1,162 @@
+package adaptive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/config"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+type fakeCoordinator struct {
+	peers []string
+}
+
+func (f *fakeCoordinator) GetPeerAddresses() []string {
+	return f.peers
+}
+
+// an adjuster over a quiet cluster, raw ccs is 1
+func newTestAdjuster(r, w int, relax, tighten float64) (*Adjuster, *AdaptiveQuorum) {
+	quorum := NewAdaptiveQuorum(r, w, 3, 1, 3, 1, 3, zap.NewNop(), testMetrics)
+	adjuster := NewAdjuster(quorum, metrics.NewMetricsReader(testMetrics),
+		&fakeCoordinator{peers: []string{"adjuster-test-peer"}},
+		NewCCSComputer(zap.NewNop(), testMetrics), 0, relax, tighten, zap.NewNop(), testMetrics)
+	return adjuster, quorum
+}
+
+func TestAdjuster_CycleOutcomes(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	count := func(outcome string) float64 {
+		v, _ := reader.GetCounterValue(testMetrics.AdjusterCycleOutcome.WithLabelValues(outcome))
+		return v
+	}
+	expect := func(outcome string, run func()) {
+		t.Helper()
+		before := count(outcome)
+		run()
+		if got := count(outcome) - before; got != 1 {
+			t.Errorf("expected one %s cycle, got %v", outcome, got)
+		}
+	}
+
+	// ccs between the thresholds
+	stable, _ := newTestAdjuster(2, 2, 0, 1.5)
+	expect(outcomeStable, stable.adjustQuorum)
+
+	// ccs below relax, then locked out after the adjustment
+	relax, quorum := newTestAdjuster(2, 2, 2, 3)
+	expect(outcomeAdjust, relax.adjustQuorum)
+	if quorum.GetR() != 3 || quorum.GetW() != 1 {
+		t.Errorf("expected relax to R=3 W=1, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+	expect(outcomeLockout, relax.adjustQuorum)
+
+	// relaxing past MAX_R is rejected
+	bounded, _ := newTestAdjuster(3, 1, 2, 3)
+	expect(outcomeError, bounded.adjustQuorum)
+
+	// the smoothing window holds 10 samples
+	warming, _ := newTestAdjuster(2, 2, 2, 3)
+	warming.SetSkipWarmup(true)
+	expect(outcomeWarmup, warming.adjustQuorum)
+}
+
+func TestAdjuster_RecordsCCSInputs(t *testing.T) {
+	adjuster, _ := newTestAdjuster(2, 2, 0, 1.5)
+	var buf bytes.Buffer
+	adjuster.SetInputRecorder(NewInputRecorder(&buf))
+
+	adjuster.adjustQuorum()
+
+	inputs, err := ReadInputs(&buf)
+	if err != nil || len(inputs) != 1 {
+		t.Fatalf("expected one recorded cycle, got %v (%v)", inputs, err)
+	}
+	// with a single sample each window holds exactly what RecordMetrics got
+	cc := adjuster.ccsComputer
+	in := inputs[0]
+	got := []float64{in.AvgRTT, in.Availability, in.Variance, in.ErrorRate, in.ClockDrift}
+	want := []float64{cc.rttWindow.GetAverage(), cc.successWindow.GetAverage(), cc.varianceWindow.GetAverage(),
+		cc.errorWindow.GetAverage(), cc.clockWindow.GetAverage()}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("input %d: recorded %v, RecordMetrics got %v", i, got[i], want[i])
+		}
+	}
+	if in.Time.IsZero() {
+		t.Error("expected the inputs to be timestamped")
+	}
+}
+
+func TestAdjuster_HoldsWithTooFewReachablePeers(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	skips := func() float64 {
+		v, _ := reader.GetCounterValue(testMetrics.AdjusterCycleOutcome.WithLabelValues(outcomeTooFew))
+		return v
+	}
+
+	// ccs below relax, but only one peer is reachable
+	adjuster, quorum := newTestAdjuster(2, 2, 2, 3)
+	adjuster.SetMinReachablePeers(2, 2, 2)
+	before := skips()
+	adjuster.adjustQuorum()
+	if quorum.GetR() != 2 || quorum.GetW() != 2 {
+		t.Errorf("expected no adjustment, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+	if got := skips() - before; got != 1 {
+		t.Errorf("expected one too_few_peers cycle, got %v", got)
+	}
+
+	// a relaxed quorum goes back to the default
+	relaxed, quorum := newTestAdjuster(3, 1, 2, 3)
+	relaxed.SetMinReachablePeers(2, 2, 2)
+	relaxed.adjustQuorum()
+	if quorum.GetR() != 2 || quorum.GetW() != 2 {
+		t.Errorf("expected revert to R=2 W=2, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+}
+
+func TestAdjuster_RespectsManualHold(t *testing.T) {
+	// ccs below relax, so every unheld cycle relaxes
+	adjuster, quorum := newTestAdjuster(2, 2, 2, 3)
+	quorum.SetManualHold(100 * time.Millisecond)
+	if err := quorum.SetQuorumManual(1, 3, "operator"); err != nil {
+		t.Fatalf("manual change failed: %v", err)
+	}
+	if !quorum.IsHeld() {
+		t.Fatal("expected the manual change to hold the quorum")
+	}
+
+	adjuster.adjustQuorum()
+	if quorum.GetR() != 1 || quorum.GetW() != 3 {
+		t.Errorf("expected the manual R=1 W=3 to stick, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+
+	// once the hold expires the adjuster resumes without a lockout
+	time.Sleep(150 * time.Millisecond)
+	if quorum.IsHeld() {
+		t.Fatal("expected the hold to expire")
+	}
+	adjuster.adjustQuorum()
+	if quorum.GetR() != 2 || quorum.GetW() != 2 {
+		t.Errorf("expected relax to R=2 W=2 after the hold, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+}
+
+func TestAdjuster_AdjustsAmongShardOwners(t *testing.T) {
+	// five nodes, each key on three of them
+	cfg := &config.Config{N: 5, ShardingEnabled: true, ShardReplicas: 3}
+	quorum := NewAdaptiveQuorum(2, 2, cfg.QuorumSize(), 1, 3, 1, 3, zap.NewNop(), testMetrics)
+	adjuster := NewAdjuster(quorum, metrics.NewMetricsReader(testMetrics),
+		&fakeCoordinator{peers: []string{"node2", "node3", "node4", "node5"}},
+		NewCCSComputer(zap.NewNop(), testMetrics), 0, 2, 3, zap.NewNop(), testMetrics)
+
+	// r=3 w=1 intersects among 3 owners, though not across all 5 nodes
+	adjuster.adjustQuorum()
+	if quorum.GetR() != 3 || quorum.GetW() != 1 {
+		t.Errorf("expected relax to R=3 W=1 among the owners, got R=%d W=%d", quorum.GetR(), quorum.GetW())
+	}
+}
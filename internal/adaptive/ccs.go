@@ -1,8 +1,11 @@
 package adaptive
 
 import (
+	"fmt"
 	"math"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
 	"go.uber.org/zap"
@@ -37,6 +40,13 @@ func (mw *MetricsWindow) Add(value float64) {
 	}
 }
 
+// full reports whether the window holds size samples
+func (mw *MetricsWindow) Full() bool {
+	mw.mu.RLock()
+	defer mw.mu.RUnlock()
+	return mw.count == mw.size
+}
+
 // getaverage calculates the average of all samples in the window
 func (mw *MetricsWindow) GetAverage() float64 {
 	mw.mu.RLock()
@@ -53,6 +63,88 @@ func (mw *MetricsWindow) GetAverage() float64 {
 	return sum / float64(mw.count)
 }
 
+// sorted copy of the current samples (caller holds the lock)
+func (mw *MetricsWindow) sortedSamples() []float64 {
+	sorted := slices.Clone(mw.samples[:mw.count])
+	slices.Sort(sorted)
+	return sorted
+}
+
+// getmedian returns the median of the samples, unaffected by a single
+// extreme sample
+func (mw *MetricsWindow) GetMedian() float64 {
+	mw.mu.RLock()
+	defer mw.mu.RUnlock()
+
+	if mw.count == 0 {
+		return 0
+	}
+
+	sorted := mw.sortedSamples()
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// gettrimmedmean averages the samples after dropping the lowest and highest
+// trim fraction (0-0.5) of them, always keeping at least one sample
+func (mw *MetricsWindow) GetTrimmedMean(trim float64) float64 {
+	mw.mu.RLock()
+	defer mw.mu.RUnlock()
+
+	if mw.count == 0 {
+		return 0
+	}
+
+	sorted := mw.sortedSamples()
+	drop := int(float64(len(sorted)) * math.Max(0, math.Min(trim, 0.5)))
+	if 2*drop >= len(sorted) {
+		drop = (len(sorted) - 1) / 2
+	}
+	kept := sorted[drop : len(sorted)-drop]
+
+	sum := 0.0
+	for _, v := range kept {
+		sum += v
+	}
+	return sum / float64(len(kept))
+}
+
+// aggregation selects how a window is reduced to a single value
+type Aggregation string
+
+const (
+	AggregateMean        Aggregation = "mean"
+	AggregateMedian      Aggregation = "median"
+	AggregateTrimmedMean Aggregation = "trimmed"
+)
+
+// fraction dropped from each end by AggregateTrimmedMean
+const TrimFraction = 0.1
+
+// parseaggregation validates an aggregation name from config
+func ParseAggregation(name string) (Aggregation, error) {
+	switch a := Aggregation(name); a {
+	case AggregateMean, AggregateMedian, AggregateTrimmedMean:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown aggregation %q, want %q, %q or %q", name, AggregateMean, AggregateMedian, AggregateTrimmedMean)
+}
+
+// aggregate reduces the window with the given aggregation
+func (mw *MetricsWindow) Aggregate(a Aggregation) float64 {
+	switch a {
+	case AggregateMedian:
+		return mw.GetMedian()
+	case AggregateTrimmedMean:
+		return mw.GetTrimmedMean(TrimFraction)
+	default:
+		return mw.GetAverage()
+	}
+}
+
 // getvariance calculates variance of samples in the window
 func (mw *MetricsWindow) GetVariance() float64 {
 	mw.mu.RLock()
@@ -87,6 +179,42 @@ type CCSComponents struct {
 	ClockHealth float64 // hlc clock drift health
 }
 
+// samples per ccs window unless sized for a horizon
+const DefaultWindowSize = 10
+
+// bounds on a window sized for a horizon
+const (
+	minWindowSize = 2
+	maxWindowSize = 1000
+)
+
+// windowsizeforhorizon returns how many samples taken every interval cover
+// horizon, so smoothing spans the same real time whatever the interval.
+// falls back to DefaultWindowSize when either is unset
+func WindowSizeForHorizon(horizon, interval time.Duration) int {
+	if horizon <= 0 || interval <= 0 {
+		return DefaultWindowSize
+	}
+	n := int((horizon + interval - 1) / interval)
+	return min(max(n, minWindowSize), maxWindowSize)
+}
+
+// smoothinghorizon is the real time a window of windowSize samples taken
+// every interval spans
+func SmoothingHorizon(interval time.Duration, windowSize int) time.Duration {
+	return interval * time.Duration(windowSize)
+}
+
+// checkhorizon reports an error when the windows span less than minHorizon:
+// samples that close together are highly correlated, so smoothing over them
+// does little and the controller reacts to noise
+func CheckHorizon(interval time.Duration, windowSize int, minHorizon time.Duration) error {
+	if horizon := SmoothingHorizon(interval, windowSize); horizon < minHorizon {
+		return fmt.Errorf("ccs windows of %d samples every %v span %v, less than %v", windowSize, interval, horizon, minHorizon)
+	}
+	return nil
+}
+
 // ccscomputer computes consistency confidence score from metrics
 type CCSComputer struct {
 	mu sync.RWMutex
@@ -101,6 +229,10 @@ type CCSComputer struct {
 	// ccs history for smoothing
 	ccsHistory *MetricsWindow
 
+	// how the rtt and variance windows are reduced, robust options keep a
+	// single spike (e.g. a gc pause) from dragging ccs for a whole window
+	spikeAggregation Aggregation
+
 	// weights for ccs components (must sum to 1.0)
 	alphaRTT     float64 // weight for rtt health
 	betaAvail    float64 // weight for availability health
@@ -119,18 +251,23 @@ type CCSComputer struct {
 
 // newccscomputer creates a new ccs computation engine
 func NewCCSComputer(logger *zap.Logger, m *metrics.Metrics) *CCSComputer {
+	// nothing has been sampled yet
+	if m != nil {
+		m.CCSWarmup.Set(1)
+	}
 	return &CCSComputer{
-		rttWindow:      NewMetricsWindow(10),
-		successWindow:  NewMetricsWindow(10),
-		varianceWindow: NewMetricsWindow(10),
-		errorWindow:    NewMetricsWindow(10),
-		clockWindow:    NewMetricsWindow(10),
-		ccsHistory:     NewMetricsWindow(10),
-		alphaRTT:        0.20, // rtt health
-		betaAvail:       0.40, // INCREASED - availability is critical
-		gammaVar:        0.15, // variance health
-		deltaError:      0.15, // error health
-		epsilonClock:    0.10, // clock health
+		rttWindow:         NewMetricsWindow(DefaultWindowSize),
+		successWindow:     NewMetricsWindow(DefaultWindowSize),
+		varianceWindow:    NewMetricsWindow(DefaultWindowSize),
+		errorWindow:       NewMetricsWindow(DefaultWindowSize),
+		clockWindow:       NewMetricsWindow(DefaultWindowSize),
+		ccsHistory:        NewMetricsWindow(DefaultWindowSize),
+		spikeAggregation:  AggregateMean,
+		alphaRTT:          0.20,   // rtt health
+		betaAvail:         0.40,   // INCREASED - availability is critical
+		gammaVar:          0.15,   // variance health
+		deltaError:        0.15,   // error health
+		epsilonClock:      0.10,   // clock health
 		rttBadThreshold:   0.2,    // 200ms
 		varBadThreshold:   0.0025, // 50ms² = 0.05²
 		clockBadThreshold: 0.1,    // 100ms
@@ -139,6 +276,27 @@ func NewCCSComputer(logger *zap.Logger, m *metrics.Metrics) *CCSComputer {
 	}
 }
 
+// setspikeaggregation selects the aggregation for the rtt and variance
+// windows, mean by default
+func (cc *CCSComputer) SetSpikeAggregation(a Aggregation) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.spikeAggregation = a
+}
+
+// setwindowsize replaces every window with an empty one of n samples, see
+// WindowSizeForHorizon. call before recording metrics
+func (cc *CCSComputer) SetWindowSize(n int) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.rttWindow = NewMetricsWindow(n)
+	cc.successWindow = NewMetricsWindow(n)
+	cc.varianceWindow = NewMetricsWindow(n)
+	cc.errorWindow = NewMetricsWindow(n)
+	cc.clockWindow = NewMetricsWindow(n)
+	cc.ccsHistory = NewMetricsWindow(n)
+}
+
 // recordmetrics records a new set of metrics for ccs computation
 func (cc *CCSComputer) RecordMetrics(avgRTT, successRate, variance, errorRate, clockDrift float64) {
 	cc.mu.Lock()
@@ -157,9 +315,9 @@ func (cc *CCSComputer) ComputeCCS() (float64, CCSComponents) {
 	defer cc.mu.RUnlock()
 
 	// get latest values from windows
-	avgRTT := cc.rttWindow.GetAverage()
+	avgRTT := cc.rttWindow.Aggregate(cc.spikeAggregation)
 	successRate := cc.successWindow.GetAverage()
-	variance := cc.varianceWindow.GetAverage()
+	variance := cc.varianceWindow.Aggregate(cc.spikeAggregation)
 	errorRate := cc.errorWindow.GetAverage()
 	clockDrift := cc.clockWindow.GetAverage()
 
@@ -207,10 +365,24 @@ func (cc *CCSComputer) GetSmoothedCCS() float64 {
 	return cc.ccsHistory.GetAverage()
 }
 
+// warmedup reports whether the smoothing window has filled, before that the
+// smoothed ccs averages fewer samples than configured
+func (cc *CCSComputer) WarmedUp() bool {
+	return cc.ccsHistory.Full()
+}
+
 // updatemetricsguages updates prometheus gauges for ccs components
 func (cc *CCSComputer) UpdateMetricsGauges(rawCCS, smoothedCCS float64, components CCSComponents) {
+	if cc.metrics == nil {
+		return
+	}
 	cc.metrics.CCSRaw.Set(rawCCS)
 	cc.metrics.CCSSmoothed.Set(smoothedCCS)
+	if cc.WarmedUp() {
+		cc.metrics.CCSWarmup.Set(0)
+	} else {
+		cc.metrics.CCSWarmup.Set(1)
+	}
 	cc.metrics.CCSComponentRTT.Set(components.RTTHealth)
 	cc.metrics.CCSComponentAvail.Set(components.AvailHealth)
 	cc.metrics.CCSComponentVar.Set(components.VarHealth)
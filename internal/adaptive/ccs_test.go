@@ -0,0 +1,194 @@
+package adaptive
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/staleness"
+	"go.uber.org/zap"
+)
+
+// shared metrics instance to avoid duplicate registration
+var testMetrics = metrics.NewMetrics("test")
+
+// ten 10ms rtt samples with one 2s spike
+func spikedWindow() *MetricsWindow {
+	mw := NewMetricsWindow(10)
+	for i := 0; i < 9; i++ {
+		mw.Add(0.010)
+	}
+	mw.Add(2.0)
+	return mw
+}
+
+func TestMetricsWindow_RobustAggregationIgnoresSpike(t *testing.T) {
+	mw := spikedWindow()
+
+	mean := mw.GetAverage()
+	median := mw.GetMedian()
+	trimmed := mw.GetTrimmedMean(TrimFraction)
+
+	// a single spike drags the mean far past the 200ms bad threshold
+	if mean < 0.2 {
+		t.Fatalf("expected the spike to dominate the mean, got %v", mean)
+	}
+	if math.Abs(median-0.010) > 1e-9 {
+		t.Errorf("expected median to ignore the spike, got %v", median)
+	}
+	if math.Abs(trimmed-0.010) > 1e-9 {
+		t.Errorf("expected trimmed mean to ignore the spike, got %v", trimmed)
+	}
+}
+
+func TestMetricsWindow_MedianAndTrimmedMean(t *testing.T) {
+	mw := NewMetricsWindow(10)
+	if mw.GetMedian() != 0 || mw.GetTrimmedMean(TrimFraction) != 0 {
+		t.Error("expected empty window to aggregate to 0")
+	}
+
+	for _, v := range []float64{4, 1, 3, 2} {
+		mw.Add(v)
+	}
+	if got := mw.GetMedian(); got != 2.5 {
+		t.Errorf("expected even-count median 2.5, got %v", got)
+	}
+	// 25% of 4 samples drops one from each end
+	if got := mw.GetTrimmedMean(0.25); got != 2.5 {
+		t.Errorf("expected trimmed mean 2.5, got %v", got)
+	}
+	// trimming never drops every sample
+	if got := mw.GetTrimmedMean(0.5); got != 2.5 {
+		t.Errorf("expected maximal trim to keep the middle samples, got %v", got)
+	}
+}
+
+func TestCCSComputer_SpikeAggregation(t *testing.T) {
+	cc := NewCCSComputer(nil, nil)
+	for i := 0; i < 9; i++ {
+		cc.RecordMetrics(0.010, 1, 0, 0, 0)
+	}
+	cc.RecordMetrics(2.0, 1, 0, 0, 0)
+
+	_, meanComponents := cc.ComputeCCS()
+	if meanComponents.RTTHealth != 0 {
+		t.Errorf("expected the spike to zero rtt health under mean, got %v", meanComponents.RTTHealth)
+	}
+
+	cc.SetSpikeAggregation(AggregateMedian)
+	_, medianComponents := cc.ComputeCCS()
+	if medianComponents.RTTHealth < 0.9 {
+		t.Errorf("expected rtt health to stay high under median, got %v", medianComponents.RTTHealth)
+	}
+}
+
+func TestParseAggregation(t *testing.T) {
+	for _, name := range []string{"mean", "median", "trimmed"} {
+		if _, err := ParseAggregation(name); err != nil {
+			t.Errorf("expected %q to parse, got %v", name, err)
+		}
+	}
+	if _, err := ParseAggregation("max"); err == nil {
+		t.Error("expected unknown aggregation to be rejected")
+	}
+}
+
+func TestStalenessLoosener_LowCCSLoosensWithinCeiling(t *testing.T) {
+	detector := staleness.NewDetector(3*time.Second, testMetrics)
+	sl := NewStalenessLoosener(detector, 0.5, 13*time.Second, zap.NewNop())
+
+	tests := []struct {
+		ccs  float64
+		want time.Duration
+	}{
+		{0.9, 3 * time.Second},  // healthy: configured bound
+		{0.5, 3 * time.Second},  // at threshold: not loosened yet
+		{0.25, 8 * time.Second}, // halfway down: halfway to the ceiling
+		{0, 13 * time.Second},   // fully degraded: ceiling
+		{-1, 13 * time.Second},  // never past the ceiling
+	}
+	for _, tt := range tests {
+		sl.Update(tt.ccs)
+		if got := detector.RejectAge(); got != tt.want {
+			t.Errorf("ccs %v: expected reject age %v, got %v", tt.ccs, tt.want, got)
+		}
+	}
+
+	// tightens back as ccs recovers
+	sl.Update(0.8)
+	if got := detector.RejectAge(); got != 3*time.Second {
+		t.Errorf("expected bound restored after recovery, got %v", got)
+	}
+}
+
+func TestWindowSizeForHorizon(t *testing.T) {
+	tests := []struct {
+		name     string
+		horizon  time.Duration
+		interval time.Duration
+		want     int
+	}{
+		{"unset horizon", 0, 2 * time.Second, DefaultWindowSize},
+		{"unset interval", 20 * time.Second, 0, DefaultWindowSize},
+		{"exact", 20 * time.Second, 2 * time.Second, 10},
+		{"short interval", 20 * time.Second, 500 * time.Millisecond, 40},
+		{"rounds up", 5 * time.Second, 2 * time.Second, 3},
+		{"at least two samples", time.Second, 2 * time.Second, 2},
+		{"capped", time.Hour, time.Millisecond, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WindowSizeForHorizon(tt.horizon, tt.interval)
+			if got != tt.want {
+				t.Errorf("WindowSizeForHorizon(%v, %v) = %d, want %d", tt.horizon, tt.interval, got, tt.want)
+			}
+			if tt.horizon > 0 && tt.interval > 0 && got < maxWindowSize && SmoothingHorizon(tt.interval, got) < tt.horizon {
+				t.Errorf("%d samples every %v don't cover %v", got, tt.interval, tt.horizon)
+			}
+		})
+	}
+}
+
+func TestCheckHorizon(t *testing.T) {
+	// default 10 samples at the default 2s interval cover 20s
+	if err := CheckHorizon(2*time.Second, DefaultWindowSize, 10*time.Second); err != nil {
+		t.Errorf("expected default settings to pass, got %v", err)
+	}
+
+	// a 100ms interval with 10 samples only covers a second
+	if err := CheckHorizon(100*time.Millisecond, DefaultWindowSize, 10*time.Second); err == nil {
+		t.Error("expected a warning for a 1s horizon")
+	}
+
+	// sizing the window for the horizon fixes it
+	n := WindowSizeForHorizon(10*time.Second, 100*time.Millisecond)
+	if err := CheckHorizon(100*time.Millisecond, n, 10*time.Second); err != nil {
+		t.Errorf("expected a window sized for the horizon to pass, got %v", err)
+	}
+}
+
+func TestCCSComputer_WarmupGauge(t *testing.T) {
+	cc := NewCCSComputer(zap.NewNop(), testMetrics)
+	cc.SetWindowSize(3)
+	reader := metrics.NewMetricsReader(testMetrics)
+	warmup := func() float64 {
+		v, _ := reader.GetGaugeValue(testMetrics.CCSWarmup)
+		return v
+	}
+
+	if warmup() != 1 {
+		t.Fatal("expected warmup before any sample")
+	}
+	for i := 0; i < 3; i++ {
+		if warmup() != 1 {
+			t.Fatalf("expected warmup with %d of 3 samples", i)
+		}
+		cc.AddToCCSHistory(0.9)
+		cc.UpdateMetricsGauges(0.9, cc.GetSmoothedCCS(), CCSComponents{})
+	}
+	if warmup() != 0 {
+		t.Error("expected warmup to end once the window filled")
+	}
+}
@@ -0,0 +1,71 @@
+package adaptive
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ccsinputs are the values one adjuster cycle fed into RecordMetrics, so a
+// run can be replayed offline through other weights and thresholds
+type CCSInputs struct {
+	Time         time.Time `json:"time"`
+	AvgRTT       float64   `json:"avg_rtt"`      // seconds
+	Availability float64   `json:"availability"` // write success rate times peer availability
+	Variance     float64   `json:"variance"`
+	ErrorRate    float64   `json:"error_rate"`
+	ClockDrift   float64   `json:"clock_drift"` // seconds
+}
+
+// inputrecorder appends ccs inputs as json lines
+type InputRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer // nil when writing to a caller's writer
+}
+
+// newinputrecorder writes to w, which the caller closes
+func NewInputRecorder(w io.Writer) *InputRecorder {
+	return &InputRecorder{enc: json.NewEncoder(w)}
+}
+
+// openinputrecorder appends to path, creating it if needed
+func OpenInputRecorder(path string) (*InputRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &InputRecorder{enc: json.NewEncoder(f), c: f}, nil
+}
+
+// record writes one cycle's inputs
+func (r *InputRecorder) Record(in CCSInputs) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(in)
+}
+
+// close closes the file opened by OpenInputRecorder
+func (r *InputRecorder) Close() error {
+	if r.c == nil {
+		return nil
+	}
+	return r.c.Close()
+}
+
+// readinputs parses a recording back in cycle order
+func ReadInputs(r io.Reader) ([]CCSInputs, error) {
+	var inputs []CCSInputs
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var in CCSInputs
+		if err := json.Unmarshal(scanner.Bytes(), &in); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs, scanner.Err()
+}
@@ -36,6 +36,10 @@ type AdaptiveQuorum struct {
 	lastAdjustTime  time.Time
 	lockoutDuration time.Duration
 
+	// manual changes hold off automatic ones until heldUntil
+	manualHold time.Duration
+	heldUntil  time.Time
+
 	// dependencies
 	logger  *zap.Logger
 	metrics *metrics.Metrics
@@ -78,19 +82,66 @@ func (aq *AdaptiveQuorum) GetW() int {
 
 // GetN returns cluster size
 func (aq *AdaptiveQuorum) GetN() int {
+	aq.mu.RLock()
+	defer aq.mu.RUnlock()
 	return aq.n
 }
 
+// SetN updates cluster size after a runtime peer list change
+func (aq *AdaptiveQuorum) SetN(n int) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+	aq.n = n
+}
+
+// SetManualHold makes every SetQuorumManual change stick for d: automatic
+// adjustments are rejected until it passes. 0 (the default) lets the adjuster
+// override a manual change on its next cycle
+func (aq *AdaptiveQuorum) SetManualHold(d time.Duration) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+	aq.manualHold = d
+}
+
 // SetQuorum atomically updates quorum parameters with validation
 func (aq *AdaptiveQuorum) SetQuorum(newR, newW int, reason string) error {
 	aq.mu.Lock()
 	defer aq.mu.Unlock()
 
+	// a manual change holds until it expires
+	if time.Now().Before(aq.heldUntil) {
+		return fmt.Errorf("adjustment rejected: manual hold until %v", aq.heldUntil)
+	}
+
 	// check hysteresis lockout
 	if time.Since(aq.lastAdjustTime) < aq.lockoutDuration {
 		return fmt.Errorf("adjustment rejected: in hysteresis lockout period")
 	}
 
+	if err := aq.apply(newR, newW, reason); err != nil {
+		return err
+	}
+	aq.lastAdjustTime = time.Now()
+	return nil
+}
+
+// SetQuorumManual applies an operator's quorum change, ignoring the
+// hysteresis lockout, and holds off automatic adjustments for the manual
+// hold window. the hold replaces the lockout, so the adjuster resumes as
+// soon as it expires
+func (aq *AdaptiveQuorum) SetQuorumManual(newR, newW int, reason string) error {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	if err := aq.apply(newR, newW, reason); err != nil {
+		return err
+	}
+	aq.heldUntil = time.Now().Add(aq.manualHold)
+	return nil
+}
+
+// validate and apply a quorum change, callers hold aq.mu
+func (aq *AdaptiveQuorum) apply(newR, newW int, reason string) error {
 	// validate quorum intersection (r + w > n)
 	if newR+newW <= aq.n {
 		return fmt.Errorf("quorum intersection violated: r=%d + w=%d <= n=%d", newR, newW, aq.n)
@@ -111,7 +162,6 @@ func (aq *AdaptiveQuorum) SetQuorum(newR, newW int, reason string) error {
 	// apply changes
 	aq.currentR = newR
 	aq.currentW = newW
-	aq.lastAdjustTime = time.Now()
 
 	// update prometheus metrics
 	aq.metrics.CurrentR.Set(float64(newR))
@@ -135,8 +185,18 @@ func (aq *AdaptiveQuorum) IsInLockout() bool {
 	return time.Since(aq.lastAdjustTime) < aq.lockoutDuration
 }
 
+// IsHeld returns true while a manual change holds off automatic adjustments
+func (aq *AdaptiveQuorum) IsHeld() bool {
+	aq.mu.RLock()
+	defer aq.mu.RUnlock()
+	return time.Now().Before(aq.heldUntil)
+}
+
 // Validate checks if given r and w satisfy quorum requirements
 func (aq *AdaptiveQuorum) Validate(r, w int) error {
+	aq.mu.RLock()
+	defer aq.mu.RUnlock()
+
 	if r+w <= aq.n {
 		return fmt.Errorf("quorum intersection violated: r=%d + w=%d <= n=%d", r, w, aq.n)
 	}
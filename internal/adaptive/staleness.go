@@ -0,0 +1,64 @@
+package adaptive
+
+import (
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/staleness"
+	"go.uber.org/zap"
+)
+
+// stalenessloosener couples the staleness reject bound to ccs, the freshness
+// counterpart of relaxing W. at or above threshold reads are rejected past
+// MAX_STALENESS as usual; below it the bound grows linearly towards ceiling,
+// reached at ccs 0, so a degraded cluster serves flagged stale reads instead
+// of failing them. it tightens back as ccs recovers
+type StalenessLoosener struct {
+	detector  *staleness.Detector
+	threshold float64
+	ceiling   time.Duration
+	loosened  bool
+	logger    *zap.Logger
+}
+
+func NewStalenessLoosener(detector *staleness.Detector, threshold float64, ceiling time.Duration, logger *zap.Logger) *StalenessLoosener {
+	return &StalenessLoosener{
+		detector:  detector,
+		threshold: threshold,
+		ceiling:   ceiling,
+		logger:    logger,
+	}
+}
+
+// update sets the reject bound for the latest smoothed ccs
+func (sl *StalenessLoosener) Update(ccs float64) {
+	bound := sl.Bound(ccs)
+	sl.detector.SetRejectAge(bound)
+
+	loosened := bound > sl.detector.MaxAge()
+	if loosened == sl.loosened {
+		return
+	}
+	sl.loosened = loosened
+
+	if loosened {
+		sl.logger.Warn("ccs low, serving reads past the staleness bound",
+			zap.Float64("smoothed_ccs", ccs),
+			zap.Duration("max_staleness", sl.detector.MaxAge()),
+			zap.Duration("reject_age", bound))
+		return
+	}
+	sl.logger.Info("ccs recovered, staleness bound restored",
+		zap.Float64("smoothed_ccs", ccs),
+		zap.Duration("max_staleness", sl.detector.MaxAge()))
+}
+
+// bound returns the reject age for ccs, between MAX_STALENESS and ceiling
+func (sl *StalenessLoosener) Bound(ccs float64) time.Duration {
+	base := sl.detector.MaxAge()
+	if ccs >= sl.threshold || sl.ceiling <= base || sl.threshold <= 0 {
+		return base
+	}
+
+	frac := min((sl.threshold-max(ccs, 0))/sl.threshold, 1)
+	return base + time.Duration(frac*float64(sl.ceiling-base))
+}
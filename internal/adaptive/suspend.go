@@ -0,0 +1,53 @@
+package adaptive
+
+import (
+	"sync/atomic"
+
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// writesuspender puts the node in read-only mode while the smoothed ccs is
+// below a critical threshold. when the cluster is that degraded W can't be
+// met anyway, so failing puts up front saves the work of doomed replications.
+// writes resume as soon as ccs is back at or above the threshold
+type WriteSuspender struct {
+	threshold float64
+	suspended atomic.Bool
+	logger    *zap.Logger
+	metrics   *metrics.Metrics
+}
+
+func NewWriteSuspender(threshold float64, logger *zap.Logger, m *metrics.Metrics) *WriteSuspender {
+	return &WriteSuspender{
+		threshold: threshold,
+		logger:    logger,
+		metrics:   m,
+	}
+}
+
+// update enters or leaves read-only mode based on the latest smoothed ccs
+func (ws *WriteSuspender) Update(ccs float64) {
+	suspend := ccs < ws.threshold
+	if ws.suspended.Swap(suspend) == suspend {
+		return
+	}
+
+	if suspend {
+		ws.metrics.WriteSuspended.Set(1)
+		ws.logger.Error("ccs below critical threshold, node is read-only - writes suspended",
+			zap.Float64("smoothed_ccs", ccs),
+			zap.Float64("threshold", ws.threshold))
+		return
+	}
+
+	ws.metrics.WriteSuspended.Set(0)
+	ws.logger.Warn("ccs recovered, writes resumed",
+		zap.Float64("smoothed_ccs", ccs),
+		zap.Float64("threshold", ws.threshold))
+}
+
+// suspended reports whether puts are currently rejected
+func (ws *WriteSuspender) Suspended() bool {
+	return ws.suspended.Load()
+}
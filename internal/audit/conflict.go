@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// conflict is one concurrent write discarded by last-writer-wins: both
+// sides carried the same hlc and the node id tiebreak picked the winner
+type Conflict struct {
+	Time       time.Time `json:"time"`
+	Key        string    `json:"key"`
+	Source     string    `json:"source"` // "reconcile" or "read"
+	WinnerNode string    `json:"winner_node"`
+	WinnerHLC  hlc.HLC   `json:"winner_hlc"`
+	LoserNode  string    `json:"loser_node"`
+	LoserHLC   hlc.HLC   `json:"loser_hlc"`
+}
+
+// default bounds for the conflict audit log
+const (
+	DefaultBuffer   = 1024
+	DefaultMaxBytes = 10 << 20
+)
+
+// conflictlog appends conflicts as json lines to a dedicated file, separate
+// from the structured logs. Record never blocks the caller: records are
+// buffered and written in the background, and dropped (and counted) when the
+// buffer is full. when the file reaches maxBytes it is rotated to path.1,
+// replacing the previous rotation
+type ConflictLog struct {
+	path     string
+	maxBytes int64
+	logger   *zap.Logger
+	metrics  *metrics.Metrics
+
+	f    *os.File
+	size int64
+
+	queue chan Conflict
+	done  chan struct{}
+	once  sync.Once
+}
+
+// newconflictlog opens (or creates) path for appending
+func NewConflictLog(path string, maxBytes int64, buffer int, logger *zap.Logger, m *metrics.Metrics) (*ConflictLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	l := &ConflictLog{
+		path:     path,
+		maxBytes: maxBytes,
+		logger:   logger,
+		metrics:  m,
+		f:        f,
+		size:     info.Size(),
+		queue:    make(chan Conflict, buffer),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// record queues a conflict for writing, stamping it with the current time
+// if unset. must not be called after Close
+func (l *ConflictLog) Record(c Conflict) {
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+	select {
+	case l.queue <- c:
+	default:
+		l.metrics.ConflictAuditDropped.Inc()
+	}
+}
+
+func (l *ConflictLog) run() {
+	defer close(l.done)
+	for c := range l.queue {
+		if err := l.write(c); err != nil {
+			l.logger.Warn("failed to write conflict audit record",
+				zap.String("path", l.path),
+				zap.String("key", c.Key),
+				zap.Error(err))
+		}
+	}
+	l.f.Close()
+}
+
+func (l *ConflictLog) write(c Conflict) error {
+	line, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if l.maxBytes > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// move the current file to path.1 and start a new one
+func (l *ConflictLog) rotate() error {
+	l.f.Close()
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	l.size = 0
+	return nil
+}
+
+// close writes any buffered records and closes the file
+func (l *ConflictLog) Close() {
+	l.once.Do(func() { close(l.queue) })
+	<-l.done
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+var testMetrics = metrics.NewMetrics("test")
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	return n
+}
+
+func TestConflictLog_RotatesAtMaxBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conflicts.log")
+	// room for a couple of records per file
+	l, err := NewConflictLog(path, 400, DefaultBuffer, zap.NewNop(), testMetrics)
+	if err != nil {
+		t.Fatalf("failed to open conflict log: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		l.Record(Conflict{Key: "key", Source: "read", WinnerNode: "node2", LoserNode: "node1"})
+	}
+	l.Close()
+
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 400 {
+			t.Errorf("expected %s to stay within 400 bytes, got %d", p, info.Size())
+		}
+	}
+
+	if n := countLines(t, path) + countLines(t, path+".1"); n >= 10 {
+		t.Errorf("expected older records to be rotated away, found %d", n)
+	}
+}
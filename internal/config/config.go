@@ -3,31 +3,68 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
 )
 
-// configuration for an acp node
+// configuration for an acp node. tag fields holding credentials or key
+// material `redact:"true"` to keep them out of the /config endpoint
 type Config struct {
 	NodeID     string
 	ListenAddr string
 
 	// cluster config
-	Peers []string // list of peer addresses
-	N     int      // total no. of nodes
+	Peers         []string // list of peer addresses
+	N             int      // total no. of nodes
+	DiscoveryPort int      // port used when building discovered peer addresses
+
+	// max peers connected in parallel when the peer list changes
+	PeerConnectConcurrency int
+
+	// optional sharding: each key lives on ShardReplicas owner nodes picked by
+	// consistent hashing instead of on every node
+	ShardingEnabled bool
+	ShardReplicas   int    // owners per key, quorums are validated against this instead of N
+	ShardVNodes     int    // virtual nodes per member on the hash ring
+	AdvertiseAddr   string // this node's address as it appears in other nodes' PEERS
+
+	// move data held under full replication to the sharded placement: copy
+	// keys to their owners and drop them on other nodes
+	ShardMigration           bool
+	ShardMigrationKeysPerSec int    // 0 is unlimited
+	ShardMigrationCheckpoint string // file recording the last migrated key, for resuming
+
+	// node role, observers receive writes but never count toward N, R or W
+	Role          string
+	ObserverPeers []string // observer addresses, replicated to best-effort by voters
 
 	// quorum params
 	R int
 	W int
 
+	// count only replicas that returned a value toward R, not every responding node
+	RequireDistinctValueReplicas bool
+
 	// timeouts
 	ReplicationTimeout  time.Duration
+	ReplicationRetries      int           // extra attempts per peer after a transient replication error
+	ReplicationRetryBackoff time.Duration // wait before the first retry, doubled for each further one
+	MaxFanOut               int           // max concurrent peer rpcs per replicate or read, 0 is unlimited
+	ReplicationVerifyAcks   bool          // read acked writes back from peers before counting them
 	HealthProbeInterval time.Duration
+	HealthProbePayloadBytes int // padding added to each health check so rtt reflects larger messages
+	HealthProbeMode         string // "static" legacy probes without discovery, or "discovery"
 
 	// metrics
-	MetricsAddr string
+	MetricsAddr               string
+	HistogramSampleEvery      int  // observe 1 in n values into latency and data age histograms
+	AggregateReplicateLatency bool // one replicate latency series instead of one per peer
+	ConfigEndpoint            bool // serve the resolved config on /config next to /metrics
 
 	// adaptive quorum configuration
 	AdaptiveEnabled      bool
@@ -38,14 +75,115 @@ type Config struct {
 	AdaptiveInterval     time.Duration
 	CCSRelaxThreshold    float64
 	CCSTightenThreshold  float64
+	CCSWriteSuspendThreshold float64 // smoothed ccs below which puts are rejected, 0 disables
+	CCSSpikeAggregation  string // mean, median or trimmed for the rtt and variance windows
+	CCSSmoothingHorizon  time.Duration // size ccs windows to span this much time, 0 keeps 10 samples
+	CCSMinHorizon        time.Duration // warn when the ccs windows span less than this
+	AdjusterSkipWarmup   bool          // hold adjustments until the ccs smoothing window is full
+	AdjusterMinPeers     int           // hold adjustments and return to R, W below this many reachable peers, 0 disables
+	QuorumManualHold     time.Duration // how long a manual quorum change holds off the adjuster, 0 disables
+	CCSInputsFile        string        // append each cycle's ccs inputs as json lines, empty disables
 
 	// hlc and staleness configuration
 	HLCMaxDrift          time.Duration // maximum allowed clock drift
+	HLCDriftWarning      time.Duration // drift that triggers a warning before rejection
+	HLCBackwardJumpThreshold time.Duration // local clock step back that is logged and counted, 0 disables
+	HLCMaxLead               time.Duration // cap on how far the hlc runs ahead of the wall clock, 0 disables
+	HLCResolution            time.Duration // hlc physical component is truncated to a multiple of this, 0 keeps nanoseconds
+	HealthCheckClockReadOnly bool          // health checks measure drift without advancing the local clock
+	DriftQuarantineEnabled   bool          // exclude peers with repeated drift rejections from quorums
+	DriftQuarantineThreshold int           // drift rejections within the window that quarantine a peer
+	DriftQuarantineWindow    time.Duration // window over which drift rejections are counted
 	MaxStaleness         time.Duration // maximum data age before rejection
+	StalenessCeiling     time.Duration // reject bound reached as ccs falls to 0, 0 never loosens MaxStaleness
+	StalenessLoosenCCS   float64       // smoothed ccs below which the reject bound is loosened
+	StalenessBypass      bool          // honour per-request ignore_staleness on gets
 	ReconciliationEnabled bool          // enable reconciliation after partition healing
 	ReconciliationInterval time.Duration // interval for reconciliation checks
+	ReconcileLogCompaction bool          // keep only the latest write per key in the reconcile log
+	ReconcileLogMaxBytes   int           // key and value bytes the reconcile log may hold, 0 is unlimited
+	ReconcileLogMaxValue   int           // values larger than this are logged by key and hlc only, 0 keeps all
+	ReconcileLogAsync      bool          // record writes into the reconcile log off the request path
+	ReconcileAsyncBuffer   int           // writes queued for async recording before new ones are dropped
+	ReconcileConcurrency   int           // healed peers reconciled at once, longest down first; 0 is serial
+	ReconcileChunkSize     int           // log entries per reconcile chunk, 0 reconciles the whole log at once
+	ReconcileBytesPerSec   int           // applied bytes per second a chunked reconcile may reach, 0 is unlimited
+	HealingQueueSize       int           // healing events buffered for reconciliation before new ones are dropped
+	PeerBootstrap          string        // push data to newly discovered peers: "off", "log" or "full"
+	ConflictAuditFile      string        // append discarded concurrent writes to this file, empty disables
+	ConflictAuditMaxBytes  int           // size at which the conflict audit file is rotated
+
+	// storage
+	BloomFilterEnabled bool // maintain a bloom filter over stored keys
+	BloomExpectedKeys  int  // sizing hint for the bloom filter
+	HotKeyTracking     bool // track per-key access frequency
+	HotKeyTopK         int  // number of hottest keys to report
+	VersionHistory     int  // previous values kept per key for SnapshotRead, 0 disables
+	AgeEvictionThreshold time.Duration // evict values whose hlc is older than this, 0 disables
+	AgeEvictionInterval  time.Duration // how often the age eviction sweep runs
+
+	// read divergence monitoring
+	ReadDivergenceThreshold float64 // fraction of replicas disagreeing with the winner that counts as high
+	ReadDivergenceAnnotate  bool    // set Divergent on get responses above the threshold
+	ReadVerification        bool    // check quorum read results against the local store in the background
+	ExcludeStaleReplicas    bool    // ignore replicas that flagged their value stale when picking the read winner
+	ReadAgreementFastPath   bool    // answer quorum reads locally when every replica holds the local version
+	DegradedReads           bool    // serve reads that miss R with the best value gathered, flagged degraded
+
+	// allow the Flush admin rpc to wipe the node, test environments only
+	FlushEnabled bool
+
+	// time allowed to drain rpcs and finish metrics scrapes on shutdown
+	ShutdownTimeout time.Duration
+
+	// turn clients away at startup until this many peers are connected or
+	// the timeout passes, 0 serves immediately
+	StartupMinPeers       int
+	StartupBarrierTimeout time.Duration
+
+	// return Internal for a panicking rpc handler instead of crashing
+	PanicRecovery bool
+
+	// logging
+	LogBufferSize    int           // buffer log output up to this many bytes, 0 writes synchronously
+	LogFlushInterval time.Duration // write out buffered logs at least this often
+
+	// allow the ListKeys admin rpc, expensive on large stores
+	ListKeysEnabled bool
+
+	// allow gets pinned to one replica's local value, for debugging
+	PinnedReadsEnabled bool
+
+	// write failure handling
+	PutFailureMode string // "keep" leaves a quorum-failed write in place, "rollback" undoes it locally
+	WriteCoalesceWindow time.Duration // merge puts to the same key within this window into one replication, 0 disables
 }
 
+// node roles
+const (
+	RoleVoter    = "voter"
+	RoleObserver = "observer"
+)
+
+// put failure modes
+const (
+	PutFailureKeep     = "keep"
+	PutFailureRollback = "rollback"
+)
+
+// health probe modes
+const (
+	HealthProbeStatic    = "static"
+	HealthProbeDiscovery = "discovery"
+)
+
+// peer bootstrap modes
+const (
+	PeerBootstrapOff  = "off"
+	PeerBootstrapLog  = "log"
+	PeerBootstrapFull = "full"
+)
+
 // load config from env vars
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
@@ -55,10 +193,26 @@ func LoadConfig() (*Config, error) {
 		ReplicationTimeout:  getDurationEnv("REPLICATION_TIMEOUT", 500*time.Millisecond),
 		HealthProbeInterval: getDurationEnv("HEALTH_PROBE_INTERVAL", 500*time.Millisecond),
 	}
+	cfg.HealthProbePayloadBytes = getIntEnv("HEALTH_PROBE_PAYLOAD_BYTES", 0)
+	// kubernetes deployments discover peers through the headless service
+	probeMode := HealthProbeStatic
+	if os.Getenv("HEADLESS_SERVICE") != "" {
+		probeMode = HealthProbeDiscovery
+	}
+	cfg.HealthProbeMode = getEnv("HEALTH_PROBE_MODE", probeMode)
+	cfg.ReplicationRetries = getIntEnv("REPLICATION_RETRIES", 0)
+	cfg.ReplicationRetryBackoff = getDurationEnv("REPLICATION_RETRY_BACKOFF", 10*time.Millisecond)
+	cfg.MaxFanOut = getIntEnv("MAX_FANOUT", 0)
+	cfg.ReplicationVerifyAcks = getBoolEnv("REPLICATION_VERIFY_ACKS", false)
+
+	// discovered peers listen on the same port as this node unless overridden
+	cfg.DiscoveryPort = getIntEnv("DISCOVERY_PORT", listenPort(cfg.ListenAddr))
 
 	// k8s peer discovery
 	if headlessSvc := os.Getenv("HEADLESS_SERVICE"); headlessSvc != "" {
-		cfg.Peers = discoverKubernetesPeers(cfg.NodeID, headlessSvc)
+		cfg.Peers = discoverKubernetesPeers(cfg.NodeID, headlessSvc, cfg.DiscoveryPort)
+		cfg.AdvertiseAddr = fmt.Sprintf("%s.%s.%s.svc.cluster.local:%d",
+			cfg.NodeID, headlessSvc, getEnv("NAMESPACE", "default"), cfg.DiscoveryPort)
 	} else {
 		// fallback
 		peersStr := getEnv("PEERS", "")
@@ -70,26 +224,117 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	cfg.Role = getEnv("NODE_ROLE", RoleVoter)
+	if observersStr := getEnv("OBSERVER_PEERS", ""); observersStr != "" {
+		for _, observer := range strings.Split(observersStr, ",") {
+			cfg.ObserverPeers = append(cfg.ObserverPeers, strings.TrimSpace(observer))
+		}
+	}
+
+	// an observer is not part of the voting set it replicates from
 	cfg.N = len(cfg.Peers) + 1
+	if cfg.Role == RoleObserver {
+		cfg.N = len(cfg.Peers)
+	}
+	cfg.PeerConnectConcurrency = getIntEnv("PEER_CONNECT_CONCURRENCY", 8)
+
+	cfg.AdvertiseAddr = getEnv("ADVERTISE_ADDR", cfg.AdvertiseAddr)
+	cfg.ShardingEnabled = getBoolEnv("SHARDING_ENABLED", false)
+	cfg.ShardReplicas = getIntEnv("SHARD_REPLICAS", 3)
+	cfg.ShardVNodes = getIntEnv("SHARD_VNODES", 64)
+	cfg.ShardMigration = getBoolEnv("SHARD_MIGRATION_ENABLED", false)
+	cfg.ShardMigrationKeysPerSec = getIntEnv("SHARD_MIGRATION_KEYS_PER_SEC", 100)
+	cfg.ShardMigrationCheckpoint = getEnv("SHARD_MIGRATION_CHECKPOINT", "")
 
 	cfg.R = getIntEnv("QUORUM_R", 2)
 	cfg.W = getIntEnv("QUORUM_W", 2)
+	cfg.RequireDistinctValueReplicas = getBoolEnv("REQUIRE_DISTINCT_VALUE_REPLICAS", false)
 
 	// adaptive quorum configuration
 	cfg.AdaptiveEnabled = getBoolEnv("ADAPTIVE_ENABLED", false)
 	cfg.MinR = getIntEnv("MIN_R", 1)
-	cfg.MaxR = getIntEnv("MAX_R", cfg.N)
+	cfg.MaxR = getIntEnv("MAX_R", cfg.QuorumSize())
 	cfg.MinW = getIntEnv("MIN_W", 1)
-	cfg.MaxW = getIntEnv("MAX_W", cfg.N)
+	cfg.MaxW = getIntEnv("MAX_W", cfg.QuorumSize())
 	cfg.AdaptiveInterval = getDurationEnv("ADAPTIVE_INTERVAL", 2*time.Second)
 	cfg.CCSRelaxThreshold = getFloatEnv("CCS_RELAX_THRESHOLD", 0.45)
 	cfg.CCSTightenThreshold = getFloatEnv("CCS_TIGHTEN_THRESHOLD", 0.75)
+	cfg.CCSWriteSuspendThreshold = getFloatEnv("CCS_WRITE_SUSPEND_THRESHOLD", 0)
+	cfg.CCSSpikeAggregation = getEnv("CCS_SPIKE_AGGREGATION", "mean")
+	cfg.CCSSmoothingHorizon = getDurationEnv("CCS_SMOOTHING_HORIZON", 0)
+	cfg.CCSMinHorizon = getDurationEnv("CCS_MIN_HORIZON", 10*time.Second)
+	cfg.AdjusterSkipWarmup = getBoolEnv("ADJUSTER_SKIP_WARMUP", false)
+	cfg.AdjusterMinPeers = getIntEnv("ADJUSTER_MIN_REACHABLE_PEERS", 0)
+	cfg.QuorumManualHold = getDurationEnv("QUORUM_MANUAL_HOLD", 0)
+	cfg.CCSInputsFile = getEnv("CCS_INPUTS_FILE", "")
 
 	// hlc and staleness configuration
 	cfg.HLCMaxDrift = getDurationEnv("HLC_MAX_DRIFT", 500*time.Millisecond)
+	cfg.HLCDriftWarning = getDurationEnv("HLC_DRIFT_WARNING", cfg.HLCMaxDrift/2)
+	cfg.HLCBackwardJumpThreshold = getDurationEnv("HLC_BACKWARD_JUMP_THRESHOLD", time.Second)
+	cfg.HLCMaxLead = getDurationEnv("HLC_MAX_LEAD", 0)
+	cfg.HLCResolution = getDurationEnv("HLC_RESOLUTION", hlc.DefaultEncoding.Resolution)
+	cfg.HealthCheckClockReadOnly = getBoolEnv("HEALTH_CHECK_CLOCK_READ_ONLY", false)
+	cfg.DriftQuarantineEnabled = getBoolEnv("DRIFT_QUARANTINE_ENABLED", false)
+	cfg.DriftQuarantineThreshold = getIntEnv("DRIFT_QUARANTINE_THRESHOLD", 5)
+	cfg.DriftQuarantineWindow = getDurationEnv("DRIFT_QUARANTINE_WINDOW", time.Minute)
 	cfg.MaxStaleness = getDurationEnv("MAX_STALENESS", 3*time.Second)
+	cfg.StalenessCeiling = getDurationEnv("STALENESS_CEILING", 0)
+	cfg.StalenessLoosenCCS = getFloatEnv("STALENESS_LOOSEN_CCS", cfg.CCSRelaxThreshold)
+	cfg.StalenessBypass = getBoolEnv("STALENESS_BYPASS_ENABLED", false)
 	cfg.ReconciliationEnabled = getBoolEnv("RECONCILIATION_ENABLED", false)
 	cfg.ReconciliationInterval = getDurationEnv("RECONCILIATION_INTERVAL", 30*time.Second)
+	cfg.ReconcileLogCompaction = getBoolEnv("RECONCILE_LOG_COMPACTION", false)
+	cfg.ReconcileLogMaxBytes = getIntEnv("RECONCILE_LOG_MAX_BYTES", 0)
+	cfg.ReconcileLogMaxValue = getIntEnv("RECONCILE_LOG_MAX_VALUE_BYTES", 0)
+	cfg.ReconcileLogAsync = getBoolEnv("RECONCILE_LOG_ASYNC", false)
+	cfg.ReconcileAsyncBuffer = getIntEnv("RECONCILE_LOG_ASYNC_BUFFER", 4096)
+	cfg.ReconcileConcurrency = getIntEnv("RECONCILE_CONCURRENCY", 0)
+	cfg.ReconcileChunkSize = getIntEnv("RECONCILE_CHUNK_SIZE", 0)
+	cfg.ReconcileBytesPerSec = getIntEnv("RECONCILE_BYTES_PER_SEC", 0)
+	cfg.HealingQueueSize = getIntEnv("HEALING_QUEUE_SIZE", 100)
+	cfg.PeerBootstrap = getEnv("PEER_BOOTSTRAP", PeerBootstrapOff)
+	cfg.ConflictAuditFile = getEnv("CONFLICT_AUDIT_FILE", "")
+	cfg.ConflictAuditMaxBytes = getIntEnv("CONFLICT_AUDIT_MAX_BYTES", 10<<20)
+
+	// storage
+	cfg.BloomFilterEnabled = getBoolEnv("BLOOM_FILTER_ENABLED", false)
+	cfg.BloomExpectedKeys = getIntEnv("BLOOM_EXPECTED_KEYS", 100000)
+	cfg.HotKeyTracking = getBoolEnv("HOT_KEY_TRACKING_ENABLED", false)
+	cfg.HotKeyTopK = getIntEnv("HOT_KEY_TOP_K", 10)
+	cfg.VersionHistory = getIntEnv("STORE_VERSION_HISTORY", 0)
+	cfg.AgeEvictionThreshold = getDurationEnv("AGE_EVICTION_THRESHOLD", 0)
+	cfg.AgeEvictionInterval = getDurationEnv("AGE_EVICTION_INTERVAL", time.Minute)
+
+	// read divergence monitoring
+	cfg.ReadDivergenceThreshold = getFloatEnv("READ_DIVERGENCE_THRESHOLD", 0.5)
+	cfg.ReadDivergenceAnnotate = getBoolEnv("READ_DIVERGENCE_ANNOTATE", false)
+	cfg.ReadVerification = getBoolEnv("READ_VERIFICATION_ENABLED", false)
+	cfg.ExcludeStaleReplicas = getBoolEnv("EXCLUDE_STALE_REPLICAS", false)
+	cfg.ReadAgreementFastPath = getBoolEnv("READ_AGREEMENT_FAST_PATH", false)
+	cfg.DegradedReads = getBoolEnv("DEGRADED_READS_ENABLED", false)
+
+	// metrics
+	cfg.HistogramSampleEvery = getIntEnv("HISTOGRAM_SAMPLE_EVERY", 1)
+	cfg.AggregateReplicateLatency = getBoolEnv("AGGREGATE_REPLICATE_LATENCY", false)
+	cfg.ConfigEndpoint = getBoolEnv("CONFIG_ENDPOINT_ENABLED", false)
+
+	cfg.FlushEnabled = getBoolEnv("FLUSH_ENABLED", false)
+
+	cfg.ShutdownTimeout = getDurationEnv("SHUTDOWN_TIMEOUT", 10*time.Second)
+	cfg.StartupMinPeers = getIntEnv("STARTUP_MIN_PEERS", 0)
+	cfg.StartupBarrierTimeout = getDurationEnv("STARTUP_BARRIER_TIMEOUT", 30*time.Second)
+	cfg.PanicRecovery = getBoolEnv("PANIC_RECOVERY_ENABLED", true)
+
+	// logging
+	cfg.LogBufferSize = getIntEnv("LOG_BUFFER_SIZE", 0)
+	cfg.LogFlushInterval = getDurationEnv("LOG_FLUSH_INTERVAL", time.Second)
+	cfg.ListKeysEnabled = getBoolEnv("LIST_KEYS_ENABLED", false)
+	cfg.PinnedReadsEnabled = getBoolEnv("PINNED_READS_ENABLED", false)
+
+	// write failure handling
+	cfg.PutFailureMode = getEnv("PUT_FAILURE_MODE", PutFailureKeep)
+	cfg.WriteCoalesceWindow = getDurationEnv("WRITE_COALESCE_WINDOW", 0)
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -98,7 +343,20 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
-func discoverKubernetesPeers(nodeID, headlessSvc string) []string {
+// extract the port from a listen address, falling back to 8080
+func listenPort(listenAddr string) int {
+	_, portStr, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return 8080
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 8080
+	}
+	return port
+}
+
+func discoverKubernetesPeers(nodeID, headlessSvc string, port int) []string {
 	clusterSize := getIntEnv("CLUSTER_SIZE", 3)
 	namespace := getEnv("NAMESPACE", "default")
 
@@ -107,8 +365,8 @@ func discoverKubernetesPeers(nodeID, headlessSvc string) []string {
 		peerName := fmt.Sprintf("acp-node-%d", i)
 
 		if peerName != nodeID {
-			peerAddr := fmt.Sprintf("%s.%s.%s.svc.cluster.local:8080",
-				peerName, headlessSvc, namespace)
+			peerAddr := fmt.Sprintf("%s.%s.%s.svc.cluster.local:%d",
+				peerName, headlessSvc, namespace, port)
 			peers = append(peers, peerAddr)
 		}
 	}
@@ -122,23 +380,126 @@ func (c *Config) Validate() error {
 		return errors.New("NODE_ID cannot be empty")
 	}
 
+	if c.Role != RoleVoter && c.Role != RoleObserver {
+		return fmt.Errorf("NODE_ROLE must be %q or %q, got %q", RoleVoter, RoleObserver, c.Role)
+	}
+
 	if c.N < 3 {
 		return fmt.Errorf("cluster must have atleast 3 nodes, got %d", c.N)
 	}
 
-	if c.R < 1 || c.R > c.N {
-		return fmt.Errorf("R must be between 1 and %d, got %d", c.N, c.R)
+	// with sharding, quorums are formed among a key's owners
+	n := c.N
+	if c.ShardingEnabled {
+		if c.AdvertiseAddr == "" {
+			return errors.New("ADVERTISE_ADDR is required when SHARDING_ENABLED is set")
+		}
+		if c.ShardReplicas < 1 || c.ShardReplicas > c.N {
+			return fmt.Errorf("SHARD_REPLICAS must be between 1 and %d, got %d", c.N, c.ShardReplicas)
+		}
+		n = c.ShardReplicas
+		// the adaptive quorum must not move past a key's owners
+		if c.MaxR > n || c.MaxW > n {
+			return fmt.Errorf("MAX_R and MAX_W must be at most SHARD_REPLICAS (%d), got %d and %d", n, c.MaxR, c.MaxW)
+		}
+	}
+	if c.ShardMigration && !c.ShardingEnabled {
+		return errors.New("SHARD_MIGRATION_ENABLED requires SHARDING_ENABLED")
 	}
 
-	if c.W < 1 || c.W > c.N {
-		return fmt.Errorf("W must be between 1 and %d, got %d", c.N, c.W)
+	if c.R < 1 || c.R > n {
+		return fmt.Errorf("R must be between 1 and %d, got %d", n, c.R)
+	}
+
+	if c.W < 1 || c.W > n {
+		return fmt.Errorf("W must be between 1 and %d, got %d", n, c.W)
 	}
 
 	// validate quorum intersection ( R + W > N )
-	if c.R+c.W <= c.N {
+	if c.R+c.W <= n {
 		return fmt.Errorf("quorum intersection violated")
 	}
 
+	if c.StartupMinPeers > 0 && c.StartupBarrierTimeout <= 0 {
+		return fmt.Errorf("STARTUP_BARRIER_TIMEOUT must be positive, got %v", c.StartupBarrierTimeout)
+	}
+	if c.AgeEvictionThreshold > 0 && c.AgeEvictionInterval <= 0 {
+		return fmt.Errorf("AGE_EVICTION_INTERVAL must be positive, got %v", c.AgeEvictionInterval)
+	}
+
+	if c.StalenessCeiling > 0 && c.StalenessCeiling < c.MaxStaleness {
+		return fmt.Errorf("STALENESS_CEILING must be 0 or at least MAX_STALENESS (%v), got %v", c.MaxStaleness, c.StalenessCeiling)
+	}
+
+	// peers may legitimately push the clock up to HLC_MAX_DRIFT ahead
+	if c.HLCMaxLead > 0 && c.HLCMaxLead < c.HLCMaxDrift {
+		return fmt.Errorf("HLC_MAX_LEAD must be 0 or at least HLC_MAX_DRIFT (%v), got %v", c.HLCMaxDrift, c.HLCMaxLead)
+	}
+
+	if c.HLCResolution < 0 {
+		return fmt.Errorf("HLC_RESOLUTION must not be negative, got %v", c.HLCResolution)
+	}
+
+	if c.HealingQueueSize < 1 {
+		return fmt.Errorf("HEALING_QUEUE_SIZE must be positive, got %d", c.HealingQueueSize)
+	}
+
+	if c.ReconcileLogAsync && c.ReconcileAsyncBuffer < 1 {
+		return fmt.Errorf("RECONCILE_LOG_ASYNC_BUFFER must be positive, got %d", c.ReconcileAsyncBuffer)
+	}
+
+	if c.PutFailureMode != PutFailureKeep && c.PutFailureMode != PutFailureRollback {
+		return fmt.Errorf("PUT_FAILURE_MODE must be %q or %q, got %q", PutFailureKeep, PutFailureRollback, c.PutFailureMode)
+	}
+
+	if c.HealthProbeMode != HealthProbeStatic && c.HealthProbeMode != HealthProbeDiscovery {
+		return fmt.Errorf("HEALTH_PROBE_MODE must be %q or %q, got %q", HealthProbeStatic, HealthProbeDiscovery, c.HealthProbeMode)
+	}
+
+	switch c.PeerBootstrap {
+	case PeerBootstrapOff, PeerBootstrapLog, PeerBootstrapFull:
+	default:
+		return fmt.Errorf("PEER_BOOTSTRAP must be %q, %q or %q, got %q", PeerBootstrapOff, PeerBootstrapLog, PeerBootstrapFull, c.PeerBootstrap)
+	}
+
+	return nil
+}
+
+// validatepeers checks a replacement peer list (excluding this node) against
+// the current quorum sizes before it is applied at runtime. with sharding,
+// replicas is the owners per key that quorums are formed among, otherwise 0
+func ValidatePeers(peers []string, r, w, replicas int) error {
+	seen := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			return fmt.Errorf("invalid peer address %q: %w", peer, err)
+		}
+		if seen[peer] {
+			return fmt.Errorf("duplicate peer address %q", peer)
+		}
+		seen[peer] = true
+	}
+
+	n := len(peers) + 1
+	if n < 3 {
+		return fmt.Errorf("cluster must have atleast 3 nodes, got %d", n)
+	}
+
+	if replicas > 0 {
+		if n < replicas {
+			return fmt.Errorf("cluster size %d is below SHARD_REPLICAS %d", n, replicas)
+		}
+		n = replicas
+	}
+
+	if r > n || w > n {
+		return fmt.Errorf("current quorum R=%d W=%d exceeds cluster size %d", r, w, n)
+	}
+
+	if r+w <= n {
+		return fmt.Errorf("quorum intersection violated: R=%d + W=%d <= N=%d", r, w, n)
+	}
+
 	return nil
 }
 
@@ -202,3 +563,12 @@ func (c *Config) GetW() int {
 func (c *Config) GetN() int {
 	return c.N
 }
+
+// quorumsize is the number of replicas quorums are formed among: the owners
+// per key with sharding, otherwise every node
+func (c *Config) QuorumSize() int {
+	if c.ShardingEnabled {
+		return c.ShardReplicas
+	}
+	return c.N
+}
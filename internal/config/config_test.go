@@ -0,0 +1,184 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+)
+
+func TestListenPort(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected int
+	}{
+		{":8080", 8080},
+		{":9000", 9000},
+		{"0.0.0.0:7070", 7070},
+		{"invalid", 8080},
+		{":notaport", 8080},
+	}
+
+	for _, tt := range tests {
+		if got := listenPort(tt.addr); got != tt.expected {
+			t.Errorf("listenPort(%q): expected %d, got %d", tt.addr, tt.expected, got)
+		}
+	}
+}
+
+func TestDiscoverKubernetesPeers_UsesPort(t *testing.T) {
+	t.Setenv("CLUSTER_SIZE", "3")
+	t.Setenv("NAMESPACE", "default")
+
+	peers := discoverKubernetesPeers("acp-node-0", "acp-headless", 9000)
+
+	expected := []string{
+		"acp-node-1.acp-headless.default.svc.cluster.local:9000",
+		"acp-node-2.acp-headless.default.svc.cluster.local:9000",
+	}
+	if len(peers) != len(expected) {
+		t.Fatalf("expected %d peers, got %d", len(expected), len(peers))
+	}
+	for i := range expected {
+		if peers[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], peers[i])
+		}
+	}
+}
+
+func TestLoadConfig_DiscoveryPortFromListenAddr(t *testing.T) {
+	t.Setenv("LISTEN_ADDR", ":7070")
+	t.Setenv("HEADLESS_SERVICE", "acp-headless")
+	t.Setenv("NODE_ID", "acp-node-0")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.DiscoveryPort != 7070 {
+		t.Errorf("expected discovery port 7070, got %d", cfg.DiscoveryPort)
+	}
+	for _, peer := range cfg.Peers {
+		if !strings.HasSuffix(peer, ":7070") {
+			t.Errorf("expected peer address on port 7070, got %s", peer)
+		}
+	}
+
+	t.Setenv("DISCOVERY_PORT", "9000")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.DiscoveryPort != 9000 {
+		t.Errorf("expected discovery port 9000, got %d", cfg.DiscoveryPort)
+	}
+}
+
+func TestValidatePeers(t *testing.T) {
+	tests := []struct {
+		name    string
+		peers   []string
+		r, w    int
+		shards  int
+		wantErr bool
+	}{
+		{name: "valid", peers: []string{"node2:8080", "node3:8080"}, r: 2, w: 2},
+		{name: "grow cluster", peers: []string{"node2:8080", "node3:8080", "node4:8080"}, r: 2, w: 3},
+		{name: "missing port", peers: []string{"node2", "node3:8080"}, r: 2, w: 2, wantErr: true},
+		{name: "duplicate", peers: []string{"node2:8080", "node2:8080"}, r: 2, w: 2, wantErr: true},
+		{name: "too few nodes", peers: []string{"node2:8080"}, r: 1, w: 2, wantErr: true},
+		{name: "quorum exceeds n", peers: []string{"node2:8080", "node3:8080"}, r: 4, w: 2, wantErr: true},
+		{name: "no intersection", peers: []string{"node2:8080", "node3:8080", "node4:8080"}, r: 2, w: 2, wantErr: true},
+		{name: "intersection among owners", peers: []string{"node2:8080", "node3:8080", "node4:8080", "node5:8080"}, r: 2, w: 2, shards: 3},
+		{name: "fewer nodes than owners", peers: []string{"node2:8080", "node3:8080"}, r: 2, w: 3, shards: 4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePeers(tt.peers, tt.r, tt.w, tt.shards)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandler_ServesLoadedConfig(t *testing.T) {
+	t.Setenv("NODE_ID", "acp-node-0")
+	t.Setenv("PEERS", "acp-node-1:8080,acp-node-2:8080")
+	t.Setenv("HLC_MAX_DRIFT", "250ms")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	cfg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("expected json body, got %q: %v", rec.Body.String(), err)
+	}
+	if got["NodeID"] != "acp-node-0" {
+		t.Errorf("expected NodeID acp-node-0, got %v", got["NodeID"])
+	}
+	if got["HLCMaxDrift"] != "250ms" {
+		t.Errorf("expected HLCMaxDrift 250ms, got %v", got["HLCMaxDrift"])
+	}
+	if peers, _ := got["Peers"].([]any); len(peers) != 2 {
+		t.Errorf("expected 2 peers, got %v", got["Peers"])
+	}
+}
+
+func TestRedact_MarkedFields(t *testing.T) {
+	type secrets struct {
+		CertFile string
+		KeyFile  string `redact:"true"`
+		Password string `redact:"true"`
+	}
+
+	got := redact(&secrets{CertFile: "/tls/cert.pem", KeyFile: "/tls/key.pem", Password: "hunter2"})
+
+	if got["CertFile"] != "/tls/cert.pem" {
+		t.Errorf("expected unmarked field to be kept, got %v", got["CertFile"])
+	}
+	if got["KeyFile"] != redactedValue || got["Password"] != redactedValue {
+		t.Errorf("expected marked fields to be redacted, got %v", got)
+	}
+}
+
+func TestLoadConfig_HLCResolutionMakesTimestampsEncodable(t *testing.T) {
+	t.Setenv("HEADLESS_SERVICE", "acp-headless")
+	t.Setenv("NODE_ID", "acp-node-0")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.HLCResolution != hlc.DefaultEncoding.Resolution {
+		t.Fatalf("expected default resolution %v, got %v", hlc.DefaultEncoding.Resolution, cfg.HLCResolution)
+	}
+
+	// configured the way acp-node configures its clock, against the real wall clock
+	clock := hlc.NewClock("node1", time.Second)
+	clock.SetMaxLead(cfg.HLCMaxLead)
+	clock.SetResolution(cfg.HLCResolution)
+
+	var prev uint64
+	for i := 0; i < 1000; i++ {
+		h := clock.Now()
+		enc, err := h.Encode()
+		if err != nil {
+			t.Fatalf("encode %v: %v", h, err)
+		}
+		if enc <= prev {
+			t.Fatalf("expected encoded timestamps to increase, got %d after %d", enc, prev)
+		}
+		prev = enc
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// placeholder for fields tagged `redact:"true"` (credentials, key paths)
+const redactedValue = "[REDACTED]"
+
+// redacted returns the resolved config keyed by field name, with fields
+// tagged `redact:"true"` replaced and durations rendered as strings
+func (c *Config) Redacted() map[string]any {
+	return redact(c)
+}
+
+// handler serves the redacted config as json
+func (c *Config) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(c.Redacted()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func redact(v any) map[string]any {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+
+	out := make(map[string]any, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch value := rv.Field(i).Interface().(type) {
+		case time.Duration:
+			out[field.Name] = value.String()
+		default:
+			out[field.Name] = value
+		}
+		if field.Tag.Get("redact") == "true" {
+			out[field.Name] = redactedValue
+		}
+	}
+	return out
+}
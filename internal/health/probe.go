@@ -13,9 +13,11 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-// healinglistener receives notifications when partitions heal
+// healinglistener receives notifications when partitions heal. downFor is
+// how long the peer failed health checks, zero for a peer seen for the first
+// time
 type HealingListener interface {
-	NotifyHealingEvent(peer string)
+	NotifyHealingEvent(peer string, downFor time.Duration)
 }
 
 type Probe struct {
@@ -30,7 +32,22 @@ type Probe struct {
 	mu              sync.RWMutex                   // protect peers and conns maps
 	probes          map[string]context.CancelFunc  // track active probe goroutines
 	peerStatus      map[string]bool                // track peer up/down status
+	downSince       map[string]time.Time           // first failed check of peers currently down
 	healingListener HealingListener                // notified on partition healing
+
+	// lifetime of probes started by UpdatePeers, cancelled by Stop
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// max peers connected in parallel by reconcilePeers
+	connectConcurrency int
+
+	// padding sent with every health check, empty by default
+	payload []byte
+
+	// start probes the initial peers through the discovery lifecycle instead
+	// of the legacy goroutines
+	discoveryOnly bool
 }
 
 func NewProbe(nodeID string, peerAddrs []string, interval time.Duration, logger *zap.Logger, metrics *metrics.Metrics) (*Probe, error) {
@@ -44,7 +61,11 @@ func NewProbe(nodeID string, peerAddrs []string, interval time.Duration, logger
 		stopCh:     make(chan struct{}),
 		probes:     make(map[string]context.CancelFunc),
 		peerStatus: make(map[string]bool),
+		downSince:  make(map[string]time.Time),
+
+		connectConcurrency: replication.DefaultConnectConcurrency,
 	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
 
 	// establish connection to all peers
 	for _, addr := range peerAddrs {
@@ -63,6 +84,12 @@ func (p *Probe) SetHealingListener(listener HealingListener) {
 	p.healingListener = listener
 }
 
+// setconnectconcurrency bounds how many peers reconcilePeers connects to at
+// once. values below 1 are treated as 1
+func (p *Probe) SetConnectConcurrency(n int) {
+	p.connectConcurrency = max(n, 1)
+}
+
 func (p *Probe) addPeer(addr string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -81,6 +108,7 @@ func (p *Probe) addPeer(addr string) error {
 	client := proto.NewACPServiceClient(conn)
 	p.peers[addr] = client
 	p.conns[addr] = conn
+	p.metrics.ProbeConnections.Inc()
 	p.logger.Info("health probe connected to peer", zap.String("peer", addr))
 	return nil
 }
@@ -100,6 +128,7 @@ func (p *Probe) removePeer(addr string) {
 		conn.Close()
 		delete(p.peers, addr)
 		delete(p.conns, addr)
+		p.metrics.ProbeConnections.Dec()
 		p.logger.Info("health probe removed peer", zap.String("peer", addr))
 	}
 }
@@ -124,26 +153,45 @@ func (p *Probe) reconcilePeers(ctx context.Context, newPeerAddrs []string) {
 		}
 	}
 
-	// add new peers
+	// add new peers through a bounded worker pool
+	sem := make(chan struct{}, max(p.connectConcurrency, 1))
+	var wg sync.WaitGroup
 	for addr := range newPeerSet {
 		p.mu.RLock()
 		_, exists := p.peers[addr]
 		p.mu.RUnlock()
 
-		if !exists {
+		if exists {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
 			if err := p.addPeer(addr); err != nil {
 				p.logger.Warn("failed to connect to new peer for health probe",
 					zap.String("peer", addr),
 					zap.Error(err))
-			} else {
-				// start probing the new peer
-				go p.probePeer(ctx, addr)
+				return
 			}
-		}
+
+			// start probing the new peer
+			go p.probePeer(ctx, addr)
+		}(addr)
 	}
+	wg.Wait()
 }
 
-func (p *Probe) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, namespace string, interval time.Duration) {
+// replaces the probed peer list at runtime, for static clusters without dns
+// discovery. probes for new peers run until Stop
+func (p *Probe) UpdatePeers(peerAddrs []string) {
+	p.reconcilePeers(p.ctx, peerAddrs)
+}
+
+func (p *Probe) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, namespace string, port int, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -154,7 +202,7 @@ func (p *Probe) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, nam
 	for {
 		select {
 		case <-ticker.C:
-			peers, err := replication.DiscoverPeersDNS(nodeID, headlessSvc, namespace)
+			peers, err := replication.DiscoverPeersDNS(nodeID, headlessSvc, namespace, port)
 			if err != nil {
 				p.logger.Warn("health probe peer discovery failed", zap.Error(err))
 				continue
@@ -169,20 +217,35 @@ func (p *Probe) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, nam
 	}
 }
 
+// setdiscoveryonly makes Start probe the initial peers with probePeer, the
+// same cancellable per-peer lifecycle discovery uses, so a peer discovery
+// later drops is not still probed by a legacy goroutine. call before Start
+func (p *Probe) SetDiscoveryOnly(enabled bool) {
+	p.discoveryOnly = enabled
+}
+
 // begin periodic health checks
 func (p *Probe) Start() {
 	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	for addr := range p.peers {
+		if p.discoveryOnly {
+			go p.probePeer(p.ctx, addr)
+			continue
+		}
 		p.wg.Add(1)
 		go p.probePeerLegacy(addr)
 	}
-	p.mu.RUnlock()
 }
 
 // sends periodic health checks to a single peer (legacy version)
 func (p *Probe) probePeerLegacy(peerAddr string) {
 	defer p.wg.Done()
 
+	p.metrics.ActiveProbeGoroutines.Inc()
+	defer p.metrics.ActiveProbeGoroutines.Dec()
+
 	ticker := time.NewTicker(p.interval)
 	defer ticker.Stop()
 
@@ -210,6 +273,9 @@ func (p *Probe) probePeer(ctx context.Context, peerAddr string) {
 	// create cancellable context for this probe
 	probeCtx, cancel := context.WithCancel(ctx)
 
+	p.metrics.ActiveProbeGoroutines.Inc()
+	defer p.metrics.ActiveProbeGoroutines.Dec()
+
 	p.mu.Lock()
 	p.probes[peerAddr] = cancel
 	p.mu.Unlock()
@@ -242,6 +308,13 @@ func (p *Probe) probePeer(ctx context.Context, peerAddr string) {
 	}
 }
 
+// setpayloadsize pads every health check with size bytes so the measured
+// rtt reflects replication-sized messages on bandwidth-limited links rather
+// than just small-packet latency. 0 sends no padding
+func (p *Probe) SetPayloadSize(size int) {
+	p.payload = make([]byte, max(size, 0))
+}
+
 // performs a single health check
 func (p *Probe) checkPeer(client proto.ACPServiceClient, peerAddr string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -252,6 +325,7 @@ func (p *Probe) checkPeer(client proto.ACPServiceClient, peerAddr string) {
 	req := &proto.HealthRequest{
 		SourceNodeId: p.nodeID,
 		Timestamp:    start.UnixNano(),
+		Payload:      p.payload,
 	}
 
 	resp, err := client.HealthCheck(ctx, req)
@@ -270,9 +344,7 @@ func (p *Probe) checkPeer(client proto.ACPServiceClient, peerAddr string) {
 		p.metrics.Errors.WithLabelValues("health").Inc()
 
 		// mark peer as down
-		p.mu.Lock()
-		p.peerStatus[peerAddr] = false
-		p.mu.Unlock()
+		p.markDown(peerAddr, start)
 
 		return
 	}
@@ -283,24 +355,28 @@ func (p *Probe) checkPeer(client proto.ACPServiceClient, peerAddr string) {
 			zap.String("peer_node_id", resp.NodeId))
 
 		// mark peer as down
-		p.mu.Lock()
-		p.peerStatus[peerAddr] = false
-		p.mu.Unlock()
+		p.markDown(peerAddr, start)
 
 		return
 	}
 
 	// peer is now healthy
+	var downFor time.Duration
 	p.mu.Lock()
 	p.peerStatus[peerAddr] = true
+	if since, ok := p.downSince[peerAddr]; ok {
+		downFor = time.Since(since)
+		delete(p.downSince, peerAddr)
+	}
 	p.mu.Unlock()
 
 	// detect partition healing: peer was down, now up
 	if wasDown && p.healingListener != nil {
 		p.logger.Info("partition healing detected",
 			zap.String("peer", peerAddr),
-			zap.String("peer_node_id", resp.NodeId))
-		p.healingListener.NotifyHealingEvent(peerAddr)
+			zap.String("peer_node_id", resp.NodeId),
+			zap.Duration("down_for", downFor))
+		p.healingListener.NotifyHealingEvent(peerAddr, downFor)
 	}
 
 	// record succesful health check
@@ -313,18 +389,33 @@ func (p *Probe) checkPeer(client proto.ACPServiceClient, peerAddr string) {
 	p.metrics.HealthRTT.WithLabelValues(peerAddr).Set(rtt.Seconds())
 }
 
+// mark a peer down, keeping the time of its first failed check
+func (p *Probe) markDown(peerAddr string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.peerStatus[peerAddr] = false
+	if _, ok := p.downSince[peerAddr]; !ok {
+		p.downSince[peerAddr] = at
+	}
+}
+
 // stop all health probes
 func (p *Probe) Stop() {
 	close(p.stopCh)
+	p.cancel()
 	p.wg.Wait()
 
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	// close all conns
-	for _, conn := range p.conns {
+	for addr, conn := range p.conns {
 		if err := conn.Close(); err != nil {
 			p.logger.Warn("failed to close health probe connection", zap.Error(err))
 		}
+		delete(p.peers, addr)
+		delete(p.conns, addr)
+		p.metrics.ProbeConnections.Dec()
 	}
 }
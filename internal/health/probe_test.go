@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// shared metrics instance to avoid duplicate registration
+var testMetrics = metrics.NewMetrics("test")
+
+func TestProbe_GaugesReturnToBaseline(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	connBaseline, _ := reader.GetGaugeValue(testMetrics.ProbeConnections)
+	goroutineBaseline, _ := reader.GetGaugeValue(testMetrics.ActiveProbeGoroutines)
+
+	p, err := NewProbe("node1", nil, time.Hour, zap.NewNop(), testMetrics)
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p.reconcilePeers(ctx, []string{"peer1:8080", "peer2:8080"})
+
+	if v, _ := reader.GetGaugeValue(testMetrics.ProbeConnections); v != connBaseline+2 {
+		t.Fatalf("expected %v probe connections, got %v", connBaseline+2, v)
+	}
+	waitForProbeGoroutines(t, reader, goroutineBaseline+2)
+
+	// dropping a peer stops its probe and closes its connection
+	p.reconcilePeers(ctx, []string{"peer2:8080"})
+
+	if v, _ := reader.GetGaugeValue(testMetrics.ProbeConnections); v != connBaseline+1 {
+		t.Fatalf("expected %v probe connections, got %v", connBaseline+1, v)
+	}
+	waitForProbeGoroutines(t, reader, goroutineBaseline+1)
+
+	cancel()
+	p.Stop()
+
+	if v, _ := reader.GetGaugeValue(testMetrics.ProbeConnections); v != connBaseline {
+		t.Errorf("expected probe connections back at baseline %v, got %v", connBaseline, v)
+	}
+	waitForProbeGoroutines(t, reader, goroutineBaseline)
+}
+
+// probe goroutines start and exit asynchronously, poll until the gauge settles
+func waitForProbeGoroutines(t *testing.T, reader *metrics.MetricsReader, want float64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, _ := reader.GetGaugeValue(testMetrics.ActiveProbeGoroutines)
+		if v == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v active probe goroutines, got %v", want, v)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// records the size of each health check payload
+type payloadPeer struct {
+	proto.ACPServiceClient
+	sizes chan int
+}
+
+func (f *payloadPeer) HealthCheck(ctx context.Context, req *proto.HealthRequest, opts ...grpc.CallOption) (*proto.HealthResponse, error) {
+	f.sizes <- len(req.Payload)
+	time.Sleep(time.Millisecond)
+	return &proto.HealthResponse{Healthy: true, NodeId: "node2"}, nil
+}
+
+func TestProbe_SendsConfiguredPayload(t *testing.T) {
+	p, err := NewProbe("node1", nil, time.Hour, zap.NewNop(), testMetrics)
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	defer p.Stop()
+	p.SetPayloadSize(64 * 1024)
+
+	peer := &payloadPeer{sizes: make(chan int, 1)}
+	p.checkPeer(peer, "payload-peer:8080")
+
+	if size := <-peer.sizes; size != 64*1024 {
+		t.Errorf("expected a 64KiB payload, got %d bytes", size)
+	}
+	rtt, err := metrics.NewMetricsReader(testMetrics).GetHealthRTT("payload-peer:8080")
+	if err != nil || rtt <= 0 {
+		t.Errorf("expected rtt to be recorded, got %v (%v)", rtt, err)
+	}
+}
+
+func TestProbe_DiscoveryOnlySkipsLegacyGoroutines(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	goroutineBaseline, _ := reader.GetGaugeValue(testMetrics.ActiveProbeGoroutines)
+
+	p, err := NewProbe("node1", []string{"peer1:8080"}, time.Hour, zap.NewNop(), testMetrics)
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	p.SetDiscoveryOnly(true)
+	p.Start()
+
+	// the initial peer is probed through the discovery lifecycle, which
+	// registers a cancel func; legacy goroutines never do
+	waitForProbeGoroutines(t, reader, goroutineBaseline+1)
+	p.mu.RLock()
+	_, tracked := p.probes["peer1:8080"]
+	p.mu.RUnlock()
+	if !tracked {
+		t.Fatal("expected the initial peer to be probed by probePeer")
+	}
+
+	// discovery replacing the peer stops its probe and starts the new one
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.reconcilePeers(ctx, []string{"peer2:8080"})
+	waitForProbeGoroutines(t, reader, goroutineBaseline+1)
+	p.mu.RLock()
+	_, stale := p.probes["peer1:8080"]
+	p.mu.RUnlock()
+	if stale {
+		t.Error("expected the dropped peer's probe to stop")
+	}
+
+	cancel()
+	p.Stop()
+	waitForProbeGoroutines(t, reader, goroutineBaseline)
+}
+
+// fails health checks until up is set
+type flappingPeer struct {
+	proto.ACPServiceClient
+	up bool
+}
+
+func (f *flappingPeer) HealthCheck(ctx context.Context, req *proto.HealthRequest, opts ...grpc.CallOption) (*proto.HealthResponse, error) {
+	if !f.up {
+		return nil, context.DeadlineExceeded
+	}
+	return &proto.HealthResponse{Healthy: true, NodeId: "node2"}, nil
+}
+
+type healingRecorder struct {
+	downFor []time.Duration
+}
+
+func (h *healingRecorder) NotifyHealingEvent(peer string, downFor time.Duration) {
+	h.downFor = append(h.downFor, downFor)
+}
+
+func TestProbe_ReportsDowntimeOnHealing(t *testing.T) {
+	p, err := NewProbe("node1", nil, time.Hour, zap.NewNop(), testMetrics)
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	defer p.Stop()
+	listener := &healingRecorder{}
+	p.SetHealingListener(listener)
+
+	peer := &flappingPeer{}
+	p.checkPeer(peer, "flapping-peer:8080")
+	time.Sleep(10 * time.Millisecond)
+	p.checkPeer(peer, "flapping-peer:8080")
+	peer.up = true
+	p.checkPeer(peer, "flapping-peer:8080")
+
+	// downtime counts from the first failed check
+	if len(listener.downFor) != 1 || listener.downFor[0] < 10*time.Millisecond {
+		t.Errorf("expected one healing event with at least 10ms downtime, got %v", listener.downFor)
+	}
+}
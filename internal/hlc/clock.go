@@ -20,24 +20,110 @@ type Clock struct {
 	logical  int64         // current logical counter
 	nodeID   string        // this node's identifier
 	maxDrift time.Duration // maximum allowed clock drift
+
+	// early warning for drift approaching maxDrift (disabled when zero)
+	driftWarning   time.Duration
+	onDriftWarning func(remote HLC, drift time.Duration)
+
+	// wall clock readings stepping back by more than backwardJump are
+	// reported (disabled when zero)
+	wallClock      func() int64
+	lastWall       int64
+	backwardJump   time.Duration
+	onBackwardJump func(jump, lead time.Duration)
+
+	// bound on how far physical may run ahead of the wall clock (disabled when zero)
+	maxLead time.Duration
+
+	// wall clock readings are truncated to a multiple of resolution (disabled when zero)
+	resolution time.Duration
 }
 
 // create new hlc clock
 func NewClock(nodeID string, maxDrift time.Duration) *Clock {
+	now := time.Now().UnixNano()
 	return &Clock{
-		physical: time.Now().UnixNano(),
-		logical:  0,
-		nodeID:   nodeID,
-		maxDrift: maxDrift,
+		physical:  now,
+		logical:   0,
+		nodeID:    nodeID,
+		maxDrift:  maxDrift,
+		wallClock: func() int64 { return time.Now().UnixNano() },
+		lastWall:  now,
 	}
 }
 
-// generate new hlc timestamp (monotonic)
+// setbackwardjumpwarning registers a callback invoked when the wall clock
+// steps back by more than threshold between two readings, e.g. an ntp step.
+// lead is how far the hlc is ahead of the wall clock after the step. the
+// callback runs under the clock lock and must not call back into the clock
+func (c *Clock) SetBackwardJumpWarning(threshold time.Duration, onJump func(jump, lead time.Duration)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backwardJump = threshold
+	c.onBackwardJump = onJump
+}
+
+// setmaxlead caps how far the hlc physical component may run ahead of the
+// wall clock. after a large backward step the clock would otherwise keep
+// issuing timestamps from before the step, skewing every age computed from
+// them; with a cap it falls back to at most maxLead ahead instead. this gives
+// up monotonicity across the step, so timestamps issued after it may sort
+// before ones issued earlier. zero disables the cap
+func (c *Clock) SetMaxLead(maxLead time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxLead = maxLead
+}
+
+// setresolution truncates wall clock readings to a multiple of resolution, so
+// every timestamp this clock issues can be packed by an Encoding with the same
+// Resolution. ordering within one unit is carried by the logical counter.
+// nodes exchanging timestamps must share the resolution, since a remote
+// physical component is adopted as is. zero disables truncation
+func (c *Clock) SetResolution(resolution time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolution = resolution
+	if resolution > 0 {
+		// round up rather than down so the clock never moves backward
+		if rem := c.physical % resolution.Nanoseconds(); rem != 0 {
+			c.physical += resolution.Nanoseconds() - rem
+			c.logical = 0
+		}
+	}
+}
+
+// read the wall clock, reporting backward steps and applying maxLead.
+// caller holds the lock
+func (c *Clock) readWall() int64 {
+	physicalNow := c.wallClock()
+	if c.resolution > 0 {
+		physicalNow -= physicalNow % c.resolution.Nanoseconds()
+	}
+
+	if jump := c.lastWall - physicalNow; c.onBackwardJump != nil && c.backwardJump > 0 && jump > c.backwardJump.Nanoseconds() {
+		c.onBackwardJump(time.Duration(jump), time.Duration(c.physical-physicalNow))
+	}
+	c.lastWall = physicalNow
+
+	if c.maxLead > 0 && c.physical-physicalNow > c.maxLead.Nanoseconds() {
+		lead := c.maxLead.Nanoseconds()
+		if c.resolution > 0 {
+			lead -= lead % c.resolution.Nanoseconds()
+		}
+		c.physical = physicalNow + lead
+		c.logical = 0
+	}
+
+	return physicalNow
+}
+
+// generate new hlc timestamp (monotonic unless capped by SetMaxLead)
 func (c *Clock) Now() HLC {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	physicalNow := time.Now().UnixNano()
+	physicalNow := c.readWall()
 
 	if physicalNow > c.physical {
 		// physical time advanced
@@ -56,18 +142,46 @@ func (c *Clock) Now() HLC {
 	}
 }
 
+// peek reports the clock without issuing a timestamp: the wall clock when it
+// is ahead of the last issued timestamp, otherwise that timestamp. unlike Now
+// it never advances the clock, so the result may equal the next Now
+func (c *Clock) Peek() HLC {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	physicalNow := c.wallClock()
+	if c.resolution > 0 {
+		physicalNow -= physicalNow % c.resolution.Nanoseconds()
+	}
+	if physicalNow > c.physical {
+		return HLC{Physical: physicalNow, NodeID: c.nodeID}
+	}
+	return HLC{
+		Physical: c.physical,
+		Logical:  c.logical,
+		NodeID:   c.nodeID,
+	}
+}
+
+// setdriftwarning registers a callback invoked whenever a remote timestamp is
+// ahead of local time by more than threshold, including updates that are then
+// rejected for exceeding maxDrift. the callback runs under the clock lock and
+// must not call back into the clock
+func (c *Clock) SetDriftWarning(threshold time.Duration, onWarning func(remote HLC, drift time.Duration)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.driftWarning = threshold
+	c.onDriftWarning = onWarning
+}
+
 // update local clock with remote timestamp
 func (c *Clock) Update(remote HLC) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	physicalNow := time.Now().UnixNano()
-
-	// check for excessive clock drift
-	drift := remote.Physical - physicalNow
-	if drift > c.maxDrift.Nanoseconds() {
-		return fmt.Errorf("clock drift too large: remote %d ahead of local %d (max: %v)",
-			remote.Physical, physicalNow, c.maxDrift)
+	physicalNow := c.readWall()
+	if err := c.checkDrift(remote, physicalNow); err != nil {
+		return err
 	}
 
 	if remote.Physical > c.physical {
@@ -95,6 +209,31 @@ func (c *Clock) Update(remote HLC) error {
 	return nil
 }
 
+// check runs the same drift warning and rejection as update without merging
+// remote into the local clock
+func (c *Clock) Check(remote HLC) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkDrift(remote, c.readWall())
+}
+
+// checkdrift warns on and rejects remote timestamps too far ahead of
+// physicalNow. callers hold c.mu
+func (c *Clock) checkDrift(remote HLC, physicalNow int64) error {
+	// warn before drift reaches the rejection limit
+	drift := remote.Physical - physicalNow
+	if c.onDriftWarning != nil && c.driftWarning > 0 && drift > c.driftWarning.Nanoseconds() {
+		c.onDriftWarning(remote, time.Duration(drift))
+	}
+
+	// check for excessive clock drift
+	if drift > c.maxDrift.Nanoseconds() {
+		return fmt.Errorf("clock drift too large: remote %d ahead of local %d (max: %v)",
+			remote.Physical, physicalNow, c.maxDrift)
+	}
+	return nil
+}
+
 // check if h happened before other
 func (h HLC) HappensBefore(other HLC) bool {
 	if h.Physical < other.Physical {
@@ -152,7 +291,7 @@ func (h HLC) IsZero() bool {
 	return h.Physical == 0 && h.Logical == 0
 }
 
-// convert to single int64 (loses logical component)
+// convert to single int64 (loses logical component, use Encode to keep it)
 func (h HLC) ToNanos() int64 {
 	return h.Physical
 }
@@ -1,6 +1,7 @@
 package hlc
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -287,3 +288,190 @@ func TestClock_ConcurrentEvents(t *testing.T) {
 		}
 	}
 }
+
+// fakeWall is a settable wall clock for simulating clock steps
+type fakeWall struct{ now int64 }
+
+func (f *fakeWall) read() int64 { return f.now }
+
+func TestClock_BackwardJump(t *testing.T) {
+	wall := &fakeWall{now: int64(100 * time.Second)}
+	clock := NewClock("node1", 500*time.Millisecond)
+	clock.wallClock = wall.read
+	clock.lastWall = wall.now
+	clock.physical = wall.now
+
+	var jumps []time.Duration
+	clock.SetBackwardJumpWarning(time.Second, func(jump, lead time.Duration) {
+		jumps = append(jumps, jump)
+	})
+
+	before := clock.Now()
+
+	// small step back: no warning, logical counter keeps order
+	wall.now -= int64(100 * time.Millisecond)
+	if ts := clock.Now(); !ts.HappensAfter(before) {
+		t.Errorf("expected %v after %v", ts, before)
+	}
+	if len(jumps) != 0 {
+		t.Fatalf("expected no warning for a small step, got %v", jumps)
+	}
+
+	// large step back: warned once, and without a cap the hlc stays ahead
+	wall.now -= int64(5 * time.Second)
+	ts := clock.Now()
+	clock.Now()
+	if len(jumps) != 1 || jumps[0] != 5*time.Second {
+		t.Fatalf("expected one 5s jump warning, got %v", jumps)
+	}
+	if ts.Physical != before.Physical {
+		t.Errorf("expected uncapped hlc to keep the pre-step physical time, got %d", ts.Physical)
+	}
+}
+
+func TestClock_MaxLeadCapsAfterBackwardJump(t *testing.T) {
+	wall := &fakeWall{now: int64(100 * time.Second)}
+	clock := NewClock("node1", 500*time.Millisecond)
+	clock.wallClock = wall.read
+	clock.lastWall = wall.now
+	clock.physical = wall.now
+	clock.SetMaxLead(time.Second)
+
+	clock.Now()
+	wall.now -= int64(10 * time.Second)
+
+	ts := clock.Now()
+	if lead := time.Duration(ts.Physical - wall.now); lead != time.Second {
+		t.Fatalf("expected hlc capped to 1s ahead of the wall clock, got %v", lead)
+	}
+
+	// monotonic again from the capped point
+	if next := clock.Now(); !next.HappensAfter(ts) {
+		t.Errorf("expected %v after %v", next, ts)
+	}
+
+	// leads within the cap are left alone
+	wall.now += int64(500 * time.Millisecond)
+	if next := clock.Now(); next.Physical != ts.Physical {
+		t.Errorf("expected lead within the cap to be kept, got physical %d want %d", next.Physical, ts.Physical)
+	}
+}
+
+func TestClock_DriftWarning(t *testing.T) {
+	clock := NewClock("node1", 500*time.Millisecond)
+
+	warnings := 0
+	clock.SetDriftWarning(200*time.Millisecond, func(remote HLC, drift time.Duration) {
+		warnings++
+	})
+
+	// below warning threshold: no warning, accepted
+	if err := clock.Update(HLC{Physical: time.Now().UnixNano(), NodeID: "node2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warnings != 0 {
+		t.Errorf("expected no warning for small drift, got %d", warnings)
+	}
+
+	// between warning and reject thresholds: warns but accepted
+	ahead := time.Now().Add(300 * time.Millisecond).UnixNano()
+	if err := clock.Update(HLC{Physical: ahead, NodeID: "node2"}); err != nil {
+		t.Errorf("expected drift below max to be accepted, got %v", err)
+	}
+	if warnings != 1 {
+		t.Errorf("expected 1 warning, got %d", warnings)
+	}
+
+	// above reject threshold: warns and rejects
+	farAhead := time.Now().Add(time.Second).UnixNano()
+	if err := clock.Update(HLC{Physical: farAhead, NodeID: "node2"}); err == nil {
+		t.Error("expected drift above max to be rejected")
+	}
+	if warnings != 2 {
+		t.Errorf("expected 2 warnings, got %d", warnings)
+	}
+}
+
+// ops for FuzzClock_Invariants, each encoded as three bytes: op, node, arg
+const (
+	fuzzStepWall = iota // move the node's wall clock by int8(arg) ms, either way
+	fuzzNow             // issue a local timestamp
+	fuzzSend            // node+1 issues a timestamp and node receives it
+	fuzzProbe           // node receives a remote int8(arg) ns past the drift limit
+	fuzzOps
+)
+
+// runs random sequences of Now and Update across simulated nodes with their
+// own wall clocks and checks that every clock stays monotonic, that Now after
+// an accepted Update is after the remote, and that drift is rejected exactly
+// past maxDrift
+func FuzzClock_Invariants(f *testing.F) {
+	f.Add([]byte{fuzzNow, 0, 0, fuzzNow, 0, 0})
+	f.Add([]byte{fuzzSend, 0, 0, fuzzNow, 0, 0, fuzzSend, 1, 0, fuzzNow, 1, 0})
+	f.Add([]byte{fuzzStepWall, 1, 100, fuzzSend, 0, 0, fuzzNow, 0, 0}) // remote ahead
+	f.Add([]byte{fuzzStepWall, 0, 0x80, fuzzNow, 0, 0, fuzzNow, 0, 0}) // wall steps back
+	f.Add([]byte{fuzzProbe, 0, 0, fuzzProbe, 0, 1, fuzzProbe, 0, 0xff, fuzzNow, 0, 0})
+	f.Add([]byte{fuzzStepWall, 2, 127, fuzzStepWall, 2, 127, fuzzStepWall, 2, 127, fuzzStepWall, 2, 127, fuzzSend, 1, 0, fuzzNow, 1, 0})
+	f.Add([]byte{fuzzSend, 0, 0, fuzzSend, 1, 0, fuzzSend, 2, 0, fuzzStepWall, 0, 50, fuzzSend, 2, 0, fuzzNow, 2, 0})
+
+	const (
+		numNodes = 3
+		maxDrift = 500 * time.Millisecond
+	)
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		walls := make([]*fakeWall, numNodes)
+		clocks := make([]*Clock, numNodes)
+		last := make([]HLC, numNodes)
+		for i := range clocks {
+			walls[i] = &fakeWall{now: int64(1000 * time.Second)}
+			clocks[i] = NewClock(fmt.Sprintf("node%d", i), maxDrift)
+			clocks[i].wallClock = walls[i].read
+			clocks[i].lastWall = walls[i].now
+			clocks[i].physical = walls[i].now
+		}
+
+		// every timestamp a clock issues must be after the previous one
+		issue := func(n int) HLC {
+			ts := clocks[n].Now()
+			if !last[n].IsZero() && !ts.HappensAfter(last[n]) {
+				t.Fatalf("node%d issued %v, not after %v", n, ts, last[n])
+			}
+			last[n] = ts
+			return ts
+		}
+
+		// update n with remote, checking the drift boundary and causality
+		receive := func(n int, remote HLC) {
+			err := clocks[n].Update(remote)
+			drift := remote.Physical - walls[n].now
+			if rejected := drift > maxDrift.Nanoseconds(); rejected != (err != nil) {
+				t.Fatalf("node%d drift %v: rejected=%v, err=%v", n, time.Duration(drift), rejected, err)
+			}
+			ts := issue(n)
+			if err == nil && !ts.HappensAfter(remote) {
+				t.Fatalf("node%d issued %v after accepting %v", n, ts, remote)
+			}
+		}
+
+		for i := 0; i+2 < len(ops); i += 3 {
+			n := int(ops[i+1]) % numNodes
+			arg := int64(int8(ops[i+2]))
+
+			switch ops[i] % fuzzOps {
+			case fuzzStepWall:
+				walls[n].now += arg * int64(time.Millisecond)
+			case fuzzNow:
+				issue(n)
+			case fuzzSend:
+				receive(n, issue((n+1)%numNodes))
+			case fuzzProbe:
+				receive(n, HLC{
+					Physical: walls[n].now + maxDrift.Nanoseconds() + arg,
+					Logical:  int64(ops[i+1]),
+					NodeID:   "probe",
+				})
+			}
+		}
+	})
+}
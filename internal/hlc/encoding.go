@@ -0,0 +1,96 @@
+package hlc
+
+import (
+	"fmt"
+	"time"
+)
+
+// packs an hlc into a single uint64 for external systems that order by integer
+// comparison. layout, most significant bit first:
+//
+//	[ physical / Resolution : 64-LogicalBits bits ][ logical : LogicalBits bits ]
+//
+// node id is not encoded. physical must be a multiple of Resolution: truncating
+// it would let {1000ns, L5} sort above {1500ns, L0}, so Encode rejects inputs
+// it cannot order instead. a clock issuing encodable timestamps is configured
+// with Clock.SetResolution. for accepted inputs integer order matches
+// HappensBefore exactly
+type Encoding struct {
+	LogicalBits uint          // low bits reserved for the logical counter (1-63)
+	Resolution  time.Duration // precision kept for the physical component
+}
+
+// default layout: 52 bits of microseconds since the unix epoch (overflows in
+// year 2112) and 12 bits of logical counter (max 4095)
+var DefaultEncoding = Encoding{
+	LogicalBits: 12,
+	Resolution:  time.Microsecond,
+}
+
+// largest logical counter that fits the layout
+func (e Encoding) MaxLogical() int64 {
+	return int64(1)<<e.LogicalBits - 1
+}
+
+// largest physical timestamp in nanoseconds that fits the layout
+func (e Encoding) MaxPhysical() int64 {
+	units := uint64(1)<<(64-e.LogicalBits) - 1
+	if units > uint64(1<<63-1)/uint64(e.Resolution) {
+		return 1<<63 - 1
+	}
+	return int64(units) * int64(e.Resolution)
+}
+
+func (e Encoding) validate() error {
+	if e.LogicalBits == 0 || e.LogicalBits > 63 {
+		return fmt.Errorf("logical bits must be between 1 and 63, got %d", e.LogicalBits)
+	}
+	if e.Resolution <= 0 {
+		return fmt.Errorf("resolution must be positive, got %v", e.Resolution)
+	}
+	return nil
+}
+
+// pack h into a uint64, failing if either component is out of range
+func (e Encoding) Encode(h HLC) (uint64, error) {
+	if err := e.validate(); err != nil {
+		return 0, err
+	}
+	if h.Physical < 0 || h.Physical > e.MaxPhysical() {
+		return 0, fmt.Errorf("physical %d out of range for encoding (max: %d)", h.Physical, e.MaxPhysical())
+	}
+	if h.Logical < 0 || h.Logical > e.MaxLogical() {
+		return 0, fmt.Errorf("logical %d out of range for encoding (max: %d)", h.Logical, e.MaxLogical())
+	}
+	if h.Physical%int64(e.Resolution) != 0 {
+		return 0, fmt.Errorf("physical %d is not a multiple of resolution %v", h.Physical, e.Resolution)
+	}
+
+	units := uint64(h.Physical / int64(e.Resolution))
+	return units<<e.LogicalBits | uint64(h.Logical), nil
+}
+
+// unpack a value produced by Encode. node id is empty
+func (e Encoding) Decode(v uint64) (HLC, error) {
+	if err := e.validate(); err != nil {
+		return HLC{}, err
+	}
+	units := v >> e.LogicalBits
+	if units > uint64(e.MaxPhysical()/int64(e.Resolution)) {
+		return HLC{}, fmt.Errorf("encoded value %d out of range for encoding", v)
+	}
+	return HLC{
+		Physical: int64(units) * int64(e.Resolution),
+		Logical:  int64(v & uint64(e.MaxLogical())),
+	}, nil
+}
+
+// pack into a single uint64 using DefaultEncoding
+func (h HLC) Encode() (uint64, error) {
+	return DefaultEncoding.Encode(h)
+}
+
+// unpack a value produced by HLC.Encode
+func DecodeHLC(v uint64) (HLC, error) {
+	return DefaultEncoding.Decode(v)
+}
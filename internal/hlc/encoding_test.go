@@ -0,0 +1,171 @@
+package hlc
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestEncode_OrderingMatchesHappensBefore(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	res := int64(DefaultEncoding.Resolution)
+
+	// physical components on resolution boundaries, plus logical variations
+	var timestamps []HLC
+	for _, offset := range []int64{0, 1, 2, 1000, 1_000_000} {
+		for _, logical := range []int64{0, 1, 7, DefaultEncoding.MaxLogical()} {
+			timestamps = append(timestamps, HLC{Physical: base + offset*res, Logical: logical})
+		}
+	}
+
+	for _, a := range timestamps {
+		encA, err := a.Encode()
+		if err != nil {
+			t.Fatalf("encode %v: %v", a, err)
+		}
+		for _, b := range timestamps {
+			encB, err := b.Encode()
+			if err != nil {
+				t.Fatalf("encode %v: %v", b, err)
+			}
+			if a.HappensBefore(b) != (encA < encB) {
+				t.Errorf("ordering mismatch: %v before %v = %v, but %d < %d = %v",
+					a, b, a.HappensBefore(b), encA, encB, encA < encB)
+			}
+			if a.Equal(b) != (encA == encB) {
+				t.Errorf("equality mismatch for %v and %v", a, b)
+			}
+		}
+	}
+}
+
+func TestEncode_ArbitraryOffsets(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	rng := rand.New(rand.NewSource(1))
+
+	// {1000ns, L5} happens before {1500ns, L0} but shares its microsecond
+	if _, err := (HLC{Physical: base + 1500, Logical: 0}).Encode(); err == nil {
+		t.Error("expected error for physical inside a resolution unit")
+	}
+
+	var timestamps []HLC
+	for i := 0; i < 200; i++ {
+		timestamps = append(timestamps, HLC{
+			Physical: base + rng.Int63n(5000),
+			Logical:  rng.Int63n(8),
+		})
+	}
+
+	for _, a := range timestamps {
+		encA, errA := a.Encode()
+		aligned := a.Physical%int64(DefaultEncoding.Resolution) == 0
+		if aligned != (errA == nil) {
+			t.Fatalf("encode %v: aligned=%v, err=%v", a, aligned, errA)
+		}
+		if errA != nil {
+			continue
+		}
+		for _, b := range timestamps {
+			encB, errB := b.Encode()
+			if errB != nil {
+				continue
+			}
+			if a.HappensBefore(b) != (encA < encB) {
+				t.Errorf("ordering mismatch: %v before %v = %v, but %d < %d = %v",
+					a, b, a.HappensBefore(b), encA, encB, encA < encB)
+			}
+		}
+	}
+}
+
+func TestEncode_ClockWithResolution(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	rng := rand.New(rand.NewSource(1))
+
+	clock := NewClock("node1", time.Second)
+	wall := base + 123
+	clock.wallClock = func() int64 { return wall }
+	clock.SetResolution(DefaultEncoding.Resolution)
+
+	// wall clock advancing by arbitrary nanosecond steps
+	prev := clock.Now()
+	prevEnc, err := prev.Encode()
+	if err != nil {
+		t.Fatalf("encode %v: %v", prev, err)
+	}
+	for i := 0; i < 1000; i++ {
+		wall += rng.Int63n(700)
+		h := clock.Now()
+		enc, err := h.Encode()
+		if err != nil {
+			t.Fatalf("encode %v: %v", h, err)
+		}
+		if !prev.HappensBefore(h) || prevEnc >= enc {
+			t.Fatalf("expected %v (%d) before %v (%d)", prev, prevEnc, h, enc)
+		}
+		prev, prevEnc = h, enc
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	clock := NewClock("node1", time.Second)
+	clock.SetResolution(DefaultEncoding.Resolution)
+	h := clock.Now()
+	h.Logical = 42
+
+	enc, err := h.Encode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := DecodeHLC(enc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// node id is dropped
+	if got.Physical != h.Physical || got.Logical != h.Logical {
+		t.Errorf("expected physical=%d logical=%d, got %v", h.Physical, h.Logical, got)
+	}
+	if got.NodeID != "" {
+		t.Errorf("expected empty node id, got %s", got.NodeID)
+	}
+}
+
+func TestEncode_CustomSplit(t *testing.T) {
+	enc := Encoding{LogicalBits: 20, Resolution: time.Millisecond}
+
+	h := HLC{Physical: 5 * int64(time.Millisecond), Logical: 1 << 19}
+	v, err := enc.Encode(h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != 5<<20|1<<19 {
+		t.Errorf("unexpected bit layout: %b", v)
+	}
+
+	got, err := enc.Decode(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Equal(h) {
+		t.Errorf("expected %v, got %v", h, got)
+	}
+}
+
+func TestEncode_Overflow(t *testing.T) {
+	if _, err := (HLC{Physical: 1, Logical: DefaultEncoding.MaxLogical() + 1}).Encode(); err == nil {
+		t.Error("expected error for logical overflow")
+	}
+	if _, err := (HLC{Physical: -1}).Encode(); err == nil {
+		t.Error("expected error for negative physical")
+	}
+
+	small := Encoding{LogicalBits: 60, Resolution: time.Nanosecond}
+	if _, err := small.Encode(HLC{Physical: 16}); err == nil {
+		t.Error("expected error for physical overflow")
+	}
+
+	if _, err := (Encoding{LogicalBits: 0, Resolution: time.Microsecond}).Encode(HLC{}); err == nil {
+		t.Error("expected error for invalid layout")
+	}
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newbufferedproduction builds a logger equivalent to zap.NewProduction whose
+// output goes through a write buffer, so request handlers only copy the entry
+// into memory instead of waiting on the log sink. the buffer is written out
+// when it holds size bytes, every flushInterval, on logger.Sync and for
+// entries at panic or fatal level. call Stop on the returned syncer at
+// shutdown to flush whatever is left
+func NewBufferedProduction(ws zapcore.WriteSyncer, size int, flushInterval time.Duration) (*zap.Logger, *zapcore.BufferedWriteSyncer) {
+	buffered := &zapcore.BufferedWriteSyncer{
+		WS:            ws,
+		Size:          size,
+		FlushInterval: flushInterval,
+	}
+
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		buffered,
+		zap.InfoLevel,
+	)
+	// same sampling as zap.NewProduction
+	core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)), buffered
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSink holds every write until released
+type blockingSink struct {
+	release chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *blockingSink) Write(p []byte) (int, error) {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *blockingSink) Sync() error { return nil }
+
+func (s *blockingSink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestBufferedProduction_DoesNotBlockAndFlushesOnStop(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	logger, buffered := NewBufferedProduction(sink, 256*1024, time.Hour)
+
+	// a sink that never returns must not stall the caller
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Info("request handled")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected logging to return without waiting on the sink")
+	}
+	if out := sink.String(); out != "" {
+		t.Fatalf("expected entries to stay buffered, sink got %q", out)
+	}
+
+	close(sink.release)
+	if err := buffered.Stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if n := strings.Count(sink.String(), "request handled"); n != 10 {
+		t.Errorf("expected all 10 entries flushed on stop, got %d", n)
+	}
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"math/rand/v2"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -8,13 +10,33 @@ import (
 // holds all prometheus metrics
 type Metrics struct {
 	// latency histogram
-	PutLatency       prometheus.Histogram
-	GetLatency       prometheus.Histogram
-	ReplicateLatency *prometheus.HistogramVec
+	PutLatency          prometheus.Histogram
+	PutLocalLatency     prometheus.Histogram // local store write phase of a put
+	PutReplicateLatency prometheus.Histogram // time spent awaiting W acks
+	GetLatency          prometheus.Histogram
+	ReplicateLatency    *prometheus.HistogramVec
+	ReplicateReceivedLatency prometheus.Histogram // handling of inbound Replicate rpcs
+	GetLocalLatency          prometheus.Histogram // handling of inbound GetLocal rpcs
+	GRPCLatency              *prometheus.HistogramVec // every rpc, by method
 
 	// success/failure counters
-	ReplicateAcks *prometheus.CounterVec
-	Errors        *prometheus.CounterVec
+	ReplicateAcks       *prometheus.CounterVec
+	ReplicateBackground *prometheus.CounterVec // replications completed after the client was acked
+	PeerBootstrapWrites *prometheus.CounterVec // writes pushed to newly discovered peers, by result
+	MigrationKeys       *prometheus.CounterVec // placement migration actions: copied, verified, kept, dropped
+	MigrationProgress   prometheus.Gauge       // fraction of keys the placement migration has visited
+	Errors              *prometheus.CounterVec
+	RequestsAbandoned   *prometheus.CounterVec // client requests dropped because the caller's context ended
+	ReplicateReceived   *prometheus.CounterVec // inbound Replicate rpcs by result
+	GetLocalTotal       *prometheus.CounterVec // inbound GetLocal rpcs by result
+	GRPCRequests        *prometheus.CounterVec // every rpc, by method and status code
+	PanicsRecovered     *prometheus.CounterVec // handler panics turned into Internal errors, by method
+	WritesCoalesced     prometheus.Counter     // puts merged into another put's replication
+	ReplicateRetries    prometheus.Counter     // replication attempts repeated after a transient error
+	ReplicateBytes      prometheus.Counter     // wire size of replication requests sent to peers and observers
+	WriteAmplification  prometheus.Gauge       // replicated bytes per client value byte over the last interval
+	WriteHookDropped    prometheus.Counter     // commits dropped because the async write hook buffer was full
+	ConflictAuditDropped prometheus.Counter    // conflict audit records dropped because the buffer was full
 
 	// success ratios
 	WriteSuccessTotal prometheus.Counter
@@ -22,10 +44,27 @@ type Metrics struct {
 	ReadSuccessTotal  prometheus.Counter
 	ReadFailureTotal  prometheus.Counter
 
+	// how reads were answered
+	ReadsLocalServed  prometheus.Counter // reads answered from the local store without consulting peers
+	ReadsQuorumServed prometheus.Counter // reads that queried peers for a quorum
+	ReadsAgreementFastPath prometheus.Counter // quorum reads where every replica agreed with the local value
+	ReadsDegraded          prometheus.Counter // quorum reads served below R with degraded set
+
 	// quorum gauges
 	CurrentR prometheus.Gauge
 	CurrentW prometheus.Gauge
 
+	// quorum each read and write actually ran at, after overrides
+	EffectiveR prometheus.Histogram
+	EffectiveW prometheus.Histogram
+
+	// peer connection metrics
+	PeerConnectRetries     prometheus.Counter // background reconnect attempts for unreachable peers
+	SelfReplicationSkipped prometheus.Counter // peer lists that included this node's own address
+	CoordinatorConnections prometheus.Gauge   // open replication connections
+	ProbeConnections       prometheus.Gauge   // open health probe connections
+	ActiveProbeGoroutines  prometheus.Gauge   // running per-peer health probe loops
+
 	// health metrics
 	HealthRTT         *prometheus.GaugeVec
 	RTTVariance       *prometheus.GaugeVec // RTT variance per peer in ms^2
@@ -36,6 +75,7 @@ type Metrics struct {
 	// adaptive quorum metrics
 	CCSRaw               prometheus.Gauge
 	CCSSmoothed          prometheus.Gauge
+	CCSWarmup            prometheus.Gauge // 1 until the ccs smoothing window has filled
 	CCSComponentRTT      prometheus.Gauge
 	CCSComponentAvail    prometheus.Gauge
 	CCSComponentVar      prometheus.Gauge
@@ -44,10 +84,18 @@ type Metrics struct {
 	QuorumAdjustments    prometheus.Counter
 	QuorumAdjustmentReason *prometheus.CounterVec
 	HysteresisActive     prometheus.Gauge
+	AdjusterCycleDuration prometheus.Histogram
+	AdjusterCycleOutcome  *prometheus.CounterVec
+	WriteSuspended       prometheus.Gauge // 1 while puts are rejected for critically low ccs
 
 	// hlc and staleness metrics
 	HLCDrift            *prometheus.GaugeVec // drift per peer in milliseconds
+	ClockDriftWarnings  *prometheus.CounterVec // drift observations above the warning threshold per peer
+	ClockBackwardJumps  prometheus.Counter     // local wall clock steps back beyond the threshold
+	PeersQuarantined    prometheus.Gauge       // peers excluded from quorums for persistent clock drift
 	StalenessViolations prometheus.Counter   // total staleness bound violations
+	StalenessRejectAge  prometheus.Gauge     // data age above which strict reads are rejected
+	AgeEvicted          prometheus.Counter   // values removed for exceeding the age eviction threshold
 	StaleReadsRejected  prometheus.Counter   // total reads rejected due to staleness
 	DataAge             prometheus.Histogram  // distribution of data age on reads
 
@@ -57,10 +105,27 @@ type Metrics struct {
 	ReconciliationRuns    prometheus.Counter    // total reconciliation runs
 	ReconciliationKeys    prometheus.Histogram  // keys reconciled per run
 	ReconciliationLatency prometheus.Histogram  // reconciliation duration
+	ReconcileLogBytes     prometheus.Gauge      // key and value bytes held by the recent write log
+	ReconcileLogDropped   prometheus.Counter    // writes not logged because the async log buffer was full
+	ReconcileBytes        prometheus.Counter    // key and value bytes applied by reconciliation
 	PartitionHealing      prometheus.Counter    // partition healing events detected
+	HealingQueueDepth     prometheus.Gauge      // healing events waiting for reconciliation
+	HealingEventsDropped  prometheus.Counter    // healing events dropped because the queue was full
 	ReadRepair            prometheus.Counter    // read repair operations
+	ReadDivergenceHigh    prometheus.Counter    // quorum reads where too many replicas disagreed with the winner
+	ReadConsistencyAnomaly prometheus.Counter   // quorum reads that returned a value older than the local one
+
+	// access frequency
+	HotKey *prometheus.GaugeVec // estimated access count of the current top-k keys
+
+	// observation thinning for high throughput, set once at startup
+	sampleEvery               int  // observe 1 in n values into sampled histograms (<= 1 observes all)
+	aggregateReplicateLatency bool // record ReplicateLatency under AggregatePeerLabel only
 }
 
+// replicatelatency label used instead of the peer address in aggregate mode
+const AggregatePeerLabel = "all"
+
 // create and register all prometheus metrics
 func NewMetrics(namespace string) *Metrics {
 	m := &Metrics{
@@ -71,6 +136,20 @@ func NewMetrics(namespace string) *Metrics {
 			Buckets:   prometheus.DefBuckets,
 		}),
 
+		PutLocalLatency: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "put_local_latency_seconds",
+			Help:      "Latency of the local store write phase of PUT operations",
+			Buckets:   prometheus.DefBuckets,
+		}),
+
+		PutReplicateLatency: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "put_replicate_latency_seconds",
+			Help:      "Time PUT operations spend awaiting W replication acks",
+			Buckets:   prometheus.DefBuckets,
+		}),
+
 		GetLatency: promauto.NewHistogram(prometheus.HistogramOpts{
 			Namespace: namespace,
 			Name:      "get_latency_seconds",
@@ -85,18 +164,129 @@ func NewMetrics(namespace string) *Metrics {
 			Buckets:   prometheus.DefBuckets,
 		}, []string{"peer"}),
 
+		ReplicateReceivedLatency: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "replicate_received_latency_seconds",
+			Help:      "Latency of handling replication requests from peers",
+			Buckets:   prometheus.DefBuckets,
+		}),
+
+		GetLocalLatency: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "getlocal_latency_seconds",
+			Help:      "Latency of handling local reads requested by peers",
+			Buckets:   prometheus.DefBuckets,
+		}),
+
+		GRPCLatency: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_latency_seconds",
+			Help:      "Latency of every gRPC method served by the node",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+
+		GRPCRequests: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "gRPC requests served by the node by method and status code",
+		}, []string{"method", "code"}),
+
+		PanicsRecovered: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "panics_recovered_total",
+			Help:      "Panics in rpc handlers recovered and returned to the caller as Internal",
+		}, []string{"method"}),
+
+		WritesCoalesced: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "writes_coalesced_total",
+			Help:      "Puts merged into a pending replication of the same key instead of replicating on their own",
+		}),
+
+		ReplicateRetries: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "replicate_retries_total",
+			Help:      "Replication attempts retried after a transient peer error",
+		}),
+
+		ReplicateBytes: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "replicate_bytes_total",
+			Help:      "Bytes of replication requests sent to peers and observers, including retries",
+		}),
+
+		WriteAmplification: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "write_amplification",
+			Help:      "Replicated bytes per client-written value byte over the last interval",
+		}),
+
+		ReplicateReceived: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "replicate_received_total",
+			Help:      "Replication requests received from peers (applied, ignored as older than the local value)",
+		}, []string{"result"}),
+
+		GetLocalTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "getlocal_total",
+			Help:      "Local reads requested by peers (found, not_found)",
+		}, []string{"result"}),
+
 		ReplicateAcks: promauto.NewCounterVec(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "replicate_acks_total",
 			Help:      "Total replication acknowledgements",
 		}, []string{"result"}),
 
+		ReplicateBackground: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "replicate_background_total",
+			Help:      "Replications that completed after the write quorum was already met",
+		}, []string{"result"}),
+
+		PeerBootstrapWrites: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "peer_bootstrap_writes_total",
+			Help:      "Writes pushed to newly discovered peers to bootstrap them",
+		}, []string{"result"}),
+
+		MigrationKeys: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "migration_keys_total",
+			Help:      "Placement migration actions: copies sent to new owners, new owners found to hold the key already, keys kept and keys dropped here",
+		}, []string{"action"}),
+
+		MigrationProgress: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "migration_progress",
+			Help:      "Fraction of this node's keys the running placement migration has visited",
+		}),
+
 		Errors: promauto.NewCounterVec(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "errors_total",
 			Help:      "Total errors by type",
 		}, []string{"type"}),
 
+		RequestsAbandoned: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_abandoned_total",
+			Help:      "Client requests abandoned because the caller's deadline passed or it cancelled",
+		}, []string{"operation"}),
+
+		WriteHookDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "write_hook_dropped_total",
+			Help:      "Committed writes dropped because the async write hook buffer was full",
+		}),
+
+		ConflictAuditDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "conflict_audit_dropped_total",
+			Help:      "Conflict audit records dropped because the audit log buffer was full",
+		}),
+
 		WriteSuccessTotal: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "write_success_total",
@@ -121,6 +311,30 @@ func NewMetrics(namespace string) *Metrics {
 			Help:      "Total failed read operations",
 		}),
 
+		ReadsLocalServed: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reads_local_served_total",
+			Help:      "Reads answered from the local store without consulting peers (R=1 or observer)",
+		}),
+
+		ReadsQuorumServed: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reads_quorum_served_total",
+			Help:      "Reads that queried peers for a read quorum",
+		}),
+
+		ReadsAgreementFastPath: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reads_agreement_fast_path_total",
+			Help:      "Quorum reads answered with the local value because every replica queried held the same version",
+		}),
+
+		ReadsDegraded: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reads_degraded_total",
+			Help:      "Quorum reads that reached fewer than R replicas and returned the best value gathered, flagged degraded",
+		}),
+
 		CurrentR: promauto.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "current_r",
@@ -133,6 +347,50 @@ func NewMetrics(namespace string) *Metrics {
 			Help:      "Current write quorum size",
 		}),
 
+		EffectiveR: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "effective_r",
+			Help:      "Read quorum each get ran at, after observer, strong consistency and sharding overrides",
+			Buckets:   prometheus.LinearBuckets(1, 1, 9),
+		}),
+
+		EffectiveW: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "effective_w",
+			Help:      "Write quorum each put ran at, after strong consistency and sharding overrides",
+			Buckets:   prometheus.LinearBuckets(1, 1, 9),
+		}),
+
+		PeerConnectRetries: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "peer_connect_retries_total",
+			Help:      "Background connection retries for peers that failed initial setup",
+		}),
+
+		SelfReplicationSkipped: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "self_replication_skipped_total",
+			Help:      "Times this node's own address was left out of its peer list",
+		}),
+
+		CoordinatorConnections: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "coordinator_connections",
+			Help:      "Open replication coordinator connections to peers",
+		}),
+
+		ProbeConnections: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "probe_connections",
+			Help:      "Open health probe connections to peers",
+		}),
+
+		ActiveProbeGoroutines: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_probe_goroutines",
+			Help:      "Running per-peer health probe goroutines",
+		}),
+
 		HealthRTT: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "health_rtt_seconds",
@@ -164,6 +422,12 @@ func NewMetrics(namespace string) *Metrics {
 			Help:      "Smoothed consistency confidence score (10-sample moving average)",
 		}),
 
+		CCSWarmup: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ccs_warmup",
+			Help:      "1 while the CCS smoothing window is still filling and CCS is unreliable, 0 after",
+		}),
+
 		CCSComponentRTT: promauto.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "ccs_component_rtt",
@@ -206,6 +470,25 @@ func NewMetrics(namespace string) *Metrics {
 			Help:      "Whether hysteresis lockout is currently active (1=active, 0=inactive)",
 		}),
 
+		AdjusterCycleDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "adjuster_cycle_duration_seconds",
+			Help:      "Time taken by one adaptive quorum adjuster cycle",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8), // 100us to ~1.6s
+		}),
+
+		AdjusterCycleOutcome: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "adjuster_cycle_outcome_total",
+			Help:      "Adjuster cycles by outcome (adjust, lockout, stable, warmup, error)",
+		}, []string{"outcome"}),
+
+		WriteSuspended: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "write_suspended",
+			Help:      "Whether writes are suspended because CCS is below the critical threshold (1=suspended, 0=accepting)",
+		}),
+
 		// hlc and staleness metrics
 		HLCDrift: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
@@ -213,12 +496,42 @@ func NewMetrics(namespace string) *Metrics {
 			Help:      "Clock drift per peer in milliseconds",
 		}, []string{"peer"}),
 
+		ClockDriftWarnings: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "clock_drift_warnings_total",
+			Help:      "Remote timestamps ahead of local time by more than the drift warning threshold",
+		}, []string{"peer"}),
+
+		ClockBackwardJumps: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "clock_backward_jumps_total",
+			Help:      "Local wall clock steps backwards larger than the backward jump threshold",
+		}),
+
+		PeersQuarantined: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "peers_quarantined",
+			Help:      "Peers excluded from quorum participation after repeated clock drift rejections",
+		}),
+
 		StalenessViolations: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "staleness_violations_total",
 			Help:      "Total staleness bound violations detected",
 		}),
 
+		StalenessRejectAge: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "staleness_reject_age_seconds",
+			Help:      "Data age above which strict reads are rejected, MAX_STALENESS unless loosened by low CCS",
+		}),
+
+		AgeEvicted: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "age_evicted_total",
+			Help:      "Values removed from the store for exceeding the age eviction threshold",
+		}),
+
 		StaleReadsRejected: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "stale_reads_rejected_total",
@@ -265,18 +578,67 @@ func NewMetrics(namespace string) *Metrics {
 			Buckets:   prometheus.DefBuckets,
 		}),
 
+		ReconcileLogBytes: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "reconcile_log_bytes",
+			Help:      "Key and value bytes held by the recent write log",
+		}),
+
+		ReconcileLogDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_log_dropped_total",
+			Help:      "Writes not recorded in the recent write log because the async log buffer was full",
+		}),
+
+		ReconcileBytes: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_bytes_total",
+			Help:      "Key and value bytes applied to the store by reconciliation",
+		}),
+
 		PartitionHealing: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "partition_healing_total",
 			Help:      "Partition healing events detected (peer reconnections)",
 		}),
 
+		HealingQueueDepth: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "healing_queue_depth",
+			Help:      "Healing events queued and waiting for reconciliation",
+		}),
+
+		HealingEventsDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "healing_events_dropped_total",
+			Help:      "Healing events dropped because the healing queue was full",
+		}),
+
 		ReadRepair: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "read_repair_total",
 			Help:      "Read repair operations performed",
 		}),
 
+		ReadDivergenceHigh: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "read_divergence_high_total",
+			Help:      "Quorum reads where the fraction of replicas disagreeing with the winner exceeded the threshold",
+		}),
+
+		ReadConsistencyAnomaly: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "read_consistency_anomaly_total",
+			Help:      "Quorum reads whose returned value was older than the local value (read verification mode)",
+		}),
+
+		// access frequency
+		HotKey: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "hot_key",
+			Help:      "Estimated access count of the hottest keys (top-k only)",
+		}, []string{"key"}),
+
 		CCSComponentClock: promauto.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "ccs_component_clock",
@@ -287,6 +649,36 @@ func NewMetrics(namespace string) *Metrics {
 	return m
 }
 
+// sethistogramsampling observes only about 1 in n values into the latency
+// and data age histograms, trading precision for overhead. n <= 1 observes all
+func (m *Metrics) SetHistogramSampling(n int) {
+	m.sampleEvery = n
+}
+
+// setaggregatereplicatelatency records replication latency under a single
+// AggregatePeerLabel series instead of one per peer, for very large clusters.
+// per-peer rtt variance and latency-based peer availability are then unavailable
+func (m *Metrics) SetAggregateReplicateLatency(enabled bool) {
+	m.aggregateReplicateLatency = enabled
+}
+
+// observesampled records v into h subject to histogram sampling
+func (m *Metrics) ObserveSampled(h prometheus.Observer, v float64) {
+	if m.sampleEvery > 1 && rand.IntN(m.sampleEvery) != 0 {
+		return
+	}
+	h.Observe(v)
+}
+
+// observereplicatelatency records a replication ack latency for peer,
+// subject to histogram sampling and aggregate mode
+func (m *Metrics) ObserveReplicateLatency(peer string, seconds float64) {
+	if m.aggregateReplicateLatency {
+		peer = AggregatePeerLabel
+	}
+	m.ObserveSampled(m.ReplicateLatency.WithLabelValues(peer), seconds)
+}
+
 func (m *Metrics) RecordWriteSuccess() {
 	m.WriteSuccessTotal.Inc()
 	m.WriteOpsTotal.Inc()
@@ -123,6 +123,20 @@ func (r *MetricsReader) GetAllPeersLatencyStats(peers []string) (*HistogramStats
 		return &HistogramStats{}, nil
 	}
 
+	// no per-peer series, treat every peer as reachable once any data exists
+	if r.metrics.aggregateReplicateLatency {
+		stats, err := r.GetPeerLatencyStats(AggregatePeerLabel)
+		if err != nil || stats.Count == 0 {
+			return &HistogramStats{}, nil
+		}
+		return &HistogramStats{
+			Count: uint64(len(peers)),
+			Sum:   stats.Sum,
+			Avg:   stats.Avg,
+			P95:   stats.P95,
+		}, nil
+	}
+
 	totalCount := uint64(0)
 	totalSum := 0.0
 	maxP95 := 0.0
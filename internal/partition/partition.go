@@ -0,0 +1,114 @@
+package partition
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// partitioner maps a key to the addresses of the nodes that own it
+type Partitioner interface {
+	Owners(key string) []string
+}
+
+// membership-aware partitioners are rebuilt when the peer list changes
+type Rebalancer interface {
+	SetNodes(nodes []string)
+}
+
+// allnodes places every key on every node, full replication as a
+// Partitioner, e.g. the old placement when migrating to sharding
+type AllNodes []string
+
+func (a AllNodes) Owners(string) []string {
+	return a
+}
+
+// default virtual nodes per member, smooths the key distribution
+const DefaultVirtualNodes = 64
+
+// consistenthash places each node at several points on a hash ring and
+// assigns a key to the first replicas distinct nodes clockwise from it, so
+// adding or removing a node only moves the keys next to its points
+type ConsistentHash struct {
+	mu       sync.RWMutex
+	replicas int
+	vnodes   int
+	ring     []uint64          // sorted virtual node hashes
+	owner    map[uint64]string // virtual node hash -> node address
+	nodes    int               // distinct members
+}
+
+// newconsistenthash builds a ring over nodes where each key has replicas owners
+func NewConsistentHash(nodes []string, replicas, vnodes int) *ConsistentHash {
+	if vnodes < 1 {
+		vnodes = DefaultVirtualNodes
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+	ch := &ConsistentHash{replicas: replicas, vnodes: vnodes}
+	ch.SetNodes(nodes)
+	return ch
+}
+
+// setnodes replaces the ring membership
+func (ch *ConsistentHash) SetNodes(nodes []string) {
+	ring := make([]uint64, 0, len(nodes)*ch.vnodes)
+	owner := make(map[uint64]string, len(nodes)*ch.vnodes)
+
+	distinct := slices.Clone(nodes)
+	slices.Sort(distinct)
+	distinct = slices.Compact(distinct)
+
+	for _, node := range distinct {
+		for i := 0; i < ch.vnodes; i++ {
+			h := hashString(node + "#" + strconv.Itoa(i))
+			// on the rare collision the lower address (added first) keeps
+			// the point, so every node builds the same ring
+			if _, taken := owner[h]; taken {
+				continue
+			}
+			ring = append(ring, h)
+			owner[h] = node
+		}
+	}
+	slices.Sort(ring)
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.ring = ring
+	ch.owner = owner
+	ch.nodes = len(distinct)
+}
+
+// owners returns the replicas nodes responsible for key, primary first
+func (ch *ConsistentHash) Owners(key string) []string {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if len(ch.ring) == 0 {
+		return nil
+	}
+
+	want := min(ch.replicas, ch.nodes)
+	owners := make([]string, 0, want)
+
+	h := hashString(key)
+	start := sort.Search(len(ch.ring), func(i int) bool { return ch.ring[i] >= h })
+	for i := 0; i < len(ch.ring) && len(owners) < want; i++ {
+		node := ch.owner[ch.ring[(start+i)%len(ch.ring)]]
+		if !slices.Contains(owners, node) {
+			owners = append(owners, node)
+		}
+	}
+	return owners
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,75 @@
+package partition
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestConsistentHash_StableOwners(t *testing.T) {
+	nodes := []string{"a:8080", "b:8080", "c:8080", "d:8080", "e:8080"}
+	ch := NewConsistentHash(nodes, 3, DefaultVirtualNodes)
+
+	// same membership in a different order builds the same ring
+	reordered := NewConsistentHash([]string{"e:8080", "c:8080", "a:8080", "d:8080", "b:8080"}, 3, DefaultVirtualNodes)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owners := ch.Owners(key)
+
+		if len(owners) != 3 {
+			t.Fatalf("expected 3 owners for %s, got %v", key, owners)
+		}
+		distinct := slices.Clone(owners)
+		slices.Sort(distinct)
+		if len(slices.Compact(distinct)) != 3 {
+			t.Fatalf("expected distinct owners for %s, got %v", key, owners)
+		}
+		if !slices.Equal(owners, ch.Owners(key)) {
+			t.Fatalf("expected repeated lookups of %s to agree", key)
+		}
+		if !slices.Equal(owners, reordered.Owners(key)) {
+			t.Fatalf("expected owners of %s to be independent of node order", key)
+		}
+	}
+}
+
+func TestConsistentHash_FewerNodesThanReplicas(t *testing.T) {
+	ch := NewConsistentHash([]string{"a:8080", "b:8080"}, 3, DefaultVirtualNodes)
+
+	if owners := ch.Owners("key"); len(owners) != 2 {
+		t.Errorf("expected every node to own the key, got %v", owners)
+	}
+	if owners := NewConsistentHash(nil, 3, DefaultVirtualNodes).Owners("key"); owners != nil {
+		t.Errorf("expected no owners on an empty ring, got %v", owners)
+	}
+}
+
+func TestConsistentHash_AddingNodeMovesFewKeys(t *testing.T) {
+	nodes := []string{"a:8080", "b:8080", "c:8080", "d:8080"}
+	ch := NewConsistentHash(nodes, 1, DefaultVirtualNodes)
+
+	const keys = 2000
+	before := make([]string, keys)
+	for i := range before {
+		before[i] = ch.Owners(fmt.Sprintf("key%d", i))[0]
+	}
+
+	ch.SetNodes(append(nodes, "e:8080"))
+
+	moved := 0
+	for i := range before {
+		owner := ch.Owners(fmt.Sprintf("key%d", i))[0]
+		if owner != before[i] {
+			if owner != "e:8080" {
+				t.Fatalf("key%d moved between existing nodes %s -> %s", i, before[i], owner)
+			}
+			moved++
+		}
+	}
+
+	// ideally 1/5 of keys move to the new node
+	if moved == 0 || moved > keys/2 {
+		t.Errorf("expected roughly a fifth of keys to move, %d of %d moved", moved, keys)
+	}
+}
@@ -2,9 +2,12 @@ package reconcile
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"github.com/rachitkumar205/acp-kv/internal/audit"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
 	"github.com/rachitkumar205/acp-kv/internal/storage"
@@ -21,7 +24,27 @@ type Engine struct {
 	interval      time.Duration
 	enabled       bool
 	mu            sync.RWMutex
-	healingEvents chan string // peer addresses that just healed
+	healingEvents chan healingEvent
+	conflictAudit *audit.ConflictLog
+
+	// with maxConcurrent > 0 healed peers are reconciled by schedule, most
+	// down first, instead of serially in arrival order
+	maxConcurrent int
+	schedMu       sync.Mutex
+	pending       map[string]time.Duration // peer -> longest reported downtime
+	running       map[string]bool
+	reconcile     func(peer string) int // reconcileWithPeer, replaced in tests
+
+	// pacing of large reconciliations, see SetChunking
+	chunkSize   int
+	bytesPerSec int64
+	sleep       func(time.Duration)
+}
+
+// a peer that just healed and how long it was down
+type healingEvent struct {
+	peer    string
+	downFor time.Duration
 }
 
 // reconcilercoordinator defines methods needed from coordinator
@@ -29,6 +52,21 @@ type ReconcilerCoordinator interface {
 	GetPeerAddresses() []string
 }
 
+// implemented by coordinators that can read a peer's local copy of a key,
+// used to fetch values the write log elided
+type peerReader interface {
+	GetLocalFrom(ctx context.Context, addr, key string) (*proto.GetResponse, error)
+}
+
+// default bounds of the recent write log
+const (
+	DefaultLogSize = 1000
+	DefaultLogAge  = 5 * time.Minute
+)
+
+// healing events buffered before new ones are dropped
+const DefaultHealingQueueSize = 100
+
 // newengine creates a new reconciliation engine
 func NewEngine(
 	store *storage.Store,
@@ -40,16 +78,77 @@ func NewEngine(
 ) *Engine {
 	return &Engine{
 		store:         store,
-		recentWrites:  NewRecentWriteLog(1000, 5*time.Minute), // keep 1000 writes for 5 minutes
+		recentWrites:  NewRecentWriteLog(DefaultLogSize, DefaultLogAge),
 		coordinator:   coordinator,
 		logger:        logger,
 		metrics:       m,
 		interval:      interval,
 		enabled:       enabled,
-		healingEvents: make(chan string, 100),
+		healingEvents: make(chan healingEvent, DefaultHealingQueueSize),
+		sleep:         time.Sleep,
 	}
 }
 
+// sethealingqueuesize buffers up to n healing events waiting for
+// reconciliation; events arriving while it is full are dropped and counted.
+// must be called before Start and before any event is delivered
+func (e *Engine) SetHealingQueueSize(n int) {
+	e.healingEvents = make(chan healingEvent, n)
+}
+
+// setchunking processes a reconciliation in chunks of chunkSize log entries
+// and pauses between chunks so applied key and value bytes stay under
+// bytesPerSec (0 is unlimited). a chunkSize of 0 (the default) runs the
+// whole log at once. must be called before Start
+func (e *Engine) SetChunking(chunkSize int, bytesPerSec int64) {
+	e.chunkSize = chunkSize
+	e.bytesPerSec = bytesPerSec
+}
+
+// how long to wait after a chunk that applied bytes in elapsed
+func (e *Engine) chunkPause(bytes int64, elapsed time.Duration) time.Duration {
+	if e.bytesPerSec <= 0 {
+		return 0
+	}
+	budget := time.Duration(float64(bytes) / float64(e.bytesPerSec) * float64(time.Second))
+	return max(budget-elapsed, 0)
+}
+
+// setreconcileconcurrency reconciles up to n healed peers at once, starting
+// with the peer that was down longest since it has likely diverged most.
+// 0 (the default) reconciles one peer at a time in arrival order. must be
+// called before Start
+func (e *Engine) SetReconcileConcurrency(n int) {
+	e.maxConcurrent = n
+	e.pending = make(map[string]time.Duration)
+	e.running = make(map[string]bool)
+	e.reconcile = e.reconcileWithPeer
+}
+
+// enablelogcompaction switches the write log to keep only the latest write per key
+// must be called before Start; any writes already recorded are discarded
+func (e *Engine) EnableLogCompaction() {
+	e.recentWrites = NewCompactedWriteLog(DefaultLogSize, DefaultLogAge)
+}
+
+// writelog returns the log the engine reconciles from
+func (e *Engine) WriteLog() *RecentWriteLog {
+	return e.recentWrites
+}
+
+// setwritelog makes the engine reconcile from a log maintained elsewhere,
+// e.g. by the server, so history recorded before the engine existed is kept.
+// must be called before Start
+func (e *Engine) SetWriteLog(log *RecentWriteLog) {
+	e.recentWrites = log
+}
+
+// setconflictaudit records the losing side of every concurrent write the
+// engine resolves. must be called before Start
+func (e *Engine) SetConflictAudit(log *audit.ConflictLog) {
+	e.conflictAudit = log
+}
+
 // start runs the reconciliation engine
 func (e *Engine) Start(ctx context.Context) {
 	if !e.enabled {
@@ -65,11 +164,17 @@ func (e *Engine) Start(ctx context.Context) {
 
 	for {
 		select {
-		case peer := <-e.healingEvents:
+		case ev := <-e.healingEvents:
+			e.metrics.HealingQueueDepth.Set(float64(len(e.healingEvents)))
 			e.logger.Info("partition healing detected, triggering reconciliation",
-				zap.String("healed_peer", peer))
+				zap.String("healed_peer", ev.peer),
+				zap.Duration("down_for", ev.downFor))
 			e.metrics.PartitionHealing.Inc()
-			e.reconcileWithPeer(peer)
+			if e.maxConcurrent > 0 {
+				e.schedule(ev)
+			} else {
+				e.reconcileWithPeer(ev.peer)
+			}
 
 		case <-ticker.C:
 			// periodic reconciliation check (optional)
@@ -83,18 +188,78 @@ func (e *Engine) Start(ctx context.Context) {
 }
 
 // notifyhealingevent signals that a peer has reconnected
-func (e *Engine) NotifyHealingEvent(peer string) {
+func (e *Engine) NotifyHealingEvent(peer string, downFor time.Duration) {
 	select {
-	case e.healingEvents <- peer:
+	case e.healingEvents <- healingEvent{peer: peer, downFor: downFor}:
 		e.logger.Debug("healing event queued", zap.String("peer", peer))
 	default:
 		e.logger.Warn("healing event queue full, dropping event",
 			zap.String("peer", peer))
+		e.metrics.HealingEventsDropped.Inc()
 	}
+	e.metrics.HealingQueueDepth.Set(float64(len(e.healingEvents)))
+}
+
+// queue a healed peer and start reconciliations up to the concurrency bound.
+// a peer healing again before its turn keeps its longest downtime
+func (e *Engine) schedule(ev healingEvent) {
+	e.schedMu.Lock()
+	defer e.schedMu.Unlock()
+
+	e.pending[ev.peer] = max(e.pending[ev.peer], ev.downFor)
+	e.dispatch()
+}
+
+// start the longest-down pending peers while slots are free, never the same
+// peer twice at once (caller holds schedMu)
+func (e *Engine) dispatch() {
+	for len(e.running) < e.maxConcurrent {
+		next, found := "", false
+		for peer, downFor := range e.pending {
+			if e.running[peer] {
+				continue
+			}
+			if !found || downFor > e.pending[next] || (downFor == e.pending[next] && peer < next) {
+				next, found = peer, true
+			}
+		}
+		if !found {
+			return
+		}
+
+		delete(e.pending, next)
+		e.running[next] = true
+		go func(peer string) {
+			e.reconcile(peer)
+
+			e.schedMu.Lock()
+			defer e.schedMu.Unlock()
+			delete(e.running, peer)
+			e.dispatch()
+		}(next)
+	}
+}
+
+// reconcilenow synchronously reconciles with a known peer and returns keys repaired
+func (e *Engine) ReconcileNow(peer string) (int, error) {
+	known := false
+	for _, addr := range e.coordinator.GetPeerAddresses() {
+		if addr == peer {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return 0, fmt.Errorf("unknown peer: %s", peer)
+	}
+
+	e.logger.Info("manual reconciliation triggered", zap.String("peer", peer))
+	return e.reconcileWithPeer(peer), nil
 }
 
 // reconcile with a specific peer using recent write log
-func (e *Engine) reconcileWithPeer(peer string) {
+// returns the number of keys updated locally
+func (e *Engine) reconcileWithPeer(peer string) int {
 	start := time.Now()
 	defer func() {
 		e.metrics.ReconciliationLatency.Observe(time.Since(start).Seconds())
@@ -106,8 +271,28 @@ func (e *Engine) reconcileWithPeer(peer string) {
 	// get recent writes
 	writes := e.recentWrites.GetAll()
 	keysReconciled := 0
+	chunks, chunkStart, chunkBytes := 1, time.Now(), int64(0)
+
+	for i, write := range writes {
+		if e.chunkSize > 0 && i > 0 && i%e.chunkSize == 0 {
+			e.logger.Info("reconciliation progress",
+				zap.String("peer", peer),
+				zap.Int("writes_checked", i),
+				zap.Int("total_writes", len(writes)),
+				zap.Int("keys_reconciled", keysReconciled),
+				zap.Int64("chunk_bytes", chunkBytes))
+			if pause := e.chunkPause(chunkBytes, time.Since(chunkStart)); pause > 0 {
+				e.sleep(pause)
+			}
+			chunks++
+			chunkStart, chunkBytes = time.Now(), 0
+		}
+
+		// bloom filter short-circuits keys that are definitely absent
+		if !e.store.MightContain(write.Key) {
+			continue
+		}
 
-	for _, write := range writes {
 		// for each recent write, check local store and resolve conflicts
 		localValue, found := e.store.Get(write.Key)
 
@@ -116,33 +301,59 @@ func (e *Engine) reconcileWithPeer(peer string) {
 			continue
 		}
 
-		// use lww: keep the value with the latest hlc timestamp
-		if write.HLC.HappensAfter(localValue.HLC) {
-			// remote write is newer, update local store
-			e.store.PutWithHLC(write.Key, write.Value, write.NodeID, write.HLC)
+		// use lww with the same tiebreak as replicate and the read merge.
+		// concurrent writes share an hlc (should be rare)
+		incoming := storage.VersionedValue{NodeID: write.NodeID, HLC: write.HLC}
+		concurrent := write.HLC.Equal(localValue.HLC) && write.NodeID != localValue.NodeID
+		if concurrent {
+			e.metrics.ConflictsDetected.Inc()
+			e.logger.Warn("reconciliation: concurrent writes detected",
+				zap.String("key", write.Key),
+				zap.String("local_node", localValue.NodeID),
+				zap.String("remote_node", write.NodeID))
+		}
+
+		remoteWins := storage.ShouldReplace(localValue, incoming)
+		value, kept := write.ValueFrom(localValue)
+		if remoteWins && !kept {
+			// the log only has the key and hlc, ask the peer for that version
+			value, kept = e.fetchElided(peer, write)
+		}
+		if remoteWins && !kept {
+			e.logger.Debug("reconciliation: value of newer write not retained",
+				zap.String("key", write.Key),
+				zap.String("peer", peer))
+		} else if remoteWins {
+			// remote write wins, update local store
+			e.store.PutWithContentType(write.Key, value, write.ContentType, write.NodeID, write.HLC)
 			keysReconciled++
+			applied := int64(len(write.Key) + len(value))
+			chunkBytes += applied
+			e.metrics.ReconcileBytes.Add(float64(applied))
 			e.metrics.ConflictsResolved.Inc()
 			e.logger.Debug("reconciliation: remote write newer",
 				zap.String("key", write.Key),
 				zap.String("peer", peer))
-		} else if localValue.HLC.HappensAfter(write.HLC) {
-			// local value is newer, no action needed
+		} else {
+			// local value wins, no action needed
 			e.logger.Debug("reconciliation: local value newer",
 				zap.String("key", write.Key))
-		} else if write.HLC.Equal(localValue.HLC) {
-			// concurrent writes with same hlc (should be rare)
-			e.metrics.ConflictsDetected.Inc()
-			e.logger.Warn("reconciliation: concurrent writes detected",
-				zap.String("key", write.Key),
-				zap.String("local_node", localValue.NodeID),
-				zap.String("remote_node", write.NodeID))
+		}
 
-			// tiebreak using node id (deterministic)
-			if write.NodeID > localValue.NodeID {
-				e.store.PutWithHLC(write.Key, write.Value, write.NodeID, write.HLC)
-				keysReconciled++
-				e.metrics.ConflictsResolved.Inc()
+		if concurrent && e.conflictAudit != nil {
+			c := audit.Conflict{
+				Key:        write.Key,
+				Source:     "reconcile",
+				WinnerNode: localValue.NodeID,
+				WinnerHLC:  localValue.HLC,
+				LoserNode:  write.NodeID,
+				LoserHLC:   write.HLC,
+			}
+			if remoteWins {
+				c.WinnerNode, c.LoserNode = c.LoserNode, c.WinnerNode
+				c.WinnerHLC, c.LoserHLC = c.LoserHLC, c.WinnerHLC
 			}
+			e.conflictAudit.Record(c)
 		}
 	}
 
@@ -153,10 +364,50 @@ func (e *Engine) reconcileWithPeer(peer string) {
 		zap.String("peer", peer),
 		zap.Int("keys_reconciled", keysReconciled),
 		zap.Int("total_writes_checked", len(writes)),
+		zap.Int("chunks", chunks),
 		zap.Duration("duration", time.Since(start)))
+
+	return keysReconciled
+}
+
+// read an elided write's value from peer, which only counts if the peer still
+// holds that exact version
+func (e *Engine) fetchElided(peer string, write WriteEntry) ([]byte, bool) {
+	reader, ok := e.coordinator.(peerReader)
+	if !ok {
+		return nil, false
+	}
+
+	resp, err := reader.GetLocalFrom(context.Background(), peer, write.Key)
+	if err != nil {
+		e.logger.Debug("reconciliation: failed to fetch elided value",
+			zap.String("key", write.Key),
+			zap.String("peer", peer),
+			zap.Error(err))
+		return nil, false
+	}
+	if !resp.Found || !hlc.FromProto(resp.Hlc).Equal(write.HLC) {
+		return nil, false
+	}
+	return resp.Value, true
 }
 
 // recordwrite adds a write to the recent write log
 func (e *Engine) RecordWrite(key string, value []byte, nodeID string, timestamp hlc.HLC) {
 	e.recentWrites.Add(key, value, nodeID, timestamp)
 }
+
+// recentwrites returns the non-expired entries of the write log
+func (e *Engine) RecentWrites() []WriteEntry {
+	return e.recentWrites.GetAll()
+}
+
+// forgetwrite removes a previously recorded write (e.g. a rolled back put)
+func (e *Engine) ForgetWrite(key string, timestamp hlc.HLC) {
+	e.recentWrites.Remove(key, timestamp)
+}
+
+// clearlog drops every recorded write, used when the store is flushed
+func (e *Engine) ClearLog() {
+	e.recentWrites.Clear()
+}
@@ -1,9 +1,18 @@
 package reconcile
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"github.com/rachitkumar205/acp-kv/internal/audit"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
 	"github.com/rachitkumar205/acp-kv/internal/storage"
@@ -140,6 +149,80 @@ func TestEngine_ReconcileWithPeer_ConcurrentWrites(t *testing.T) {
 	}
 }
 
+func TestEngine_ReconcileConvergesInEitherOrder(t *testing.T) {
+	now := time.Now().UnixNano()
+	a := hlc.HLC{Physical: now, Logical: 1, NodeID: "node1"}
+	b := hlc.HLC{Physical: now, Logical: 1, NodeID: "node2"}
+
+	// each node holds one write and learns about the other one
+	reconciled := func(local, remote hlc.HLC) storage.VersionedValue {
+		store := storage.NewStore()
+		engine := NewEngine(store, &mockCoordinator{peers: []string{"peer1"}}, time.Second, true, zap.NewNop(), testMetrics)
+		store.PutWithHLC("key1", []byte(local.NodeID), local.NodeID, local)
+		engine.RecordWrite("key1", []byte(remote.NodeID), remote.NodeID, remote)
+		engine.reconcileWithPeer("peer1")
+		vv, _ := store.Get("key1")
+		return vv
+	}
+
+	ab, ba := reconciled(a, b), reconciled(b, a)
+	if string(ab.Value) != string(ba.Value) || ab.HLC != ba.HLC {
+		t.Fatalf("expected both orders to converge, got %s and %s", ab.Value, ba.Value)
+	}
+
+	// the winner must match what replicate apply would keep
+	want := storage.NewStore()
+	want.PutIfNewer("key1", []byte("node1"), "node1", a)
+	want.PutIfNewer("key1", []byte("node2"), "node2", b)
+	if vv, _ := want.Get("key1"); string(vv.Value) != string(ab.Value) {
+		t.Errorf("expected reconciliation to agree with PutIfNewer on %s, got %s", vv.Value, ab.Value)
+	}
+}
+
+func TestEngine_ConcurrentConflictAudited(t *testing.T) {
+	logger := zap.NewNop()
+	store := storage.NewStore()
+	engine := NewEngine(store, &mockCoordinator{peers: []string{"peer1"}}, time.Second, true, logger, testMetrics)
+
+	path := filepath.Join(t.TempDir(), "conflicts.log")
+	conflicts, err := audit.NewConflictLog(path, audit.DefaultMaxBytes, audit.DefaultBuffer, logger, testMetrics)
+	if err != nil {
+		t.Fatalf("failed to open conflict log: %v", err)
+	}
+	engine.SetConflictAudit(conflicts)
+
+	now := time.Now().UnixNano()
+	localHLC := hlc.HLC{Physical: now, Logical: 3, NodeID: "node1"}
+	remoteHLC := hlc.HLC{Physical: now, Logical: 3, NodeID: "peer1"}
+	store.PutWithHLC("key1", []byte("local_value"), "node1", localHLC)
+	engine.RecordWrite("key1", []byte("remote_value"), "peer1", remoteHLC)
+
+	engine.reconcileWithPeer("peer1")
+	conflicts.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read conflict log: %v", err)
+	}
+	var got audit.Conflict
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("expected one json record, got %q: %v", data, err)
+	}
+
+	if got.Key != "key1" || got.Source != "reconcile" {
+		t.Errorf("unexpected record key/source: %+v", got)
+	}
+	if got.WinnerNode != "peer1" || got.WinnerHLC != remoteHLC {
+		t.Errorf("expected peer1 to win with %v, got %s %v", remoteHLC, got.WinnerNode, got.WinnerHLC)
+	}
+	if got.LoserNode != "node1" || got.LoserHLC != localHLC {
+		t.Errorf("expected node1 to lose with %v, got %s %v", localHLC, got.LoserNode, got.LoserHLC)
+	}
+	if got.Time.IsZero() {
+		t.Error("expected record to be timestamped")
+	}
+}
+
 func TestRecentWriteLog_AddAndGet(t *testing.T) {
 	log := NewRecentWriteLog(10, 5*time.Minute)
 
@@ -197,3 +280,523 @@ func TestRecentWriteLog_Expiration(t *testing.T) {
 		t.Errorf("expected fresh_key, got %s", writes[0].Key)
 	}
 }
+
+func TestRecentWriteLog_CompactionKeepsLatest(t *testing.T) {
+	log := NewCompactedWriteLog(10, 5*time.Minute)
+
+	now := time.Now().UnixNano()
+
+	// repeated writes to a hot key, plus one write to another key
+	for i := 0; i < 5; i++ {
+		timestamp := hlc.HLC{Physical: now, Logical: int64(i), NodeID: "node1"}
+		log.Add("hot", []byte(fmt.Sprintf("v%d", i)), "node1", timestamp)
+	}
+	log.Add("cold", []byte("c"), "node1", hlc.HLC{Physical: now, Logical: 0, NodeID: "node1"})
+
+	// an older write arriving late must not replace the newer one
+	log.Add("hot", []byte("stale"), "node2", hlc.HLC{Physical: now - 1, Logical: 0, NodeID: "node2"})
+
+	if log.Size() != 2 {
+		t.Fatalf("expected 2 keys after compaction, got %d", log.Size())
+	}
+
+	for _, w := range log.GetAll() {
+		if w.Key == "hot" && string(w.Value) != "v4" {
+			t.Errorf("expected hot key to keep latest value v4, got %s", string(w.Value))
+		}
+	}
+}
+
+func TestRecentWriteLog_CompactionRemoveKeepsEntry(t *testing.T) {
+	log := NewCompactedWriteLog(10, 5*time.Minute)
+
+	now := time.Now().UnixNano()
+	log.Add("key1", []byte("v1"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+	log.Add("key1", []byte("v2"), "node1", hlc.HLC{Physical: now + 1, NodeID: "node1"})
+
+	// undoing the latest write must not drop the key, v1 is already compacted away
+	log.Remove("key1", hlc.HLC{Physical: now + 1, NodeID: "node1"})
+
+	if log.Size() != 1 {
+		t.Fatalf("expected key1 to stay in the log, got %d entries", log.Size())
+	}
+}
+
+func TestRecentWriteLog_CompactionEvictsOldestKey(t *testing.T) {
+	log := NewCompactedWriteLog(2, 5*time.Minute)
+
+	now := time.Now().UnixNano()
+	for i, key := range []string{"a", "b", "c"} {
+		log.Add(key, []byte("v"), "node1", hlc.HLC{Physical: now + int64(i), NodeID: "node1"})
+		time.Sleep(time.Millisecond) // distinct receipt times
+	}
+
+	if log.Size() != 2 {
+		t.Fatalf("expected size=2, got %d", log.Size())
+	}
+	for _, w := range log.GetAll() {
+		if w.Key == "a" {
+			t.Error("expected oldest key to be evicted")
+		}
+	}
+}
+
+func TestRecentWriteLog_CompactionRewriteRefreshesKey(t *testing.T) {
+	log := NewCompactedWriteLog(2, 5*time.Minute)
+	log.SetMemoryLimits(4, 0)
+
+	now := time.Now().UnixNano()
+	log.Add("a", []byte("v"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+	log.Add("b", []byte("v"), "node1", hlc.HLC{Physical: now + 1, NodeID: "node1"})
+	// rewriting a makes b the least recently received key
+	log.Add("a", []byte("v"), "node1", hlc.HLC{Physical: now + 2, NodeID: "node1"})
+	log.Add("c", []byte("v"), "node1", hlc.HLC{Physical: now + 3, NodeID: "node1"})
+
+	keys := make([]string, 0, 2)
+	for _, w := range log.GetAll() {
+		keys = append(keys, w.Key)
+	}
+	if !slices.Equal(keys, []string{"a", "c"}) {
+		t.Errorf("expected a and c in receipt order, got %v", keys)
+	}
+
+	// the byte cap evicts in the same order
+	log.Add("d", []byte("vvv"), "node1", hlc.HLC{Physical: now + 4, NodeID: "node1"})
+	keys = keys[:0]
+	for _, w := range log.GetAll() {
+		keys = append(keys, w.Key)
+	}
+	if !slices.Equal(keys, []string{"d"}) {
+		t.Errorf("expected only d under the byte cap, got %v", keys)
+	}
+	if log.Bytes() != 4 {
+		t.Errorf("expected 4 bytes held, got %d", log.Bytes())
+	}
+}
+
+func TestEngine_ReconcileNow(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	store := storage.NewStore()
+	coord := &mockCoordinator{peers: []string{"peer1"}}
+
+	engine := NewEngine(store, coord, time.Second, true, logger, testMetrics)
+
+	now := time.Now().UnixNano()
+
+	// two keys where the peer's write is newer, one where local is newer
+	for _, key := range []string{"key1", "key2", "key3"} {
+		store.PutWithHLC(key, []byte("local_value"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+	}
+	engine.RecordWrite("key1", []byte("remote_value"), "peer1", hlc.HLC{Physical: now + 1, NodeID: "peer1"})
+	engine.RecordWrite("key2", []byte("remote_value"), "peer1", hlc.HLC{Physical: now + 1, NodeID: "peer1"})
+	engine.RecordWrite("key3", []byte("remote_value"), "peer1", hlc.HLC{Physical: now - 1, NodeID: "peer1"})
+
+	keys, err := engine.ReconcileNow("peer1")
+	if err != nil {
+		t.Fatalf("expected reconcile to succeed, got %v", err)
+	}
+	if keys != 2 {
+		t.Errorf("expected 2 keys reconciled, got %d", keys)
+	}
+
+	for key, want := range map[string]string{"key1": "remote_value", "key2": "remote_value", "key3": "local_value"} {
+		value, _ := store.Get(key)
+		if string(value.Value) != want {
+			t.Errorf("expected %s=%s, got %s", key, want, string(value.Value))
+		}
+	}
+
+	if _, err := engine.ReconcileNow("unknown"); err == nil {
+		t.Error("expected error for unknown peer")
+	}
+}
+
+func TestRecentWriteLog_Remove(t *testing.T) {
+	log := NewRecentWriteLog(3, 5*time.Minute)
+
+	now := time.Now().UnixNano()
+	for i := 0; i < 4; i++ {
+		log.Add(fmt.Sprintf("key%d", i), []byte("v"), "node1", hlc.HLC{Physical: now, Logical: int64(i), NodeID: "node1"})
+	}
+
+	// key0 was overwritten by the circular buffer, key2 is removed explicitly
+	log.Remove("key2", hlc.HLC{Physical: now, Logical: 2, NodeID: "node1"})
+
+	writes := log.GetAll()
+	if len(writes) != 2 {
+		t.Fatalf("expected 2 writes after remove, got %d", len(writes))
+	}
+	for _, w := range writes {
+		if w.Key == "key2" {
+			t.Error("expected key2 to be removed")
+		}
+	}
+}
+
+func TestRecentWriteLog_ByteCapEvictsOldest(t *testing.T) {
+	log := NewRecentWriteLog(100, 5*time.Minute)
+	log.SetMetrics(testMetrics)
+	log.SetMemoryLimits(30, 0)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	now := time.Now().UnixNano()
+	// each entry is a 2 byte key and 8 byte value
+	for i := 0; i < 5; i++ {
+		log.Add(fmt.Sprintf("k%d", i), []byte("12345678"), "node1", hlc.HLC{Physical: now + int64(i)})
+	}
+
+	writes := log.GetAll()
+	if len(writes) != 3 {
+		t.Fatalf("expected 3 entries to fit in 30 bytes, got %d", len(writes))
+	}
+	for i, w := range writes {
+		if want := fmt.Sprintf("k%d", i+2); w.Key != want {
+			t.Errorf("entry %d: expected %s, got %s", i, want, w.Key)
+		}
+	}
+	if log.Bytes() != 30 {
+		t.Errorf("expected 30 bytes, got %d", log.Bytes())
+	}
+	if got, _ := reader.GetGaugeValue(testMetrics.ReconcileLogBytes); got != 30 {
+		t.Errorf("expected gauge 30, got %v", got)
+	}
+
+	// removal and clearing are reflected in the footprint
+	log.Remove("k4", hlc.HLC{Physical: now + 4})
+	if got, _ := reader.GetGaugeValue(testMetrics.ReconcileLogBytes); got != 20 {
+		t.Errorf("expected gauge 20 after remove, got %v", got)
+	}
+	log.Add("k5", []byte("12345678"), "node1", hlc.HLC{Physical: now + 5})
+	if writes := log.GetAll(); len(writes) != 3 || writes[0].Key != "k2" || writes[2].Key != "k5" {
+		t.Errorf("expected k2..k5 in order after remove, got %v", writes)
+	}
+	log.Clear()
+	if got, _ := reader.GetGaugeValue(testMetrics.ReconcileLogBytes); got != 0 {
+		t.Errorf("expected gauge 0 after clear, got %v", got)
+	}
+}
+
+func TestRecentWriteLog_CompactedByteCap(t *testing.T) {
+	log := NewCompactedWriteLog(100, 5*time.Minute)
+	log.SetMemoryLimits(20, 0)
+
+	now := time.Now().UnixNano()
+	log.Add("k1", []byte("12345678"), "node1", hlc.HLC{Physical: now})
+	log.Add("k2", []byte("12345678"), "node1", hlc.HLC{Physical: now + 1})
+	// growing k2 pushes the log over the cap and evicts k1
+	log.Add("k2", []byte("1234567890"), "node1", hlc.HLC{Physical: now + 2})
+
+	writes := log.GetAll()
+	if len(writes) != 1 || writes[0].Key != "k2" {
+		t.Fatalf("expected only k2 to remain, got %v", writes)
+	}
+	if log.Bytes() != 12 {
+		t.Errorf("expected 12 bytes, got %d", log.Bytes())
+	}
+}
+
+// poll until the async log holds n entries
+func waitForLogSize(t *testing.T, log *RecentWriteLog, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for log.Size() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d entries, got %d", n, log.Size())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRecentWriteLog_AsyncRecordsAllWrites(t *testing.T) {
+	log := NewRecentWriteLog(1000, 5*time.Minute)
+	log.SetMetrics(testMetrics)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log.EnableAsync(ctx, 1000)
+
+	now := time.Now().UnixNano()
+	for i := 0; i < 100; i++ {
+		log.Add(fmt.Sprintf("key%d", i), []byte("v"), "node1", hlc.HLC{Physical: now + int64(i)})
+	}
+	// the undo of the last write is applied after it
+	log.Remove("key99", hlc.HLC{Physical: now + 99})
+	waitForLogSize(t, log, 99)
+
+	writes := log.GetAll()
+	for i, w := range writes {
+		if w.Key != fmt.Sprintf("key%d", i) {
+			t.Fatalf("expected writes in order, got %s at %d", w.Key, i)
+		}
+	}
+}
+
+func TestRecentWriteLog_AsyncStopsWithContext(t *testing.T) {
+	log := NewRecentWriteLog(1000, 5*time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	log.EnableAsync(ctx, 1)
+
+	now := time.Now().UnixNano()
+	log.Add("key1", []byte("v"), "node1", hlc.HLC{Physical: now})
+	waitForLogSize(t, log, 1)
+	cancel()
+
+	// with the drain stopped changes apply synchronously, remove never blocks
+	// on the full buffer
+	log.Add("key2", []byte("v"), "node1", hlc.HLC{Physical: now + 1})
+	log.Add("key3", []byte("v"), "node1", hlc.HLC{Physical: now + 2})
+	log.Remove("key3", hlc.HLC{Physical: now + 2})
+	if log.Size() != 2 {
+		t.Errorf("expected 2 entries applied synchronously, got %d", log.Size())
+	}
+}
+
+func TestRecentWriteLog_AsyncClearAfterQueuedAdds(t *testing.T) {
+	log := NewRecentWriteLog(1000, 5*time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log.EnableAsync(ctx, 100)
+
+	// hold the lock so the adds are still queued when Clear is called
+	log.mu.Lock()
+	now := time.Now().UnixNano()
+	for i := 0; i < 10; i++ {
+		log.Add(fmt.Sprintf("key%d", i), []byte("v"), "node1", hlc.HLC{Physical: now + int64(i)})
+	}
+	cleared := make(chan struct{})
+	go func() {
+		log.Clear()
+		close(cleared)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	log.mu.Unlock()
+
+	<-cleared
+	if log.Size() != 0 {
+		t.Errorf("expected queued adds to be applied before the clear, got %d entries", log.Size())
+	}
+}
+
+func TestRecentWriteLog_AsyncStopKeepsOrder(t *testing.T) {
+	log := NewRecentWriteLog(10000, 5*time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	log.EnableAsync(ctx, 16)
+
+	// every add is undone, stopping midway must not strand or reorder any
+	var wg sync.WaitGroup
+	now := time.Now().UnixNano()
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				ts := hlc.HLC{Physical: now + int64(g*1000+i)}
+				log.Add(fmt.Sprintf("key%d-%d", g, i), []byte("v"), "node1", ts)
+				log.Remove(fmt.Sprintf("key%d-%d", g, i), ts)
+			}
+		}(g)
+	}
+	time.Sleep(time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	waitForLogSize(t, log, 0)
+}
+
+func TestRecentWriteLog_AsyncCountsDrops(t *testing.T) {
+	log := NewRecentWriteLog(1000, 5*time.Minute)
+	log.SetMetrics(testMetrics)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log.EnableAsync(ctx, 2)
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.ReconcileLogDropped)
+
+	// hold the lock so the drain goroutine can't apply anything
+	log.mu.Lock()
+	now := time.Now().UnixNano()
+	for i := 0; i < 10; i++ {
+		log.Add(fmt.Sprintf("key%d", i), []byte("v"), "node1", hlc.HLC{Physical: now + int64(i)})
+	}
+	after, _ := reader.GetCounterValue(testMetrics.ReconcileLogDropped)
+	log.mu.Unlock()
+
+	// at most one write in the drain plus two buffered got through
+	dropped := int(after - before)
+	if dropped < 7 {
+		t.Fatalf("expected at least 7 drops, got %d", dropped)
+	}
+	waitForLogSize(t, log, 10-dropped)
+}
+
+func TestEngine_ReconcilesElidedValueFromStore(t *testing.T) {
+	store := storage.NewStore()
+	engine := NewEngine(store, &mockCoordinator{}, time.Second, true, zap.NewNop(), testMetrics)
+	engine.WriteLog().SetMemoryLimits(0, 4)
+
+	now := time.Now().UnixNano()
+	big := hlc.HLC{Physical: now + 10, NodeID: "node2"}
+	store.PutWithHLC("key1", []byte("old"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+	engine.RecordWrite("key1", []byte("large value"), "node2", big)
+
+	writes := engine.RecentWrites()
+	if len(writes) != 1 || !writes[0].Elided || writes[0].Value != nil {
+		t.Fatalf("expected the large value to be elided, got %v", writes)
+	}
+	if engine.WriteLog().Bytes() != 4 {
+		t.Errorf("expected only the key to be counted, got %d bytes", engine.WriteLog().Bytes())
+	}
+
+	// the store holds an older version, the elided value can't be applied
+	if n := engine.reconcileWithPeer("peer1"); n != 0 {
+		t.Errorf("expected nothing reconciled without the value, got %d", n)
+	}
+	if vv, _ := store.Get("key1"); string(vv.Value) != "old" {
+		t.Errorf("expected store untouched, got %q", vv.Value)
+	}
+
+	// once the store holds that version the entry resolves to it
+	store.PutWithHLC("key1", []byte("large value"), "node2", big)
+	vv, _ := store.Get("key1")
+	if value, ok := writes[0].ValueFrom(vv); !ok || string(value) != "large value" {
+		t.Errorf("expected elided entry to resolve from the store, got %q ok=%v", value, ok)
+	}
+}
+
+// mock coordinator that serves GetLocal from a per-peer store
+type readerCoordinator struct {
+	mockCoordinator
+	stores map[string]*storage.Store
+}
+
+func (r *readerCoordinator) GetLocalFrom(ctx context.Context, addr, key string) (*proto.GetResponse, error) {
+	store, ok := r.stores[addr]
+	if !ok {
+		return nil, fmt.Errorf("unknown peer %s", addr)
+	}
+	vv, found := store.Get(key)
+	if !found {
+		return &proto.GetResponse{}, nil
+	}
+	return &proto.GetResponse{Found: true, Value: vv.Value, Hlc: vv.HLC.ToProto()}, nil
+}
+
+func TestEngine_FetchesElidedValueFromPeer(t *testing.T) {
+	store := storage.NewStore()
+	peerStore := storage.NewStore()
+	coord := &readerCoordinator{stores: map[string]*storage.Store{"peer1": peerStore, "peer2": storage.NewStore()}}
+	engine := NewEngine(store, coord, time.Second, true, zap.NewNop(), testMetrics)
+	engine.WriteLog().SetMemoryLimits(0, 4)
+
+	now := time.Now().UnixNano()
+	big := hlc.HLC{Physical: now + 10, NodeID: "node2"}
+	store.PutWithHLC("key1", []byte("old"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+	peerStore.PutWithHLC("key1", []byte("large value"), "node2", big)
+	engine.RecordWrite("key1", []byte("large value"), "node2", big)
+
+	// a peer without that version can't supply the value
+	if n := engine.reconcileWithPeer("peer2"); n != 0 {
+		t.Errorf("expected nothing reconciled from a peer without the value, got %d", n)
+	}
+
+	if n := engine.reconcileWithPeer("peer1"); n != 1 {
+		t.Fatalf("expected the elided write to be reconciled, got %d", n)
+	}
+	if vv, _ := store.Get("key1"); string(vv.Value) != "large value" || !vv.HLC.Equal(big) {
+		t.Errorf("expected the peer's value to be applied, got %q at %v", vv.Value, vv.HLC)
+	}
+}
+
+func TestEngine_HealingQueueFullDropsEvents(t *testing.T) {
+	engine := NewEngine(storage.NewStore(), &mockCoordinator{}, time.Second, true, zap.NewNop(), testMetrics)
+	engine.SetHealingQueueSize(2)
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.HealingEventsDropped)
+
+	// not started, nothing drains the queue
+	for _, peer := range []string{"peer1", "peer2", "peer3"} {
+		engine.NotifyHealingEvent(peer, time.Second)
+	}
+
+	if after, _ := reader.GetCounterValue(testMetrics.HealingEventsDropped); after-before != 1 {
+		t.Errorf("expected 1 dropped event, got %v", after-before)
+	}
+	if depth, _ := reader.GetGaugeValue(testMetrics.HealingQueueDepth); depth != 2 {
+		t.Errorf("expected queue depth 2, got %v", depth)
+	}
+}
+
+func TestEngine_ScheduleReconcilesLongestDownFirst(t *testing.T) {
+	engine := NewEngine(storage.NewStore(), &mockCoordinator{}, time.Second, true, zap.NewNop(), testMetrics)
+	engine.SetReconcileConcurrency(2)
+
+	// each reconciliation reports its peer and waits to be released
+	started := make(chan string, 5)
+	release := make(chan struct{})
+	engine.reconcile = func(peer string) int {
+		started <- peer
+		<-release
+		return 0
+	}
+
+	// two blockers take both slots, the rest queue in arrival order
+	engine.schedule(healingEvent{peer: "blocker1"})
+	engine.schedule(healingEvent{peer: "blocker2"})
+	engine.schedule(healingEvent{peer: "brief", downFor: time.Second})
+	engine.schedule(healingEvent{peer: "longest", downFor: time.Minute})
+	engine.schedule(healingEvent{peer: "medium", downFor: 10 * time.Second})
+
+	<-started
+	<-started
+	select {
+	case peer := <-started:
+		t.Fatalf("expected at most 2 concurrent reconciliations, %s started a third", peer)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// each finished reconciliation starts the longest-down queued peer
+	for _, want := range []string{"longest", "medium", "brief"} {
+		release <- struct{}{}
+		if peer := <-started; peer != want {
+			t.Errorf("expected %s next, got %s", want, peer)
+		}
+	}
+	close(release)
+}
+
+func TestEngine_ChunkedReconcileRespectsRateLimit(t *testing.T) {
+	store := storage.NewStore()
+	engine := NewEngine(store, &mockCoordinator{}, time.Second, true, zap.NewNop(), testMetrics)
+	engine.SetChunking(3, 1000)
+	var pauses []time.Duration
+	engine.sleep = func(d time.Duration) { pauses = append(pauses, d) }
+
+	// ten divergent keys of 100 bytes each (2 byte key, 98 byte value)
+	now := time.Now().UnixNano()
+	value := make([]byte, 98)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("k%d", i)
+		store.PutWithHLC(key, []byte("old"), "node1", hlc.HLC{Physical: now, NodeID: "node1"})
+		engine.RecordWrite(key, value, "node2", hlc.HLC{Physical: now + 1, NodeID: "node2"})
+	}
+
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.ReconcileBytes)
+
+	if n := engine.reconcileWithPeer("peer1"); n != 10 {
+		t.Fatalf("expected 10 keys reconciled, got %d", n)
+	}
+
+	// chunks of 3, 3, 3 and 1, with a pause after each of the first three
+	// that holds 300 bytes to 1000 B/s
+	if len(pauses) != 3 {
+		t.Fatalf("expected 3 pauses between 4 chunks, got %v", pauses)
+	}
+	for _, p := range pauses {
+		if p <= 250*time.Millisecond || p > 300*time.Millisecond {
+			t.Errorf("expected each pause just under 300ms, got %v", p)
+		}
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReconcileBytes); v != before+1000 {
+		t.Errorf("expected 1000 reconciled bytes, counter moved by %v", v-before)
+	}
+}
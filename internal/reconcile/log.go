@@ -1,19 +1,47 @@
 package reconcile
 
 import (
+	"container/list"
+	"context"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
 )
 
 // writeentry represents a single write operation
 type WriteEntry struct {
-	Key       string
-	Value     []byte
-	NodeID    string
-	HLC       hlc.HLC
-	Timestamp int64 // local receipt time
+	Key         string
+	Value       []byte
+	ContentType string // kept even when the value is elided
+	NodeID      string
+	HLC         hlc.HLC
+	Timestamp   int64 // local receipt time
+
+	// value was over the log's value limit and not kept; Value is nil and
+	// the write is identified by key and hlc only, see ValueFrom
+	Elided bool
+}
+
+// valuefrom returns the entry's value. an elided entry is resolved from
+// stored, the store's current value for the key, when it is still that
+// exact version
+func (w WriteEntry) ValueFrom(stored storage.VersionedValue) ([]byte, bool) {
+	if !w.Elided {
+		return w.Value, true
+	}
+	if stored.HLC.Equal(w.HLC) && stored.NodeID == w.NodeID {
+		return stored.Value, true
+	}
+	return nil, false
+}
+
+// bytes an entry holds in the log
+func entrySize(e WriteEntry) int64 {
+	return int64(len(e.Key) + len(e.Value) + len(e.ContentType))
 }
 
 // recentwritelog maintains a circular buffer of recent writes for reconciliation
@@ -25,6 +53,37 @@ type RecentWriteLog struct {
 	count      int
 	maxAge     time.Duration
 	timestamps []int64 // corresponding timestamps for entries
+
+	// compaction mode keeps only the latest write per key. order holds the
+	// WriteEntry values least recently received first, so eviction is O(1)
+	compact bool
+	latest  map[string]*list.Element
+	order   *list.List
+
+	// key and value bytes held, oldest entries are evicted past maxBytes
+	// (disabled when zero). values over maxValueBytes are not kept
+	bytes         int64
+	maxBytes      int64
+	maxValueBytes int
+	metrics       *metrics.Metrics
+
+	// async mode queues adds, removes and clears for a single goroutine to
+	// apply, keeping the lock off the write path (nil when synchronous). stop
+	// is closed when the goroutine's context ends. senders hold sendMu for
+	// reading, the goroutine takes it to set stopped once the queue is empty,
+	// after which changes apply synchronously
+	queue   chan logOp
+	stop    <-chan struct{}
+	sendMu  sync.RWMutex
+	stopped bool
+	dropped prometheus.Counter
+}
+
+// a queued change to the log, applied in order
+type logOp struct {
+	entry  WriteEntry
+	remove bool
+	clear  chan struct{} // closed once the clear is applied
 }
 
 // newrecentwritelog creates a new recent write log
@@ -37,30 +96,264 @@ func NewRecentWriteLog(maxSize int, maxAge time.Duration) *RecentWriteLog {
 	}
 }
 
+// newcompactedwritelog creates a log that keeps only the highest-hlc write per key,
+// trading exact history for broader key coverage under hot keys
+func NewCompactedWriteLog(maxSize int, maxAge time.Duration) *RecentWriteLog {
+	return &RecentWriteLog{
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		compact: true,
+		latest:  make(map[string]*list.Element, maxSize),
+		order:   list.New(),
+	}
+}
+
+// setmetrics reports the log's byte footprint on the reconcile_log_bytes gauge
+func (rwl *RecentWriteLog) SetMetrics(m *metrics.Metrics) {
+	rwl.mu.Lock()
+	defer rwl.mu.Unlock()
+	rwl.metrics = m
+	rwl.reportBytes()
+}
+
+// setmemorylimits caps the key and value bytes the log holds, evicting the
+// oldest entries to stay under maxBytes, and stops keeping values larger than
+// maxValueBytes: such writes are logged by key and hlc only and reconciled
+// from the store or the peer. zero disables either limit. existing entries are kept
+func (rwl *RecentWriteLog) SetMemoryLimits(maxBytes int64, maxValueBytes int) {
+	rwl.mu.Lock()
+	defer rwl.mu.Unlock()
+	rwl.maxBytes = maxBytes
+	rwl.maxValueBytes = maxValueBytes
+	rwl.evictOverBytes()
+	rwl.reportBytes()
+}
+
+// enableasync makes Add queue the write for a background goroutine instead
+// of taking the lock, so entries show up in GetAll shortly after the write.
+// adds that find the buffer full are dropped and counted. Remove waits for
+// buffer space so it is never applied before the add it undoes, and Clear is
+// ordered behind queued adds. the goroutine applies what is queued and exits
+// when ctx is done; later changes are applied synchronously. buffer must be at least 1. call before the log is in use, and
+// after SetMetrics for drops to be counted
+func (rwl *RecentWriteLog) EnableAsync(ctx context.Context, buffer int) {
+	if rwl.metrics != nil {
+		rwl.dropped = rwl.metrics.ReconcileLogDropped
+	}
+	rwl.queue = make(chan logOp, buffer)
+	rwl.stop = ctx.Done()
+	go rwl.drain()
+}
+
+func (rwl *RecentWriteLog) drain() {
+	for {
+		select {
+		case op := <-rwl.queue:
+			rwl.apply(op)
+		case <-rwl.stop:
+			rwl.shutdown()
+			return
+		}
+	}
+}
+
+// stop taking changes: keep applying until every sender is out, then apply
+// what is left and switch the log to synchronous mode
+func (rwl *RecentWriteLog) shutdown() {
+	locked, flushed := make(chan struct{}), make(chan struct{})
+	go func() {
+		rwl.sendMu.Lock()
+		defer rwl.sendMu.Unlock()
+		close(locked)
+		<-flushed
+		rwl.stopped = true
+	}()
+
+	for {
+		select {
+		case op := <-rwl.queue:
+			// a sender blocked on a full buffer still holds sendMu
+			rwl.apply(op)
+		case <-locked:
+			for {
+				select {
+				case op := <-rwl.queue:
+					rwl.apply(op)
+				default:
+					close(flushed)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (rwl *RecentWriteLog) apply(op logOp) {
+	switch {
+	case op.clear != nil:
+		rwl.clear()
+		close(op.clear)
+	case op.remove:
+		rwl.remove(op.entry.Key, op.entry.HLC)
+	default:
+		rwl.add(op.entry)
+	}
+}
+
+// hand op to the drain goroutine, waiting for buffer space when block is set
+// and dropping it otherwise. false once the goroutine has stopped, the caller
+// then applies op itself
+func (rwl *RecentWriteLog) enqueue(op logOp, block bool) bool {
+	if rwl.queue == nil {
+		return false
+	}
+	rwl.sendMu.RLock()
+	defer rwl.sendMu.RUnlock()
+	if rwl.stopped {
+		return false
+	}
+
+	if block {
+		rwl.queue <- op
+		return true
+	}
+	select {
+	case rwl.queue <- op:
+	default:
+		if rwl.dropped != nil {
+			rwl.dropped.Inc()
+		}
+	}
+	return true
+}
+
+// bytes returns the key and value bytes currently held
+func (rwl *RecentWriteLog) Bytes() int64 {
+	rwl.mu.RLock()
+	defer rwl.mu.RUnlock()
+	return rwl.bytes
+}
+
 // add inserts a write into the log
 func (rwl *RecentWriteLog) Add(key string, value []byte, nodeID string, timestamp hlc.HLC) {
+	rwl.AddWithContentType(key, value, "", nodeID, timestamp)
+}
+
+// same as Add, recording the value's content type
+func (rwl *RecentWriteLog) AddWithContentType(key string, value []byte, contentType, nodeID string, timestamp hlc.HLC) {
+	entry := WriteEntry{
+		Key:         key,
+		Value:       value,
+		ContentType: contentType,
+		NodeID:      nodeID,
+		HLC:         timestamp,
+		Timestamp:   time.Now().UnixNano(),
+	}
+	if !rwl.enqueue(logOp{entry: entry}, false) {
+		rwl.add(entry)
+	}
+}
+
+func (rwl *RecentWriteLog) add(entry WriteEntry) {
 	rwl.mu.Lock()
 	defer rwl.mu.Unlock()
+	defer rwl.reportBytes()
 
-	now := time.Now().UnixNano()
+	now := entry.Timestamp
+	if rwl.maxValueBytes > 0 && len(entry.Value) > rwl.maxValueBytes {
+		entry.Value = nil
+		entry.Elided = true
+	}
 
-	entry := WriteEntry{
-		Key:       key,
-		Value:     value,
-		NodeID:    nodeID,
-		HLC:       timestamp,
-		Timestamp: now,
+	if rwl.compact {
+		rwl.addCompacted(entry)
+		rwl.evictOverBytes()
+		return
 	}
 
+	if rwl.count == rwl.maxSize {
+		rwl.bytes -= entrySize(rwl.entries[rwl.index])
+	}
 	rwl.entries[rwl.index] = entry
 	rwl.timestamps[rwl.index] = now
+	rwl.bytes += entrySize(entry)
 	rwl.index = (rwl.index + 1) % rwl.maxSize
 	if rwl.count < rwl.maxSize {
 		rwl.count++
 	}
+	rwl.evictOverBytes()
+}
+
+// slot of the oldest entry in the ring. caller holds the lock
+func (rwl *RecentWriteLog) oldest() int {
+	return (rwl.index - rwl.count + rwl.maxSize) % rwl.maxSize
+}
+
+// drop oldest entries until the log fits in maxBytes, always keeping the
+// newest one. caller holds the lock
+func (rwl *RecentWriteLog) evictOverBytes() {
+	if rwl.maxBytes <= 0 {
+		return
+	}
+
+	if rwl.compact {
+		for rwl.bytes > rwl.maxBytes && len(rwl.latest) > 1 {
+			rwl.evictOldestKey()
+		}
+		return
+	}
+
+	for rwl.bytes > rwl.maxBytes && rwl.count > 1 {
+		idx := rwl.oldest()
+		rwl.bytes -= entrySize(rwl.entries[idx])
+		rwl.entries[idx] = WriteEntry{}
+		rwl.count--
+	}
+}
+
+// drop the least recently received key. caller holds the lock
+func (rwl *RecentWriteLog) evictOldestKey() {
+	rwl.removeCompacted(rwl.order.Front())
+}
+
+// drop one key's entry in compaction mode. caller holds the lock
+func (rwl *RecentWriteLog) removeCompacted(elem *list.Element) {
+	e := rwl.order.Remove(elem).(WriteEntry)
+	rwl.bytes -= entrySize(e)
+	delete(rwl.latest, e.Key)
+}
+
+// update the footprint gauge. caller holds the lock
+func (rwl *RecentWriteLog) reportBytes() {
+	if rwl.metrics != nil {
+		rwl.metrics.ReconcileLogBytes.Set(float64(rwl.bytes))
+	}
+}
+
+// keep the entry only if it is newer than what we have for the key,
+// evicting the least recently received key when the log is full
+func (rwl *RecentWriteLog) addCompacted(entry WriteEntry) {
+	if elem, exists := rwl.latest[entry.Key]; exists {
+		existing := elem.Value.(WriteEntry)
+		if existing.HLC.HappensAfter(entry.HLC) {
+			return
+		}
+		rwl.bytes += entrySize(entry) - entrySize(existing)
+		elem.Value = entry
+		rwl.order.MoveToBack(elem)
+		return
+	}
+
+	if len(rwl.latest) >= rwl.maxSize {
+		rwl.evictOldestKey()
+	}
+
+	rwl.latest[entry.Key] = rwl.order.PushBack(entry)
+	rwl.bytes += entrySize(entry)
 }
 
 // getall returns all non-expired writes from the log
+// in compaction mode this is one entry per key
 func (rwl *RecentWriteLog) GetAll() []WriteEntry {
 	rwl.mu.RLock()
 	defer rwl.mu.RUnlock()
@@ -68,10 +361,22 @@ func (rwl *RecentWriteLog) GetAll() []WriteEntry {
 	now := time.Now().UnixNano()
 	cutoff := now - int64(rwl.maxAge)
 
+	if rwl.compact {
+		result := make([]WriteEntry, 0, len(rwl.latest))
+		for elem := rwl.order.Front(); elem != nil; elem = elem.Next() {
+			if e := elem.Value.(WriteEntry); e.Timestamp >= cutoff {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+
 	result := make([]WriteEntry, 0, rwl.count)
+	start := rwl.oldest()
 	for i := 0; i < rwl.count; i++ {
-		if rwl.timestamps[i] >= cutoff {
-			result = append(result, rwl.entries[i])
+		idx := (start + i) % rwl.maxSize
+		if rwl.timestamps[idx] >= cutoff {
+			result = append(result, rwl.entries[idx])
 		}
 	}
 
@@ -82,6 +387,9 @@ func (rwl *RecentWriteLog) GetAll() []WriteEntry {
 func (rwl *RecentWriteLog) Size() int {
 	rwl.mu.RLock()
 	defer rwl.mu.RUnlock()
+	if rwl.compact {
+		return len(rwl.latest)
+	}
 	return rwl.count
 }
 
@@ -89,20 +397,108 @@ func (rwl *RecentWriteLog) Size() int {
 func (rwl *RecentWriteLog) Cleanup() {
 	rwl.mu.Lock()
 	defer rwl.mu.Unlock()
+	defer rwl.reportBytes()
 
 	now := time.Now().UnixNano()
 	cutoff := now - int64(rwl.maxAge)
 
+	if rwl.compact {
+		for elem := rwl.order.Front(); elem != nil; {
+			next := elem.Next()
+			if elem.Value.(WriteEntry).Timestamp < cutoff {
+				rwl.removeCompacted(elem)
+			}
+			elem = next
+		}
+		return
+	}
+
 	validCount := 0
 	newEntries := make([]WriteEntry, rwl.maxSize)
 	newTimestamps := make([]int64, rwl.maxSize)
+	start := rwl.oldest()
 
 	for i := 0; i < rwl.count; i++ {
-		if rwl.timestamps[i] >= cutoff {
-			newEntries[validCount] = rwl.entries[i]
-			newTimestamps[validCount] = rwl.timestamps[i]
+		idx := (start + i) % rwl.maxSize
+		if rwl.timestamps[idx] >= cutoff {
+			newEntries[validCount] = rwl.entries[idx]
+			newTimestamps[validCount] = rwl.timestamps[idx]
 			validCount++
+		} else {
+			rwl.bytes -= entrySize(rwl.entries[idx])
+		}
+	}
+
+	rwl.entries = newEntries
+	rwl.timestamps = newTimestamps
+	rwl.count = validCount
+	rwl.index = validCount % rwl.maxSize
+}
+
+// clear drops every entry. in async mode it is queued behind pending changes
+// so none of them land after it, and returns once applied
+func (rwl *RecentWriteLog) Clear() {
+	done := make(chan struct{})
+	if rwl.enqueue(logOp{clear: done}, true) {
+		<-done
+		return
+	}
+	rwl.clear()
+}
+
+func (rwl *RecentWriteLog) clear() {
+	rwl.mu.Lock()
+	defer rwl.mu.Unlock()
+	defer rwl.reportBytes()
+
+	rwl.bytes = 0
+	if rwl.compact {
+		rwl.latest = make(map[string]*list.Element, rwl.maxSize)
+		rwl.order.Init()
+		return
+	}
+
+	rwl.entries = make([]WriteEntry, rwl.maxSize)
+	rwl.timestamps = make([]int64, rwl.maxSize)
+	rwl.count = 0
+	rwl.index = 0
+}
+
+// remove drops the entry for key written at timestamp (used to undo failed writes).
+// a compacted log keeps the entry: compaction already dropped the key's earlier
+// write, and the store's restore reverts the value
+func (rwl *RecentWriteLog) Remove(key string, timestamp hlc.HLC) {
+	if !rwl.enqueue(logOp{entry: WriteEntry{Key: key, HLC: timestamp}, remove: true}, true) {
+		rwl.remove(key, timestamp)
+	}
+}
+
+func (rwl *RecentWriteLog) remove(key string, timestamp hlc.HLC) {
+	rwl.mu.Lock()
+	defer rwl.mu.Unlock()
+	defer rwl.reportBytes()
+
+	if rwl.compact {
+		return
+	}
+
+	// rebuild in insertion order without the removed entry
+	start := rwl.oldest()
+
+	newEntries := make([]WriteEntry, rwl.maxSize)
+	newTimestamps := make([]int64, rwl.maxSize)
+	validCount := 0
+
+	for i := 0; i < rwl.count; i++ {
+		idx := (start + i) % rwl.maxSize
+		e := rwl.entries[idx]
+		if e.Key == key && e.HLC.Equal(timestamp) {
+			rwl.bytes -= entrySize(e)
+			continue
 		}
+		newEntries[validCount] = e
+		newTimestamps[validCount] = rwl.timestamps[idx]
+		validCount++
 	}
 
 	rwl.entries = newEntries
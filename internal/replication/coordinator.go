@@ -2,16 +2,25 @@ package replication
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/partition"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
 
 	"github.com/rachitkumar205/acp-kv/api/proto"
 	"go.uber.org/zap"
@@ -22,13 +31,63 @@ type Coordinator struct {
 	nodeID            string
 	peers             map[string]proto.ACPServiceClient // peer address -> client
 	conns             map[string]*grpc.ClientConn       // peer address -> connection
-	configuredPeers   []string                          // full list of configured peer addresses (immutable)
+	configuredPeers   []string                          // full list of configured peer addresses (replaced by UpdatePeers)
 	logger            *zap.Logger
 	metrics           *metrics.Metrics
 	timeout           time.Duration
-	mu                sync.RWMutex // protect peers and conns maps
+	mu                sync.RWMutex // protect peers, conns, pending, configuredPeers and observers
+
+	// peers that failed initial connection setup, retried in the background.
+	// pendingAdded wakes the reconnect loop when the set becomes non-empty
+	pending      map[string]struct{}
+	pendingAdded chan struct{}
+	dial         func(addr string) (*grpc.ClientConn, error)
+
+	// max peers connected in parallel by reconcilePeers
+	connectConcurrency int
+
+	// observer peers receive writes best-effort but never count toward
+	// quorum, are not queried for reads and are not part of N
+	observers map[string]bool
+
+	// optional sharding, nil replicates every key to every peer
+	partitioner partition.Partitioner
+	selfAddr    string // this node's address as it appears in peer lists and the partitioner
+
+	// optional, peers quarantined for clock drift do not count toward quorums
+	quarantine *Quarantine
+
+	// max concurrent peer rpcs per replicate or read, 0 is unlimited
+	maxFanOut int
+
+	// optional, writes pushed to peers first seen by reconcilePeers so they
+	// don't wait for anti-entropy. known holds every peer ever connected,
+	// seeded from static PEERS and from the first discovery result
+	bootstrapSource func() []*proto.ReplicateRequest
+	known           map[string]bool
+	knownSeeded     bool
+
+	// extra attempts per peer for transient replication errors, all within
+	// the replication timeout
+	replicateRetries int
+	retryBackoff     time.Duration
+
+	// read each acked write back from the peer before counting the ack
+	verifyAcks bool
+
+	// write amplification since the last gauge update: bytes sent to peers
+	// versus value bytes written by clients
+	replicatedBytes atomic.Int64
+	writtenBytes    atomic.Int64
 }
 
+// default bound on parallel peer connection setup during reconcile
+const DefaultConnectConcurrency = 8
+
+// bound on a single dns lookup for a peer or the headless service, so a slow
+// resolver cannot stall startup or the discovery loop
+const dnsLookupTimeout = 2 * time.Second
+
 func NewCoordinator(nodeID string, peerAddrs []string, logger *zap.Logger, metrics *metrics.Metrics, timeout time.Duration) (*Coordinator, error) {
 	c := &Coordinator{
 		nodeID:          nodeID,
@@ -38,41 +97,430 @@ func NewCoordinator(nodeID string, peerAddrs []string, logger *zap.Logger, metri
 		logger:          logger,
 		metrics:         metrics,
 		timeout:         timeout,
+		pending:         make(map[string]struct{}),
+		pendingAdded:    make(chan struct{}, 1),
+		dial:            dialPeer,
+
+		connectConcurrency: DefaultConnectConcurrency,
+		observers:          make(map[string]bool),
+		known:              make(map[string]bool),
+	}
+	for _, addr := range peerAddrs {
+		c.known[addr] = true
 	}
 
-	// est connections to all peers
+	// est connections to all peers in parallel, each dns lookup is bounded by
+	// dnsLookupTimeout and failures are left to StartPeerReconnect
+	sem := make(chan struct{}, c.connectConcurrency)
+	var wg sync.WaitGroup
 	for _, addr := range peerAddrs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := c.addPeer(addr); err != nil {
+				logger.Warn("failed to connect to peer, will retry", zap.String("peer", addr), zap.Error(err))
+				c.mu.Lock()
+				c.addPendingLocked(addr)
+				c.mu.Unlock()
+			}
+		}(addr)
+	}
+	wg.Wait()
+
+	return c, nil
+}
+
+// resolve the peer host before creating the (lazy) grpc client so that
+// peers that are not in dns yet are reported as failures and retried
+func dialPeer(addr string) (*grpc.ClientConn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return nil, err
+	}
+
+	// Use dns:/// scheme for Kubernetes DNS resolution
+	target := "dns:///" + addr
+	return grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// setconnectconcurrency bounds how many peers reconcilePeers connects to at
+// once, so a large membership change does not open dozens of connections
+// simultaneously. values below 1 are treated as 1
+func (c *Coordinator) SetConnectConcurrency(n int) {
+	c.connectConcurrency = max(n, 1)
+}
+
+// addobservers connects to observer nodes. writes are replicated to them in
+// the background after quorum handling, and they are excluded from quorum
+// math, read queries, peer reconciliation and GetPeerAddresses
+func (c *Coordinator) AddObservers(addrs []string) {
+	c.mu.Lock()
+	for _, addr := range addrs {
+		c.observers[addr] = true
+	}
+	c.mu.Unlock()
+
+	for _, addr := range addrs {
 		if err := c.addPeer(addr); err != nil {
-			logger.Warn("failed to connect to peer", zap.String("peer", addr), zap.Error(err))
+			c.logger.Warn("failed to connect to observer, will retry", zap.String("observer", addr), zap.Error(err))
+			c.mu.Lock()
+			c.addPendingLocked(addr)
+			c.mu.Unlock()
 		}
 	}
+}
 
-	return c, nil
+// setreplicateretries gives each peer up to retries extra attempts when
+// replication fails with a transient error, waiting backoff (doubling) between
+// attempts. every attempt shares the replication timeout
+func (c *Coordinator) SetReplicateRetries(retries int, backoff time.Duration) {
+	c.replicateRetries = max(retries, 0)
+	c.retryBackoff = backoff
 }
 
-func (c *Coordinator) addPeer(addr string) error {
+// setverifyacks reads every acknowledged write back from the peer with
+// GetLocal and only counts the ack if the peer holds that version or a newer
+// one. catches peers that ack without persisting, at the cost of one extra rpc
+// per peer within the replication timeout
+func (c *Coordinator) SetVerifyAcks(enabled bool) {
+	c.verifyAcks = enabled
+}
+
+// setbootstrapsource makes reconcilePeers push the writes source returns to
+// every peer it connects that this node has never been connected to, e.g. a
+// node added by a scale-up. peers apply them only if newer than what they
+// hold. set before discovery starts
+func (c *Coordinator) SetBootstrapSource(source func() []*proto.ReplicateRequest) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.bootstrapSource = source
+}
 
-	// check if already connected
-	if _, exists := c.peers[addr]; exists {
+// record addr as known, reporting whether it was new. caller holds the lock
+func (c *Coordinator) markKnown(addr string) bool {
+	if c.known == nil {
+		c.known = make(map[string]bool)
+	}
+	if c.known[addr] {
+		return false
+	}
+	c.known[addr] = true
+	return true
+}
+
+// push the bootstrap writes to a newly added peer, one at a time so the
+// newcomer isn't flooded while it also takes live traffic
+func (c *Coordinator) bootstrapPeer(addr string) {
+	c.mu.RLock()
+	client, connected := c.peers[addr]
+	source := c.bootstrapSource
+	c.mu.RUnlock()
+	if !connected || source == nil {
+		return
+	}
+
+	c.mu.RLock()
+	p := c.partitioner
+	c.mu.RUnlock()
+
+	start := time.Now()
+	sent, failed := 0, 0
+	for _, req := range source() {
+		// with sharding only keys the peer owns are pushed
+		if p != nil && !slices.Contains(p.Owners(req.Key), addr) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		c.recordReplicateBytes(req)
+		resp, err := client.Replicate(ctx, req)
+		cancel()
+
+		if err != nil || !resp.Success {
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	c.metrics.PeerBootstrapWrites.WithLabelValues("success").Add(float64(sent))
+	c.metrics.PeerBootstrapWrites.WithLabelValues("failure").Add(float64(failed))
+	c.logger.Info("bootstrapped new peer",
+		zap.String("peer", addr),
+		zap.Int("writes", sent),
+		zap.Int("failed", failed),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// setmaxfanout bounds how many peers a single replicate or read contacts at
+// once. peers past the bound are contacted as earlier rpcs finish, so large
+// clusters don't start dozens of rpcs per operation. 0 is unlimited
+func (c *Coordinator) SetMaxFanOut(n int) {
+	c.maxFanOut = max(n, 0)
+}
+
+// run fn for every peer in its own goroutine, at most maxFanOut at a time.
+// returns without waiting; with a bound, peers are started from a dispatcher
+// goroutine as slots free up
+func (c *Coordinator) fanOut(peers map[string]proto.ACPServiceClient, fn func(addr string, client proto.ACPServiceClient)) {
+	limit := c.maxFanOut
+	if limit <= 0 || limit >= len(peers) {
+		for addr, client := range peers {
+			go fn(addr, client)
+		}
+		return
+	}
+
+	go func() {
+		sem := make(chan struct{}, limit)
+		for addr, client := range peers {
+			sem <- struct{}{}
+			go func(addr string, client proto.ACPServiceClient) {
+				defer func() { <-sem }()
+				fn(addr, client)
+			}(addr, client)
+		}
+	}()
+}
+
+// setquarantine excludes peers quarantined for clock drift from W ack
+// counting and read quorums
+func (c *Coordinator) SetQuarantine(q *Quarantine) {
+	c.quarantine = q
+}
+
+func (c *Coordinator) isQuarantined(nodeID string) bool {
+	return c.quarantine != nil && nodeID != "" && c.quarantine.IsQuarantined(nodeID)
+}
+
+// setselfaddress gives the coordinator this node's own address as it appears
+// in peer lists, so it is never connected to as a peer even when discovery
+// or a peer list update includes it. without it self would ack its own
+// writes twice. drops self if it is already connected
+func (c *Coordinator) SetSelfAddress(addr string) {
+	c.mu.Lock()
+	c.selfAddr = addr
+	c.configuredPeers = c.withoutSelf(c.configuredPeers)
+	delete(c.pending, addr)
+	_, connected := c.peers[addr]
+	c.mu.Unlock()
+
+	if connected {
+		c.removePeer(addr)
+	}
+}
+
+// drop this node's own address from addrs. caller holds the lock
+func (c *Coordinator) withoutSelf(addrs []string) []string {
+	if c.selfAddr == "" || !slices.Contains(addrs, c.selfAddr) {
+		return addrs
+	}
+
+	c.metrics.SelfReplicationSkipped.Inc()
+	c.logger.Warn("peer list includes this node's own address, skipping it",
+		zap.String("addr", c.selfAddr))
+	return slices.DeleteFunc(slices.Clone(addrs), func(addr string) bool { return addr == c.selfAddr })
+}
+
+// setpartitioner restricts each key's replication and reads to its owner
+// nodes. selfAddr is this node's address in the partitioner; a partitioner
+// that implements Rebalancer is kept in sync with UpdatePeers
+func (c *Coordinator) SetPartitioner(p partition.Partitioner, selfAddr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partitioner = p
+	c.selfAddr = selfAddr
+	if r, ok := p.(partition.Rebalancer); ok {
+		r.SetNodes(append(slices.Clone(c.configuredPeers), selfAddr))
+	}
+}
+
+// isowner reports whether this node holds a copy of key, always true
+// without a partitioner
+func (c *Coordinator) IsOwner(key string) bool {
+	c.mu.RLock()
+	p, self := c.partitioner, c.selfAddr
+	c.mu.RUnlock()
+
+	return p == nil || slices.Contains(p.Owners(key), self)
+}
+
+// number of replicas that hold key, counting self: n without sharding,
+// otherwise the key's owners
+func (c *Coordinator) ReplicaCount(key string, n int) int {
+	c.mu.RLock()
+	p := c.partitioner
+	c.mu.RUnlock()
+
+	if p == nil {
+		return n
+	}
+	return len(p.Owners(key))
+}
+
+// ownerPeers narrows peers to the owners of key
+func (c *Coordinator) ownerPeers(key string, peers map[string]proto.ACPServiceClient) map[string]proto.ACPServiceClient {
+	c.mu.RLock()
+	p := c.partitioner
+	c.mu.RUnlock()
+
+	if p == nil {
+		return peers
+	}
+
+	owners := p.Owners(key)
+	for addr := range peers {
+		if !slices.Contains(owners, addr) {
+			delete(peers, addr)
+		}
+	}
+	return peers
+}
+
+// snapshot of connected peers split into voters and observers
+func (c *Coordinator) peerSnapshot() (voters, observers map[string]proto.ACPServiceClient) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	voters = make(map[string]proto.ACPServiceClient, len(c.peers))
+	observers = make(map[string]proto.ACPServiceClient, len(c.observers))
+	for addr, client := range c.peers {
+		if c.observers[addr] {
+			observers[addr] = client
+		} else {
+			voters[addr] = client
+		}
+	}
+	return voters, observers
+}
+
+func (c *Coordinator) addPeer(addr string) error {
+	// check if already connected, never connect to self
+	c.mu.RLock()
+	_, exists := c.peers[addr]
+	self := c.selfAddr != "" && addr == c.selfAddr
+	c.mu.RUnlock()
+	if exists {
+		return nil
+	}
+	if self {
+		c.metrics.SelfReplicationSkipped.Inc()
+		c.logger.Warn("refusing to add this node's own address as a peer", zap.String("addr", addr))
 		return nil
 	}
 
-	// Use dns:/// scheme for Kubernetes DNS resolution
-	target := "dns:///" + addr
-	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// dial outside the lock, dns resolution may block
+	conn, err := c.dial(addr)
 	if err != nil {
 		return err
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// another caller connected while we were dialing
+	if _, exists := c.peers[addr]; exists {
+		conn.Close()
+		return nil
+	}
+
 	client := proto.NewACPServiceClient(conn)
 	c.peers[addr] = client
 	c.conns[addr] = conn
+	delete(c.pending, addr)
+	c.metrics.CoordinatorConnections.Inc()
 	c.logger.Info("connected to peer", zap.String("peer", addr))
+
+	go c.checkReady(addr, conn)
 	return nil
 }
 
+// connections are lazy, so start connecting now and warn if the peer is not
+// reachable within the replication timeout instead of finding out on the
+// first write. the connection is kept either way and grpc keeps retrying
+func (c *Coordinator) checkReady(addr string, conn *grpc.ClientConn) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready || state == connectivity.Shutdown {
+			return
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			c.logger.Warn("peer not ready after connect",
+				zap.String("peer", addr),
+				zap.Stringer("state", state),
+				zap.Duration("timeout", c.timeout))
+			return
+		}
+	}
+}
+
+// queue addr for StartPeerReconnect and wake the loop if it is idle.
+// caller holds c.mu
+func (c *Coordinator) addPendingLocked(addr string) {
+	c.pending[addr] = struct{}{}
+	select {
+	case c.pendingAdded <- struct{}{}:
+	default:
+	}
+}
+
+// retries peers that failed connection setup with exponential backoff until
+// ctx is cancelled. when nothing is pending the loop waits for a new failure
+// and starts over from initialBackoff
+func (c *Coordinator) StartPeerReconnect(ctx context.Context, initialBackoff, maxBackoff time.Duration) {
+	backoff := initialBackoff
+
+	for {
+		c.mu.RLock()
+		pending := make([]string, 0, len(c.pending))
+		for addr := range c.pending {
+			pending = append(pending, addr)
+		}
+		c.mu.RUnlock()
+
+		if len(pending) == 0 {
+			select {
+			case <-c.pendingAdded:
+				backoff = initialBackoff
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		for _, addr := range pending {
+			c.metrics.PeerConnectRetries.Inc()
+			if err := c.addPeer(addr); err != nil {
+				c.logger.Debug("peer reconnect failed",
+					zap.String("peer", addr),
+					zap.Duration("next_backoff", min(backoff*2, maxBackoff)),
+					zap.Error(err))
+			}
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
 func (c *Coordinator) removePeer(addr string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -81,16 +529,19 @@ func (c *Coordinator) removePeer(addr string) {
 		conn.Close()
 		delete(c.peers, addr)
 		delete(c.conns, addr)
+		c.metrics.CoordinatorConnections.Dec()
 		c.logger.Info("removed peer", zap.String("peer", addr))
 	}
 }
 
 // uses dns lookup to find all running pods
-func DiscoverPeersDNS(nodeID, headlessSvc, namespace string) ([]string, error) {
+func DiscoverPeersDNS(nodeID, headlessSvc, namespace string, port int) ([]string, error) {
 	fqdn := fmt.Sprintf("%s.%s.svc.cluster.local", headlessSvc, namespace)
 
 	// lookuphost returns ips of all ready pods
-	ips, err := net.LookupHost(fqdn)
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, fqdn)
 	if err != nil {
 		return nil, fmt.Errorf("dns lookup failed for %s: %w", fqdn, err)
 	}
@@ -134,26 +585,44 @@ func DiscoverPeersDNS(nodeID, headlessSvc, namespace string) ([]string, error) {
 			continue // skip self
 		}
 
-		// construct full peer address
-		peerAddr := fmt.Sprintf("%s.%s.%s.svc.cluster.local:8080",
-			podName, headlessSvc, namespace)
-		peers = append(peers, peerAddr)
+		peers = append(peers, PeerAddress(podName, headlessSvc, namespace, port))
 	}
 
 	return peers, nil
 }
 
+// construct full peer address for a statefulset pod behind a headless service
+func PeerAddress(podName, headlessSvc, namespace string, port int) string {
+	return fmt.Sprintf("%s.%s.%s.svc.cluster.local:%d",
+		podName, headlessSvc, namespace, port)
+}
+
 func (c *Coordinator) reconcilePeers(newPeerAddrs []string) {
+	c.mu.RLock()
+	newPeerAddrs = c.withoutSelf(newPeerAddrs)
+	c.mu.RUnlock()
+
 	newPeerSet := make(map[string]bool)
 	for _, addr := range newPeerAddrs {
 		newPeerSet[addr] = true
 	}
 
-	// get current peers
+	// stop retrying pending peers that are no longer members
+	c.mu.Lock()
+	for addr := range c.pending {
+		if !newPeerSet[addr] && !c.observers[addr] {
+			delete(c.pending, addr)
+		}
+	}
+	c.mu.Unlock()
+
+	// get current peers, observers are managed separately
 	c.mu.RLock()
 	currentPeers := make([]string, 0, len(c.peers))
 	for addr := range c.peers {
-		currentPeers = append(currentPeers, addr)
+		if !c.observers[addr] {
+			currentPeers = append(currentPeers, addr)
+		}
 	}
 	c.mu.RUnlock()
 
@@ -164,23 +633,90 @@ func (c *Coordinator) reconcilePeers(newPeerAddrs []string) {
 		}
 	}
 
-	// add new peers
+	// add new peers through a bounded worker pool
+	sem := make(chan struct{}, max(c.connectConcurrency, 1))
+	var wg sync.WaitGroup
 	for addr := range newPeerSet {
 		c.mu.RLock()
 		_, exists := c.peers[addr]
 		c.mu.RUnlock()
 
-		if !exists {
+		if exists {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
 			if err := c.addPeer(addr); err != nil {
-				c.logger.Warn("failed to connect to new peer",
+				c.logger.Warn("failed to connect to new peer, will retry",
 					zap.String("peer", addr),
 					zap.Error(err))
+				c.mu.Lock()
+				c.addPendingLocked(addr)
+				c.mu.Unlock()
+				return
 			}
+
+			c.mu.Lock()
+			bootstrap := c.markKnown(addr) && c.bootstrapSource != nil
+			c.mu.Unlock()
+			if bootstrap {
+				go c.bootstrapPeer(addr)
+			}
+		}(addr)
+	}
+	wg.Wait()
+}
+
+// apply one discovery result. the first one is the cluster as it was when this
+// node started, so its members are marked known rather than bootstrapped;
+// otherwise a restart with empty PEERS would push the store to every peer
+func (c *Coordinator) applyDiscovered(peers []string) {
+	c.mu.Lock()
+	if !c.knownSeeded {
+		c.knownSeeded = true
+		for _, addr := range c.withoutSelf(peers) {
+			c.markKnown(addr)
 		}
 	}
+	c.mu.Unlock()
+
+	c.rebalance(peers)
+	c.reconcilePeers(peers)
+}
+
+// replaces the configured peer list at runtime, for static clusters without
+// dns discovery. connects new peers, drops removed ones and stops retrying
+// pending peers that are no longer configured
+func (c *Coordinator) UpdatePeers(peerAddrs []string) {
+	c.mu.Lock()
+	peers := slices.Clone(c.withoutSelf(peerAddrs))
+	c.configuredPeers = peers
+	c.rebalanceLocked(peers)
+	c.mu.Unlock()
+
+	c.reconcilePeers(peers)
+}
+
+// rebalance moves the partitioner's ring to peers plus this node
+func (c *Coordinator) rebalance(peers []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebalanceLocked(slices.DeleteFunc(slices.Clone(peers), func(addr string) bool { return addr == c.selfAddr }))
+}
+
+// caller holds the lock and has removed self from peers
+func (c *Coordinator) rebalanceLocked(peers []string) {
+	if r, ok := c.partitioner.(partition.Rebalancer); ok {
+		r.SetNodes(append(slices.Clone(peers), c.selfAddr))
+	}
 }
 
-func (c *Coordinator) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, namespace string, interval time.Duration) {
+func (c *Coordinator) StartPeerDiscovery(ctx context.Context, nodeID, headlessSvc, namespace string, port int, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -191,7 +727,7 @@ func (c *Coordinator) StartPeerDiscovery(ctx context.Context, nodeID, headlessSv
 	for {
 		select {
 		case <-ticker.C:
-			peers, err := DiscoverPeersDNS(nodeID, headlessSvc, namespace)
+			peers, err := DiscoverPeersDNS(nodeID, headlessSvc, namespace, port)
 			if err != nil {
 				c.logger.Warn("peer discovery failed", zap.Error(err))
 				continue
@@ -201,7 +737,7 @@ func (c *Coordinator) StartPeerDiscovery(ctx context.Context, nodeID, headlessSv
 				zap.Int("count", len(peers)),
 				zap.Strings("peers", peers))
 
-			c.reconcilePeers(peers)
+			c.applyDiscovered(peers)
 
 		case <-ctx.Done():
 			c.logger.Info("peer discovery stopped")
@@ -214,17 +750,21 @@ func (c *Coordinator) StartPeerDiscovery(ctx context.Context, nodeID, headlessSv
 func (c *Coordinator) GetPeerAddresses() []string {
 	// Return full configured peer list, not just connected peers
 	// This ensures CCS calculation uses N=total_cluster_size, not just reachable peers
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.configuredPeers
 }
 
-// GetConnectedPeerAddresses returns only currently connected peers
+// GetConnectedPeerAddresses returns only currently connected voting peers
 func (c *Coordinator) GetConnectedPeerAddresses() []string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	addrs := make([]string, 0, len(c.peers))
 	for addr := range c.peers {
-		addrs = append(addrs, addr)
+		if !c.observers[addr] {
+			addrs = append(addrs, addr)
+		}
 	}
 	return addrs
 }
@@ -233,10 +773,13 @@ func (c *Coordinator) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for _, conn := range c.conns {
+	for addr, conn := range c.conns {
 		if err := conn.Close(); err != nil {
 			c.logger.Warn("failed to close connection", zap.Error(err))
 		}
+		delete(c.peers, addr)
+		delete(c.conns, addr)
+		c.metrics.CoordinatorConnections.Dec()
 	}
 
 	return nil
@@ -245,209 +788,483 @@ func (c *Coordinator) Close() error {
 // holds the result of a single replication attempt
 type ReplicateResult struct {
 	PeerAddr string
+	NodeID   string // set when the peer answered
 	Success  bool
 	Latency  time.Duration
 	Error    error
 }
 
 // send replication requests to all peers and wait for W acks
-func (c *Coordinator) Replicate(ctx context.Context, key string, value []byte, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int) (int, []ReplicateResult, error) {
-	// get snapshot of current peers
-	c.mu.RLock()
-	peerList := make(map[string]proto.ACPServiceClient, len(c.peers))
-	for addr, client := range c.peers {
-		peerList[addr] = client
+// returns as soon as requiredAcks is met; replication to the remaining peers
+// keeps running in the background so they still converge
+func (c *Coordinator) Replicate(ctx context.Context, key string, value []byte, contentType string, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int) (int, []ReplicateResult, error) {
+	return c.replicate(ctx, key, value, contentType, version, timestamp, hlcTimestamp, requiredAcks, false)
+}
+
+// same as Replicate, but peers are told not to record the write in their
+// reconcile log
+func (c *Coordinator) ReplicateBulk(ctx context.Context, key string, value []byte, contentType string, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int) (int, []ReplicateResult, error) {
+	return c.replicate(ctx, key, value, contentType, version, timestamp, hlcTimestamp, requiredAcks, true)
+}
+
+func (c *Coordinator) replicate(ctx context.Context, key string, value []byte, contentType string, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int, bulk bool) (int, []ReplicateResult, error) {
+	c.writtenBytes.Add(int64(len(value)))
+
+	// get snapshot of current peers, only voters that own the key count toward W
+	peerList, observers := c.peerSnapshot()
+	peerList = c.ownerPeers(key, peerList)
+
+	// the local write only counts when this node owns the key
+	selfAcks := 0
+	if c.IsOwner(key) {
+		selfAcks = 1
 	}
-	c.mu.RUnlock()
 
-	if len(peerList) == 0 {
-		// no peers, only self acknowledgement
-		return 1, []ReplicateResult{}, nil
+	req := &proto.ReplicateRequest{
+		Key:          key,
+		Value:        value,
+		Version:      version,
+		Timestamp:    timestamp,
+		SourceNodeId: c.nodeID,
+		Hlc:          hlcTimestamp.ToProto(),
+		Bulk:         bulk,
+		ContentType:  contentType,
 	}
 
-	results := make(chan ReplicateResult, len(peerList))
-	var wg sync.WaitGroup
+	// observers never block the write
+	if len(observers) > 0 {
+		go c.replicateToObservers(context.WithoutCancel(ctx), req, observers)
+	}
 
-	//send replication requests to all peers in parallel
-	for addr, client := range peerList {
-		wg.Add(1)
-		go func(peerAddr string, peerClient proto.ACPServiceClient) {
-			defer wg.Done()
+	if len(peerList) == 0 {
+		// no peers, only self acknowledgement
+		if requiredAcks > selfAcks {
+			return selfAcks, []ReplicateResult{}, fmt.Errorf("%w: need %d acks, have only self", ErrNoPeers, requiredAcks)
+		}
+		return selfAcks, []ReplicateResult{}, nil
+	}
 
-			start := time.Now()
-			repCtx, cancel := context.WithTimeout(ctx, c.timeout)
-			defer cancel()
+	// detach from the client's cancellation so background replications
+	// are not aborted when the handler returns after W acks
+	bgCtx := context.WithoutCancel(ctx)
 
-			req := &proto.ReplicateRequest{
-				Key:          key,
-				Value:        value,
-				Version:      version,
-				Timestamp:    timestamp,
-				SourceNodeId: c.nodeID,
-				Hlc:          hlcTimestamp.ToProto(),
-			}
+	// buffered to len(peerList) so goroutines never block after we return
+	results := make(chan ReplicateResult, len(peerList))
 
-			resp, err := peerClient.Replicate(repCtx, req)
-			latency := time.Since(start)
+	//send replication requests to all peers in parallel, up to maxFanOut
+	c.fanOut(peerList, func(peerAddr string, peerClient proto.ACPServiceClient) {
+		start := time.Now()
+		repCtx, cancel := context.WithTimeout(bgCtx, c.timeout)
+		defer cancel()
 
-			result := ReplicateResult{
-				PeerAddr: peerAddr,
-				Latency:  latency,
-			}
+		resp, err := c.replicateWithRetry(repCtx, peerAddr, peerClient, req)
+		latency := time.Since(start)
 
-			if err != nil {
-				result.Error = err
-				result.Success = false
-				c.logger.Warn("replication failed",
-					zap.String("peer", peerAddr),
-					zap.String("key", key),
-					zap.Duration("latency", latency),
-					zap.Error(err))
-				c.metrics.ReplicateAcks.WithLabelValues("failure").Inc()
-				c.metrics.Errors.WithLabelValues("rpc").Inc()
-			} else if !resp.Success {
-				result.Error = fmt.Errorf("peer reported failure: %s", resp.Error)
-				result.Success = false
-				c.logger.Warn("replication rejected by peer",
-					zap.String("peer", peerAddr),
-					zap.String("key", key),
-					zap.String("error", resp.Error))
-				c.metrics.ReplicateAcks.WithLabelValues("failure").Inc()
-			} else {
-				result.Success = true
-				c.logger.Debug("replication succeeded",
-					zap.String("peer", peerAddr),
-					zap.String("key", key),
-					zap.Duration("latency", latency))
-				c.metrics.ReplicateAcks.WithLabelValues("success").Inc()
-			}
+		result := ReplicateResult{
+			PeerAddr: peerAddr,
+			Latency:  latency,
+		}
 
-			// record latency
-			c.metrics.ReplicateLatency.WithLabelValues(peerAddr).Observe(latency.Seconds())
+		if err != nil {
+			result.Error = err
+			result.Success = false
+			c.logger.Warn("replication failed",
+				zap.String("peer", peerAddr),
+				zap.String("key", key),
+				zap.Duration("latency", latency),
+				zap.Error(err))
+			c.metrics.ReplicateAcks.WithLabelValues("failure").Inc()
+			c.metrics.Errors.WithLabelValues("rpc").Inc()
+		} else if !resp.Success {
+			result.Error = fmt.Errorf("peer reported failure: %s", resp.Error)
+			result.Success = false
+			c.logger.Warn("replication rejected by peer",
+				zap.String("peer", peerAddr),
+				zap.String("key", key),
+				zap.String("error", resp.Error))
+			c.metrics.ReplicateAcks.WithLabelValues("failure").Inc()
+		} else if err := c.verifyAck(repCtx, peerClient, key, hlcTimestamp); err != nil {
+			result.Error = err
+			result.Success = false
+			c.logger.Warn("replication ack not verified",
+				zap.String("peer", peerAddr),
+				zap.String("key", key),
+				zap.Error(err))
+			c.metrics.ReplicateAcks.WithLabelValues("unverified").Inc()
+		} else {
+			result.Success = true
+			result.NodeID = resp.NodeId
+			c.logger.Debug("replication succeeded",
+				zap.String("peer", peerAddr),
+				zap.String("key", key),
+				zap.Duration("latency", latency))
+			c.metrics.ReplicateAcks.WithLabelValues("success").Inc()
+		}
 
-			results <- result
-		}(addr, client)
-	}
+		// record latency
+		c.metrics.ObserveReplicateLatency(peerAddr, latency.Seconds())
 
-	// close results chan when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+		results <- result
+	})
 
-	// collect results
+	// collect results until W acks, or until W is no longer reachable
 	var allResults []ReplicateResult
-	successCount := 1 // 1 for self ack
+	successCount := selfAcks
+	received := 0
 
-	for result := range results {
+	for received < len(peerList) && successCount < requiredAcks {
+		result := <-results
+		received++
 		allResults = append(allResults, result)
-		if result.Success {
+		if result.Success && !c.isQuarantined(result.NodeID) {
 			successCount++
 		}
+		if successCount+(len(peerList)-received) < requiredAcks {
+			break
+		}
+	}
+
+	// track replications that complete after the client was answered
+	if pending := len(peerList) - received; pending > 0 {
+		go c.drainBackground(key, results, pending)
 	}
 
 	c.logger.Info("replication completed",
 		zap.String("key", key),
 		zap.Int("success_count", successCount),
 		zap.Int("required_acks", requiredAcks),
-		zap.Int("total_peers", len(peerList)))
+		zap.Int("total_peers", len(peerList)),
+		zap.Int("pending_peers", len(peerList)-received))
 
 	if successCount < requiredAcks {
-		return successCount, allResults, fmt.Errorf("insufficient acknowledgements: got %d, need %d", successCount, requiredAcks)
+		return successCount, allResults, &ErrInsufficientAcks{Got: successCount, Need: requiredAcks}
 	}
 
 	return successCount, allResults, nil
 }
 
+// with verifyAcks, confirm the peer stores key at ts or newer
+func (c *Coordinator) verifyAck(ctx context.Context, client proto.ACPServiceClient, key string, ts hlc.HLC) error {
+	if !c.verifyAcks {
+		return nil
+	}
+	resp, err := client.GetLocal(ctx, &proto.GetRequest{Key: key})
+	if err != nil {
+		return fmt.Errorf("read back failed: %w", err)
+	}
+	if !resp.Found {
+		return errors.New("peer acked but does not hold the key")
+	}
+	if stored := hlc.FromProto(resp.Hlc); stored.HappensBefore(ts) {
+		return fmt.Errorf("peer acked but holds older version %v", stored)
+	}
+	return nil
+}
+
+// grpc codes worth retrying within a single replication: the peer may
+// answer a moment later. anything else will fail the same way again
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// replicate to one peer, retrying transient errors while ctx allows
+func (c *Coordinator) replicateWithRetry(ctx context.Context, peerAddr string, client proto.ACPServiceClient, req *proto.ReplicateRequest) (*proto.ReplicateResponse, error) {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		c.recordReplicateBytes(req)
+		resp, err := client.Replicate(ctx, req)
+		if err == nil || attempt >= c.replicateRetries || !retryable(err) {
+			return resp, err
+		}
+
+		// not enough budget left for another attempt
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return resp, err
+		}
+
+		c.logger.Debug("retrying replication after transient error",
+			zap.String("peer", peerAddr),
+			zap.String("key", req.Key),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err))
+		c.metrics.ReplicateRetries.Inc()
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return resp, err
+		}
+		backoff *= 2
+	}
+}
+
+// count the wire size of a replication request about to be sent
+func (c *Coordinator) recordReplicateBytes(req *proto.ReplicateRequest) {
+	size := protobuf.Size(req)
+	c.replicatedBytes.Add(int64(size))
+	c.metrics.ReplicateBytes.Add(float64(size))
+}
+
+// startwriteamplification sets the write amplification gauge every interval
+// to the bytes replicated per client value byte written during that interval
+func (c *Coordinator) StartWriteAmplification(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.updateWriteAmplification()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// an interval without client writes leaves the gauge unchanged
+func (c *Coordinator) updateWriteAmplification() {
+	written := c.writtenBytes.Swap(0)
+	replicated := c.replicatedBytes.Swap(0)
+	if written == 0 {
+		return
+	}
+	c.metrics.WriteAmplification.Set(float64(replicated) / float64(written))
+}
+
+// best-effort replication to observer nodes, failures are only logged and
+// left to reconciliation
+func (c *Coordinator) replicateToObservers(ctx context.Context, req *proto.ReplicateRequest, observers map[string]proto.ACPServiceClient) {
+	var wg sync.WaitGroup
+	for addr, client := range observers {
+		wg.Add(1)
+		go func(observerAddr string, observerClient proto.ACPServiceClient) {
+			defer wg.Done()
+
+			repCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			c.recordReplicateBytes(req)
+			resp, err := observerClient.Replicate(repCtx, req)
+			if err != nil || !resp.Success {
+				c.logger.Debug("observer replication failed",
+					zap.String("observer", observerAddr),
+					zap.String("key", req.Key),
+					zap.Error(err))
+				c.metrics.ReplicateBackground.WithLabelValues("failure").Inc()
+				return
+			}
+			c.metrics.ReplicateBackground.WithLabelValues("success").Inc()
+		}(addr, client)
+	}
+	wg.Wait()
+}
+
+// collects replication results that arrive after Replicate has returned
+func (c *Coordinator) drainBackground(key string, results <-chan ReplicateResult, pending int) {
+	for i := 0; i < pending; i++ {
+		result := <-results
+		if result.Success {
+			c.metrics.ReplicateBackground.WithLabelValues("success").Inc()
+		} else {
+			c.metrics.ReplicateBackground.WithLabelValues("failure").Inc()
+		}
+	}
+
+	c.logger.Debug("background replication completed",
+		zap.String("key", key),
+		zap.Int("peers", pending))
+}
+
 // query R replicas for a key and return all versions
+// R counts responding nodes: self plus every peer that answered, including
+// peers that do not have the key (they count but are not returned)
+// returns as soon as requiredResponses (including self) have answered,
+// cancelling the rpcs still in flight to slower peers
 func (c *Coordinator) QueryReplicas(ctx context.Context, key string, requiredResponses int) ([]ReplicaValue, error) {
-	// get snapshot of current peers
-	c.mu.RLock()
-	peerList := make(map[string]proto.ACPServiceClient, len(c.peers))
-	for addr, client := range c.peers {
-		peerList[addr] = client
+	return c.queryReplicas(ctx, key, requiredResponses, true, false)
+}
+
+// same as QueryReplicas, but R counts only nodes that returned a value for the
+// key. self counts when selfFound is set. a key missing on too many replicas
+// fails with ErrInsufficientReplicas instead of reading as not found
+func (c *Coordinator) QueryValueReplicas(ctx context.Context, key string, requiredValues int, selfFound bool) ([]ReplicaValue, error) {
+	return c.queryReplicas(ctx, key, requiredValues, selfFound, true)
+}
+
+func (c *Coordinator) queryReplicas(ctx context.Context, key string, required int, selfCounts, valuesOnly bool) ([]ReplicaValue, error) {
+	// get snapshot of current peers, observers are not part of read quorums
+	// and only owners of the key are asked
+	peerList, _ := c.peerSnapshot()
+	peerList = c.ownerPeers(key, peerList)
+
+	counted := 0
+	if selfCounts && c.IsOwner(key) {
+		counted = 1
 	}
-	c.mu.RUnlock()
 
 	if len(peerList) == 0 {
-		if requiredResponses > 1 {
-			return nil, fmt.Errorf("insufficient replicas: need %d, have only self", requiredResponses)
+		if required > counted {
+			return nil, fmt.Errorf("%w: need %d replicas, have only self", ErrNoPeers, required)
 		}
 		return []ReplicaValue{}, nil
 	}
 
-	results := make(chan ReplicaValue, len(peerList))
-	var wg sync.WaitGroup
+	// self alone satisfies the quorum, nothing to wait for
+	if required <= counted {
+		return []ReplicaValue{}, nil
+	}
 
-	// query all peers parallel
-	for addr, client := range peerList {
-		wg.Add(1)
-		go func(peerAddr string, peerClient proto.ACPServiceClient) {
-			defer wg.Done()
+	// cancelled once quorum is reached so slow peers stop early
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-			queryCtx, cancel := context.WithTimeout(ctx, c.timeout)
-			defer cancel()
+	// buffered to len(peerList) so goroutines never block after we return
+	results := make(chan queryResult, len(peerList))
 
-			req := &proto.GetRequest{Key: key}
-			resp, err := peerClient.GetLocal(queryCtx, req)
+	// query all peers parallel, up to maxFanOut
+	c.fanOut(peerList, func(peerAddr string, peerClient proto.ACPServiceClient) {
+		rpcCtx, rpcCancel := context.WithTimeout(queryCtx, c.timeout)
+		defer rpcCancel()
 
-			if err != nil {
+		req := &proto.GetRequest{Key: key}
+		resp, err := peerClient.GetLocal(rpcCtx, req)
+
+		if err != nil {
+			// cancellation after quorum is expected, not a peer failure
+			if queryCtx.Err() == nil {
 				c.logger.Warn("query failed",
 					zap.String("peer", peerAddr),
 					zap.String("key", key),
 					zap.Error(err))
 				c.metrics.Errors.WithLabelValues("rpc").Inc()
-				return
 			}
+			results <- queryResult{err: err}
+			return
+		}
 
-			if resp.Found {
-				results <- ReplicaValue{
-					PeerAddr:  peerAddr,
-					Value:     resp.Value,
-					Version:   resp.Version,
-					Timestamp: resp.Timestamp,
-					HLC:       hlc.FromProto(resp.Hlc),
-					IsStale:   resp.IsStale,
-					Found:     true,
-				}
+		result := queryResult{}
+		if resp.Found {
+			result.value = ReplicaValue{
+				PeerAddr:    peerAddr,
+				NodeID:      resp.NodeId,
+				Value:       resp.Value,
+				Version:     resp.Version,
+				Timestamp:   resp.Timestamp,
+				HLC:         hlc.FromProto(resp.Hlc),
+				IsStale:     resp.IsStale,
+				Found:       true,
+				ContentType: resp.ContentType,
 			}
-		}(addr, client)
+		}
+		result.nodeID = resp.NodeId
+		results <- result
+	})
+
+	// collect results until quorum is reached, every peer has answered, or
+	// the remaining peers can no longer make up the difference
+	var allResults []ReplicaValue
+	for received := 0; received < len(peerList) && counted < required; received++ {
+		result := <-results
+		if result.err == nil && !c.isQuarantined(result.nodeID) {
+			if result.value.Found {
+				allResults = append(allResults, result.value)
+			}
+			if result.value.Found || !valuesOnly {
+				counted++
+			}
+		}
+		if counted+(len(peerList)-received-1) < required {
+			break
+		}
 	}
 
-	// close results chan when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	if counted < required {
+		return nil, &ErrInsufficientReplicas{Got: counted, Need: required, Partial: allResults}
+	}
 
-	// collect results
-	var allResults []ReplicaValue
-	for result := range results {
-		allResults = append(allResults, result)
+	return allResults, nil
+}
+
+// replicateto sends req to the connected peer at addr alone
+func (c *Coordinator) ReplicateTo(ctx context.Context, addr string, req *proto.ReplicateRequest) error {
+	c.mu.RLock()
+	client, ok := c.peers[addr]
+	c.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPeer, addr)
 	}
 
-	// add 1 for self
-	totalResponses := len(allResults) + 1
+	rpcCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	c.recordReplicateBytes(req)
+	resp, err := client.Replicate(rpcCtx, req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("peer %s rejected the write: %s", addr, resp.Error)
+	}
+	return nil
+}
 
-	if totalResponses < requiredResponses {
-		return nil, fmt.Errorf("insufficient responses: got %d, need %d", totalResponses, requiredResponses)
+// getlocalfrom reads key from the store of the peer at addr alone, for
+// inspecting one replica's view. addr may be a voter or an observer
+func (c *Coordinator) GetLocalFrom(ctx context.Context, addr, key string) (*proto.GetResponse, error) {
+	c.mu.RLock()
+	client, ok := c.peers[addr]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPeer, addr)
 	}
 
-	return allResults, nil
+	rpcCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return client.GetLocal(rpcCtx, &proto.GetRequest{Key: key})
+}
+
+// listkeysfrom lists one page of keys held by a single peer
+func (c *Coordinator) ListKeysFrom(ctx context.Context, addr string, req *proto.ListKeysRequest) (*proto.ListKeysResponse, error) {
+	c.mu.RLock()
+	client, ok := c.peers[addr]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPeer, addr)
+	}
+
+	rpcCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return client.ListKeys(rpcCtx, req)
+}
+
+// outcome of a single peer query
+type queryResult struct {
+	value  ReplicaValue
+	nodeID string
+	err    error
 }
 
 // value returned from a replica
 type ReplicaValue struct {
-	PeerAddr  string
-	Value     []byte
-	Version   int64
-	Timestamp int64
-	HLC       hlc.HLC // hybrid logical clock timestamp
-	IsStale   bool    // indicates if data exceeds staleness bound
-	Found     bool
+	PeerAddr    string
+	NodeID      string // responding node
+	Value       []byte
+	Version     int64
+	Timestamp   int64
+	HLC         hlc.HLC // hybrid logical clock timestamp
+	IsStale     bool    // indicates if data exceeds staleness bound
+	Found       bool
+	ContentType string
 }
 
-// get most recent val based on hlc timestamp (lww using hlc)
+// get most recent val based on hlc timestamp (lww using hlc). the result
+// does not depend on the order of values, precedence is:
+//  1. storage.ShouldReplace on the hlcs, the same rule stores apply writes by
+//  2. non-stale over stale
+//  3. lower peer address
 func GetMostRecent(values []ReplicaValue) (ReplicaValue, bool) {
 	if len(values) == 0 {
 		return ReplicaValue{}, false
@@ -455,11 +1272,42 @@ func GetMostRecent(values []ReplicaValue) (ReplicaValue, bool) {
 
 	mostRecent := values[0]
 	for _, v := range values[1:] {
-		// use hlc comparison for proper causality tracking
-		if v.HLC.HappensAfter(mostRecent.HLC) {
+		if moreRecent(v, mostRecent) {
 			mostRecent = v
 		}
 	}
 
 	return mostRecent, true
 }
+
+// freshonly drops replicas that reported their value as stale. if every
+// replica is stale the values are returned unchanged, so the caller's own
+// staleness check still decides the read
+func FreshOnly(values []ReplicaValue) []ReplicaValue {
+	fresh := make([]ReplicaValue, 0, len(values))
+	for _, v := range values {
+		if !v.IsStale {
+			fresh = append(fresh, v)
+		}
+	}
+	if len(fresh) == 0 {
+		return values
+	}
+	return fresh
+}
+
+// reports whether a takes precedence over b, see GetMostRecent
+func moreRecent(a, b ReplicaValue) bool {
+	// use hlc comparison for proper causality tracking
+	av, bv := storage.VersionedValue{HLC: a.HLC}, storage.VersionedValue{HLC: b.HLC}
+	if storage.ShouldReplace(bv, av) {
+		return true
+	}
+	if storage.ShouldReplace(av, bv) {
+		return false
+	}
+	if a.IsStale != b.IsStale {
+		return !a.IsStale
+	}
+	return a.PeerAddr < b.PeerAddr
+}
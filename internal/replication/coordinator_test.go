@@ -1,9 +1,27 @@
 package replication
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/rachitkumar205/acp-kv/api/proto"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/partition"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestGetMostRecent(t *testing.T) {
@@ -63,7 +81,7 @@ func TestGetMostRecent(t *testing.T) {
 				{PeerAddr: "node1", Value: []byte("v1"), Timestamp: 100, HLC: hlc.HLC{Physical: 100, Logical: 0, NodeID: "node1"}},
 				{PeerAddr: "node2", Value: []byte("v2"), Timestamp: 100, HLC: hlc.HLC{Physical: 100, Logical: 0, NodeID: "node2"}},
 			},
-			expected: ReplicaValue{PeerAddr: "node1", Value: []byte("v1"), Timestamp: 100, HLC: hlc.HLC{Physical: 100, Logical: 0, NodeID: "node1"}},
+			expected: ReplicaValue{PeerAddr: "node2", Value: []byte("v2"), Timestamp: 100, HLC: hlc.HLC{Physical: 100, Logical: 0, NodeID: "node2"}},
 			found:    true,
 		},
 	}
@@ -83,3 +101,1101 @@ func TestGetMostRecent(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMostRecent_AgreesWithStoreApply(t *testing.T) {
+	pairs := [][2]hlc.HLC{
+		{{Physical: 200, NodeID: "node1"}, {Physical: 100, NodeID: "node2"}},
+		{{Physical: 100, Logical: 2, NodeID: "node1"}, {Physical: 100, Logical: 1, NodeID: "node2"}},
+		{{Physical: 100, NodeID: "node1"}, {Physical: 100, NodeID: "node2"}},
+	}
+
+	for _, pair := range pairs {
+		a := ReplicaValue{PeerAddr: "peer1", HLC: pair[0], Found: true}
+		b := ReplicaValue{PeerAddr: "peer2", HLC: pair[1], Found: true}
+
+		ab, _ := GetMostRecent([]ReplicaValue{a, b})
+		ba, _ := GetMostRecent([]ReplicaValue{b, a})
+		if ab.HLC != ba.HLC {
+			t.Fatalf("expected both orders to pick the same winner, got %v and %v", ab.HLC, ba.HLC)
+		}
+
+		// the store converges to the same write whichever arrives first
+		store := storage.NewStore()
+		store.PutIfNewer("key", nil, pair[1].NodeID, pair[1])
+		store.PutIfNewer("key", nil, pair[0].NodeID, pair[0])
+		if vv, _ := store.Get("key"); vv.HLC != ab.HLC {
+			t.Errorf("expected read merge winner %v to match stored %v", ab.HLC, vv.HLC)
+		}
+	}
+}
+
+func TestGetMostRecent_TiesAreDeterministic(t *testing.T) {
+	tied := hlc.HLC{Physical: 100, Logical: 2}
+	withNode := func(h hlc.HLC, nodeID string) hlc.HLC {
+		h.NodeID = nodeID
+		return h
+	}
+
+	tests := []struct {
+		name     string
+		values   []ReplicaValue
+		expected string // PeerAddr of the winner
+	}{
+		{
+			name: "higher writer node id wins",
+			values: []ReplicaValue{
+				{PeerAddr: "peer1", HLC: withNode(tied, "node1")},
+				{PeerAddr: "peer2", HLC: withNode(tied, "node3")},
+				{PeerAddr: "peer3", HLC: withNode(tied, "node2")},
+			},
+			expected: "peer2",
+		},
+		{
+			name: "non-stale preferred for the same write",
+			values: []ReplicaValue{
+				{PeerAddr: "peer1", HLC: withNode(tied, "node1"), IsStale: true},
+				{PeerAddr: "peer2", HLC: withNode(tied, "node1")},
+				{PeerAddr: "peer3", HLC: withNode(tied, "node1"), IsStale: true},
+			},
+			expected: "peer2",
+		},
+		{
+			name: "node id outranks staleness",
+			values: []ReplicaValue{
+				{PeerAddr: "peer1", HLC: withNode(tied, "node1")},
+				{PeerAddr: "peer2", HLC: withNode(tied, "node2"), IsStale: true},
+			},
+			expected: "peer2",
+		},
+		{
+			name: "lowest peer address breaks full ties",
+			values: []ReplicaValue{
+				{PeerAddr: "peer3", HLC: withNode(tied, "node1")},
+				{PeerAddr: "peer1", HLC: withNode(tied, "node1")},
+				{PeerAddr: "peer2", HLC: withNode(tied, "node1")},
+			},
+			expected: "peer1",
+		},
+		{
+			name: "newer hlc beats every tiebreak",
+			values: []ReplicaValue{
+				{PeerAddr: "peer1", HLC: withNode(tied, "node9")},
+				{PeerAddr: "peer2", HLC: hlc.HLC{Physical: 100, Logical: 3, NodeID: "node1"}, IsStale: true},
+			},
+			expected: "peer2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every rotation of the input must select the same winner
+			for i := range tt.values {
+				rotated := append(slices.Clone(tt.values[i:]), tt.values[:i]...)
+				result, found := GetMostRecent(rotated)
+				if !found || result.PeerAddr != tt.expected {
+					t.Errorf("rotation %d: expected %s, got %s", i, tt.expected, result.PeerAddr)
+				}
+			}
+		})
+	}
+}
+
+// shared metrics instance to avoid duplicate registration
+var testMetrics = metrics.NewMetrics("test")
+
+// fakePeer answers GetLocal and Replicate after a fixed delay
+type fakePeer struct {
+	proto.ACPServiceClient
+	delay      time.Duration
+	value      []byte
+	replicated chan struct{}
+	err        error
+	missing    bool   // answer GetLocal with not found
+	nodeID     string // reported node id, "fake" when empty
+	failFirst  int32  // only the first failFirst replicate calls return err, 0 means all
+	calls      atomic.Int32
+}
+
+func (f *fakePeer) id() string {
+	if f.nodeID == "" {
+		return "fake"
+	}
+	return f.nodeID
+}
+
+func (f *fakePeer) Replicate(ctx context.Context, req *proto.ReplicateRequest, opts ...grpc.CallOption) (*proto.ReplicateResponse, error) {
+	call := f.calls.Add(1)
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.err != nil && (f.failFirst == 0 || call <= f.failFirst) {
+		return nil, f.err
+	}
+	if f.replicated != nil {
+		close(f.replicated)
+	}
+	return &proto.ReplicateResponse{Success: true, NodeId: f.id()}, nil
+}
+
+func (f *fakePeer) GetLocal(ctx context.Context, req *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	f.calls.Add(1)
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.missing {
+		return &proto.GetResponse{Found: false, NodeId: f.id()}, nil
+	}
+	return &proto.GetResponse{
+		Found:  true,
+		Value:  f.value,
+		Hlc:    &proto.HLC{Physical: time.Now().UnixNano()},
+		NodeId: f.id(),
+	}, nil
+}
+
+func newTestCoordinator(peers map[string]proto.ACPServiceClient, timeout time.Duration) *Coordinator {
+	return &Coordinator{
+		nodeID:    "node1",
+		peers:     peers,
+		conns:     make(map[string]*grpc.ClientConn),
+		logger:    zap.NewNop(),
+		metrics:   testMetrics,
+		timeout:   timeout,
+		pending:   make(map[string]struct{}),
+		dial:      dialPeer,
+		observers: make(map[string]bool),
+
+		pendingAdded: make(chan struct{}, 1),
+	}
+}
+
+func TestQueryReplicas_ReturnsAtQuorum(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"fast":  &fakePeer{delay: 0, value: []byte("fast")},
+		"slow1": &fakePeer{delay: 2 * time.Second, value: []byte("slow")},
+		"slow2": &fakePeer{delay: 2 * time.Second, value: []byte("slow")},
+		"slow3": &fakePeer{delay: 2 * time.Second, value: []byte("slow")},
+	}, 5*time.Second)
+
+	start := time.Now()
+	values, err := coord.QueryReplicas(context.Background(), "key1", 2)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("expected quorum read to succeed, got %v", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("expected read to return after R responses, took %v", elapsed)
+	}
+	if len(values) != 1 || string(values[0].Value) != "fast" {
+		t.Errorf("expected only the fast peer's value, got %d values", len(values))
+	}
+}
+
+func TestQueryReplicas_InsufficientResponses(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"fast": &fakePeer{delay: 0, value: []byte("fast")},
+		"slow": &fakePeer{delay: time.Second, value: []byte("slow")},
+	}, 100*time.Millisecond)
+
+	if _, err := coord.QueryReplicas(context.Background(), "key1", 3); err == nil {
+		t.Error("expected error when slow peer times out before quorum")
+	}
+}
+
+func TestQueryReplicas_NotFoundPeersCountAsResponses(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"missing1": &fakePeer{missing: true},
+		"missing2": &fakePeer{missing: true},
+		"found":    &fakePeer{delay: 50 * time.Millisecond, value: []byte("v")},
+	}, time.Second)
+
+	// self plus two not-found peers satisfy R=3 without waiting for the value
+	values, err := coord.QueryReplicas(context.Background(), "key1", 3)
+	if err != nil {
+		t.Fatalf("expected responding nodes to satisfy quorum, got %v", err)
+	}
+	if len(values) != 0 {
+		t.Errorf("expected not-found peers to be filtered out, got %d values", len(values))
+	}
+}
+
+func TestQueryValueReplicas_CountsOnlyValues(t *testing.T) {
+	peers := map[string]proto.ACPServiceClient{
+		"missing1": &fakePeer{missing: true},
+		"missing2": &fakePeer{missing: true},
+		"found":    &fakePeer{delay: 50 * time.Millisecond, value: []byte("v")},
+	}
+
+	tests := []struct {
+		name      string
+		required  int
+		selfFound bool
+		wantErr   bool
+		wantGot   int
+	}{
+		{name: "self and one peer value", required: 2, selfFound: true},
+		{name: "peer value without self", required: 1, selfFound: false},
+		// gives up once the not-found answers leave too few peers to reach R
+		{name: "self missing, one peer value", required: 2, selfFound: false, wantErr: true, wantGot: 0},
+		{name: "more values than exist", required: 3, selfFound: true, wantErr: true, wantGot: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coord := newTestCoordinator(peers, time.Second)
+
+			values, err := coord.QueryValueReplicas(context.Background(), "key1", tt.required, tt.selfFound)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected quorum of values, got %v", err)
+				}
+				if len(values) != 1 || string(values[0].Value) != "v" {
+					t.Errorf("expected the found peer's value, got %d values", len(values))
+				}
+				return
+			}
+
+			var replicasErr *ErrInsufficientReplicas
+			if !errors.As(err, &replicasErr) {
+				t.Fatalf("expected ErrInsufficientReplicas, got %v", err)
+			}
+			if replicasErr.Got != tt.wantGot || replicasErr.Need != tt.required {
+				t.Errorf("expected got=%d need=%d, got got=%d need=%d", tt.wantGot, tt.required, replicasErr.Got, replicasErr.Need)
+			}
+		})
+	}
+}
+
+func TestReplicate_ReturnsAtWriteQuorum(t *testing.T) {
+	slow := &fakePeer{delay: 300 * time.Millisecond, replicated: make(chan struct{})}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"fast": &fakePeer{delay: 0},
+		"slow": slow,
+	}, 2*time.Second)
+
+	// cancel the caller's context on return, as a grpc handler would
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	acks, _, err := coord.Replicate(ctx, "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2)
+	elapsed := time.Since(start)
+	cancel()
+
+	if err != nil {
+		t.Fatalf("expected write to succeed, got %v", err)
+	}
+	if acks != 2 {
+		t.Errorf("expected 2 acks at return, got %d", acks)
+	}
+	if elapsed > 200*time.Millisecond {
+		t.Errorf("expected write to return after W acks, took %v", elapsed)
+	}
+
+	// slow peer should still receive the write in the background
+	select {
+	case <-slow.replicated:
+	case <-time.After(time.Second):
+		t.Error("expected background replication to slow peer to complete")
+	}
+}
+
+func TestReplicate_InsufficientAcksError(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"bad1": &fakePeer{err: errors.New("unavailable")},
+		"bad2": &fakePeer{err: errors.New("unavailable")},
+	}, time.Second)
+
+	_, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2)
+
+	var acksErr *ErrInsufficientAcks
+	if !errors.As(err, &acksErr) {
+		t.Fatalf("expected ErrInsufficientAcks, got %v", err)
+	}
+	if acksErr.Got != 1 || acksErr.Need != 2 {
+		t.Errorf("expected got=1 need=2, got got=%d need=%d", acksErr.Got, acksErr.Need)
+	}
+}
+
+func TestReplicate_ObserverDoesNotCountTowardQuorum(t *testing.T) {
+	observer := &fakePeer{replicated: make(chan struct{})}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"voter":    &fakePeer{err: errors.New("unavailable")},
+		"observer": observer,
+	}, time.Second)
+	coord.observers["observer"] = true
+
+	_, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2)
+
+	var acksErr *ErrInsufficientAcks
+	if !errors.As(err, &acksErr) {
+		t.Fatalf("expected ErrInsufficientAcks, got %v", err)
+	}
+	if acksErr.Got != 1 {
+		t.Errorf("expected only the local ack to count, got %d", acksErr.Got)
+	}
+
+	// observer still receives the write
+	select {
+	case <-observer.replicated:
+	case <-time.After(time.Second):
+		t.Error("expected observer to receive the write")
+	}
+}
+
+func TestReplicate_VerifyAcksRejectsUnpersistedWrite(t *testing.T) {
+	peers := map[string]proto.ACPServiceClient{
+		"good": &fakePeer{},
+		"lost": &fakePeer{missing: true}, // acks but never stores
+	}
+
+	// without verification both acks count
+	coord := newTestCoordinator(peers, time.Second)
+	if acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 3); err != nil || acks != 3 {
+		t.Fatalf("expected 3 acks without verification, got %d err=%v", acks, err)
+	}
+
+	coord = newTestCoordinator(peers, time.Second)
+	coord.SetVerifyAcks(true)
+	_, results, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 3)
+	var acksErr *ErrInsufficientAcks
+	if !errors.As(err, &acksErr) {
+		t.Fatalf("expected the unpersisted ack to be rejected, got %v", err)
+	}
+	for _, r := range results {
+		if r.PeerAddr == "lost" && (r.Success || r.Error == nil) {
+			t.Errorf("expected verification failure for lost peer, got %+v", r)
+		}
+	}
+}
+
+func TestQueryReplicas_SkipsObservers(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"voter":    &fakePeer{value: []byte("v")},
+		"observer": &fakePeer{value: []byte("v")},
+	}, 200*time.Millisecond)
+	coord.observers["observer"] = true
+
+	if _, err := coord.QueryReplicas(context.Background(), "key1", 3); err == nil {
+		t.Fatal("expected observer response not to count toward R")
+	}
+	if got := coord.GetConnectedPeerAddresses(); len(got) != 1 || got[0] != "voter" {
+		t.Errorf("expected only voter in connected peers, got %v", got)
+	}
+}
+
+// staticPartitioner assigns every key the same owners
+type staticPartitioner []string
+
+func (p staticPartitioner) Owners(key string) []string { return p }
+
+func TestPartitioner_TargetsOnlyOwners(t *testing.T) {
+	owner := &fakePeer{value: []byte("v")}
+	other := &fakePeer{value: []byte("v")}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"owner:8080": owner,
+		"other:8080": other,
+	}, time.Second)
+	coord.SetPartitioner(staticPartitioner{"self:8080", "owner:8080"}, "self:8080")
+
+	acks, results, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2)
+	if err != nil || acks != 2 {
+		t.Fatalf("expected write to reach W=2 among owners, got acks=%d err=%v", acks, err)
+	}
+	if len(results) != 1 || results[0].PeerAddr != "owner:8080" {
+		t.Errorf("expected only the owner peer to be replicated to, got %v", results)
+	}
+
+	if _, err := coord.QueryReplicas(context.Background(), "key1", 2); err != nil {
+		t.Fatalf("expected read to reach R=2 among owners, got %v", err)
+	}
+
+	if n := other.calls.Load(); n != 0 {
+		t.Errorf("expected no rpcs to the non-owner, got %d", n)
+	}
+	if n := owner.calls.Load(); n != 2 {
+		t.Errorf("expected one write and one read on the owner, got %d", n)
+	}
+}
+
+func TestPartitioner_NonOwnerDoesNotAck(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"owner1:8080": &fakePeer{},
+		"owner2:8080": &fakePeer{delay: 50 * time.Millisecond, err: errors.New("unavailable")},
+	}, time.Second)
+	coord.SetPartitioner(staticPartitioner{"owner1:8080", "owner2:8080"}, "self:8080")
+
+	if coord.IsOwner("key1") {
+		t.Fatal("expected self not to own the key")
+	}
+
+	// self does not hold the key, so only owner1 acks
+	_, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2)
+	var acksErr *ErrInsufficientAcks
+	if !errors.As(err, &acksErr) || acksErr.Got != 1 {
+		t.Errorf("expected 1 ack without a self ack, got %v", err)
+	}
+}
+
+func TestPartitioner_DiscoveredMembersJoinRing(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	ring := partition.NewConsistentHash(nil, 2, 64)
+	coord.SetPartitioner(ring, "self:8080")
+
+	// what a discovery tick does with a new membership, self included
+	coord.rebalance([]string{"node1:8080", "self:8080"})
+	owners := ring.Owners("key1")
+	if len(owners) != 2 || !slices.Contains(owners, "node1:8080") || !slices.Contains(owners, "self:8080") {
+		t.Errorf("expected both members to own the key, got %v", owners)
+	}
+}
+
+func TestPartitioner_ReplicationFactor(t *testing.T) {
+	const rf = 3
+	fakes := make(map[string]*fakePeer)
+	peers := make(map[string]proto.ACPServiceClient)
+	addrs := []string{}
+	for i := 1; i < 7; i++ {
+		addr := fmt.Sprintf("node%d:8080", i)
+		fakes[addr] = &fakePeer{value: []byte("v")}
+		peers[addr] = fakes[addr]
+		addrs = append(addrs, addr)
+	}
+	coord := newTestCoordinator(peers, time.Second)
+	coord.configuredPeers = addrs
+	ring := partition.NewConsistentHash(nil, rf, 64)
+	coord.SetPartitioner(ring, "node0:8080")
+
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		for _, f := range fakes {
+			f.calls.Store(0)
+		}
+		owners := ring.Owners(key)
+		if len(owners) != rf {
+			t.Fatalf("expected %d owners for %s, got %v", rf, key, owners)
+		}
+
+		// W and R equal to rf wait for every owner
+		if _, _, err := coord.Replicate(context.Background(), key, []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, rf); err != nil {
+			t.Fatalf("expected write of %s to reach all %d owners, got %v", key, rf, err)
+		}
+		if _, err := coord.QueryReplicas(context.Background(), key, rf); err != nil {
+			t.Fatalf("expected read of %s to reach all %d owners, got %v", key, rf, err)
+		}
+
+		copies := 0
+		if coord.IsOwner(key) {
+			copies++
+		}
+		for addr, f := range fakes {
+			calls := f.calls.Load()
+			switch {
+			case slices.Contains(owners, addr):
+				copies++
+				if calls != 2 {
+					t.Errorf("expected one write and one read on owner %s of %s, got %d", addr, key, calls)
+				}
+			case calls != 0:
+				t.Errorf("expected no rpcs to non-owner %s for %s, got %d", addr, key, calls)
+			}
+		}
+		if copies != rf {
+			t.Errorf("expected %s stored on exactly %d nodes, got %d", key, rf, copies)
+		}
+	}
+}
+
+func TestQueryReplicas_InsufficientReplicasError(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"slow": &fakePeer{delay: time.Second},
+	}, 50*time.Millisecond)
+
+	_, err := coord.QueryReplicas(context.Background(), "key1", 2)
+
+	var replicasErr *ErrInsufficientReplicas
+	if !errors.As(err, &replicasErr) {
+		t.Fatalf("expected ErrInsufficientReplicas, got %v", err)
+	}
+	if replicasErr.Got != 1 || replicasErr.Need != 2 {
+		t.Errorf("expected got=1 need=2, got got=%d need=%d", replicasErr.Got, replicasErr.Need)
+	}
+}
+
+func TestNoPeersError(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+
+	if _, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 2); !errors.Is(err, ErrNoPeers) {
+		t.Errorf("expected Replicate to return ErrNoPeers, got %v", err)
+	}
+	if _, err := coord.QueryReplicas(context.Background(), "key1", 2); !errors.Is(err, ErrNoPeers) {
+		t.Errorf("expected QueryReplicas to return ErrNoPeers, got %v", err)
+	}
+	if acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, 1); err != nil || acks != 1 {
+		t.Errorf("expected W=1 write to succeed with self ack, got acks=%d err=%v", acks, err)
+	}
+}
+
+func TestPeerAddress(t *testing.T) {
+	addr := PeerAddress("acp-node-1", "acp-headless", "default", 9000)
+	expected := "acp-node-1.acp-headless.default.svc.cluster.local:9000"
+	if addr != expected {
+		t.Errorf("expected %s, got %s", expected, addr)
+	}
+}
+
+func TestStartPeerReconnect_ConnectsLatePeer(t *testing.T) {
+	var available atomic.Bool
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		if !available.Load() {
+			return nil, errors.New("no such host")
+		}
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	defer coord.Close()
+
+	if err := coord.addPeer("late:8080"); err == nil {
+		t.Fatal("expected initial connection to fail")
+	}
+	coord.pending["late:8080"] = struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		coord.StartPeerReconnect(ctx, 10*time.Millisecond, 50*time.Millisecond)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if len(coord.GetConnectedPeerAddresses()) != 0 {
+		t.Fatal("expected peer to remain disconnected while unavailable")
+	}
+
+	available.Store(true)
+
+	deadline := time.Now().Add(time.Second)
+	for len(coord.GetConnectedPeerAddresses()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	peers := coord.GetConnectedPeerAddresses()
+	if len(peers) != 1 || peers[0] != "late:8080" {
+		t.Errorf("expected late peer to be connected, got %v", peers)
+	}
+
+	// the loop keeps running with nothing pending until ctx is cancelled
+	select {
+	case <-done:
+		t.Fatal("expected reconnect loop to stay alive with nothing pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected reconnect loop to stop on cancel")
+	}
+}
+
+func TestStartPeerReconnect_RetriesPeersAddedLater(t *testing.T) {
+	var available atomic.Bool
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		if !available.Load() {
+			return nil, errors.New("no such host")
+		}
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	defer coord.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go coord.StartPeerReconnect(ctx, 10*time.Millisecond, 50*time.Millisecond)
+
+	// loop starts idle, the peer fails to connect only after it is running
+	time.Sleep(20 * time.Millisecond)
+	coord.UpdatePeers([]string{"late:8080"})
+	if len(coord.GetConnectedPeerAddresses()) != 0 {
+		t.Fatal("expected initial connection to fail")
+	}
+
+	available.Store(true)
+
+	deadline := time.Now().Add(time.Second)
+	for len(coord.GetConnectedPeerAddresses()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if peers := coord.GetConnectedPeerAddresses(); !slices.Equal(peers, []string{"late:8080"}) {
+		t.Errorf("expected late peer to be connected, got %v", peers)
+	}
+}
+
+func TestCoordinator_ConnectionGaugeReturnsToBaseline(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	baseline, _ := reader.GetGaugeValue(testMetrics.CoordinatorConnections)
+
+	coord.addPeer("peer1:8080")
+	coord.addPeer("peer2:8080")
+	coord.addPeer("peer2:8080") // duplicate must not double count
+
+	if v, _ := reader.GetGaugeValue(testMetrics.CoordinatorConnections); v != baseline+2 {
+		t.Fatalf("expected %v connections after adding peers, got %v", baseline+2, v)
+	}
+
+	coord.removePeer("peer1:8080")
+	coord.removePeer("peer1:8080")
+	coord.Close()
+
+	if v, _ := reader.GetGaugeValue(testMetrics.CoordinatorConnections); v != baseline {
+		t.Errorf("expected connections back at baseline %v, got %v", baseline, v)
+	}
+}
+
+func TestCoordinator_RefusesOwnAddress(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	skippedBefore, _ := reader.GetCounterValue(testMetrics.SelfReplicationSkipped)
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	defer coord.Close()
+
+	// connected before the coordinator knew its own address
+	coord.addPeer("self:8080")
+	coord.SetSelfAddress("self:8080")
+	if got := coord.GetConnectedPeerAddresses(); len(got) != 0 {
+		t.Fatalf("expected self to be dropped once known, got %v", got)
+	}
+
+	if err := coord.addPeer("self:8080"); err != nil {
+		t.Fatalf("expected adding self to be skipped without error, got %v", err)
+	}
+	coord.UpdatePeers([]string{"peer1:8080", "self:8080"})
+
+	if got := coord.GetConnectedPeerAddresses(); !slices.Equal(got, []string{"peer1:8080"}) {
+		t.Errorf("expected only peer1 connected, got %v", got)
+	}
+	if got := coord.GetPeerAddresses(); !slices.Equal(got, []string{"peer1:8080"}) {
+		t.Errorf("expected self left out of the configured peers, got %v", got)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.SelfReplicationSkipped); v-skippedBefore != 2 {
+		t.Errorf("expected 2 skipped self additions, got %v", v-skippedBefore)
+	}
+}
+
+func TestUpdatePeers_AddsAndDropsConnections(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	defer coord.Close()
+
+	coord.UpdatePeers([]string{"peer1:8080", "peer2:8080"})
+	coord.pending["peer2:8080"] = struct{}{}
+
+	coord.UpdatePeers([]string{"peer1:8080", "peer3:8080"})
+
+	connected := coord.GetConnectedPeerAddresses()
+	slices.Sort(connected)
+	if !slices.Equal(connected, []string{"peer1:8080", "peer3:8080"}) {
+		t.Errorf("expected peer1 and peer3 connected, got %v", connected)
+	}
+
+	if configured := coord.GetPeerAddresses(); !slices.Equal(configured, []string{"peer1:8080", "peer3:8080"}) {
+		t.Errorf("expected configured peers to be replaced, got %v", configured)
+	}
+
+	if _, ok := coord.pending["peer2:8080"]; ok {
+		t.Error("expected removed peer to stop being retried")
+	}
+}
+
+func TestReconcilePeers_BoundsConnectConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.SetConnectConcurrency(3)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			cur := maxInFlight.Load()
+			if n <= cur || maxInFlight.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	defer coord.Close()
+
+	peers := make([]string, 30)
+	for i := range peers {
+		peers[i] = fmt.Sprintf("peer%d:8080", i)
+	}
+	coord.reconcilePeers(peers)
+
+	if got := len(coord.GetConnectedPeerAddresses()); got != len(peers) {
+		t.Errorf("expected all %d peers connected, got %d", len(peers), got)
+	}
+	if m := maxInFlight.Load(); m > 3 {
+		t.Errorf("expected at most 3 concurrent connection setups, got %d", m)
+	}
+	if m := maxInFlight.Load(); m < 2 {
+		t.Errorf("expected connection setup to run in parallel, max in flight was %d", m)
+	}
+}
+
+func TestQuarantine_ExcludesDriftingPeerUntilRecovered(t *testing.T) {
+	q := NewQuarantine(3, time.Minute, zap.NewNop(), testMetrics)
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"good":  &fakePeer{value: []byte("good"), nodeID: "node2"},
+		"drift": &fakePeer{value: []byte("drift"), nodeID: "node3"},
+	}, time.Second)
+	coord.SetQuarantine(q)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	// below the threshold the peer still counts
+	q.RecordRejection("node3")
+	q.RecordRejection("node3")
+	if q.IsQuarantined("node3") {
+		t.Fatal("expected peer below the rejection threshold to stay in quorum")
+	}
+	if acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 3); err != nil || acks != 3 {
+		t.Fatalf("expected 3 acks before quarantine, got %d (%v)", acks, err)
+	}
+
+	q.RecordRejection("node3")
+	if !q.IsQuarantined("node3") {
+		t.Fatal("expected repeatedly drifting peer to be quarantined")
+	}
+	if v, _ := reader.GetGaugeValue(testMetrics.PeersQuarantined); v != 1 {
+		t.Errorf("expected quarantined gauge 1, got %v", v)
+	}
+
+	// its ack no longer counts toward W
+	_, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 3)
+	var insufficient *ErrInsufficientAcks
+	if !errors.As(err, &insufficient) {
+		t.Fatalf("expected quarantined ack to be ignored, got %v", err)
+	}
+
+	// and its value is left out of reads
+	values, err := coord.QueryReplicas(context.Background(), "key1", 2)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(values) != 1 || values[0].NodeID != "node2" {
+		t.Errorf("expected only the healthy replica to be read, got %+v", values)
+	}
+	if _, err := coord.QueryReplicas(context.Background(), "key1", 3); err == nil {
+		t.Error("expected read needing the quarantined peer to miss quorum")
+	}
+
+	// an accepted timestamp re-admits it
+	q.RecordAccepted("node3")
+	if q.IsQuarantined("node3") {
+		t.Fatal("expected peer to be re-admitted once drift normalizes")
+	}
+	if v, _ := reader.GetGaugeValue(testMetrics.PeersQuarantined); v != 0 {
+		t.Errorf("expected quarantined gauge 0, got %v", v)
+	}
+	if acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 3); err != nil || acks != 3 {
+		t.Errorf("expected 3 acks after re-admission, got %d (%v)", acks, err)
+	}
+}
+
+func TestQuarantine_RejectionsOutsideWindowExpire(t *testing.T) {
+	q := NewQuarantine(2, 50*time.Millisecond, zap.NewNop(), testMetrics)
+
+	q.RecordRejection("node3")
+	time.Sleep(80 * time.Millisecond)
+	q.RecordRejection("node3")
+
+	if q.IsQuarantined("node3") {
+		t.Error("expected rejections spread beyond the window not to quarantine")
+	}
+}
+
+func TestReplicate_RetriesTransientFailure(t *testing.T) {
+	flaky := &fakePeer{err: status.Error(codes.Unavailable, "blip"), failFirst: 1}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{"flaky": flaky}, time.Second)
+	coord.SetReplicateRetries(2, 5*time.Millisecond)
+
+	acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 2)
+	if err != nil || acks != 2 {
+		t.Fatalf("expected the retried ack to count toward W, got %d acks (%v)", acks, err)
+	}
+	if n := flaky.calls.Load(); n != 2 {
+		t.Errorf("expected one retry, got %d calls", n)
+	}
+}
+
+func TestReplicate_NoRetryForPermanentError(t *testing.T) {
+	broken := &fakePeer{err: status.Error(codes.InvalidArgument, "bad request"), failFirst: 1}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{"broken": broken}, time.Second)
+	coord.SetReplicateRetries(2, 5*time.Millisecond)
+
+	if _, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 2); err == nil {
+		t.Fatal("expected non-retryable failure to miss W")
+	}
+	if n := broken.calls.Load(); n != 1 {
+		t.Errorf("expected no retry for a permanent error, got %d calls", n)
+	}
+}
+
+func TestReplicate_RetriesBoundedByTimeout(t *testing.T) {
+	down := &fakePeer{err: status.Error(codes.Unavailable, "down")}
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{"down": down}, 100*time.Millisecond)
+	coord.SetReplicateRetries(100, 20*time.Millisecond)
+
+	start := time.Now()
+	if _, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 0, hlc.HLC{}, 2); err == nil {
+		t.Fatal("expected replication to an unavailable peer to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected retries to stop at the replication timeout, took %v", elapsed)
+	}
+	if n := down.calls.Load(); n >= 100 {
+		t.Errorf("expected the deadline to cut retries short, got %d calls", n)
+	}
+}
+
+func TestReplicate_RecordsReplicatedBytes(t *testing.T) {
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{
+		"peer1": &fakePeer{},
+		"peer2": &fakePeer{},
+		"peer3": &fakePeer{},
+	}, time.Second)
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.ReplicateBytes)
+
+	value := make([]byte, 1000)
+	if _, _, err := coord.Replicate(context.Background(), "key1", value, "", 1, 0, hlc.HLC{}, 4); err != nil {
+		t.Fatalf("replicate failed: %v", err)
+	}
+
+	// three copies of the value plus a little request overhead each
+	after, _ := reader.GetCounterValue(testMetrics.ReplicateBytes)
+	if sent := after - before; sent < 3*1000 || sent > 3*1100 {
+		t.Errorf("expected about 3000 replicated bytes, got %v", sent)
+	}
+
+	coord.updateWriteAmplification()
+	if amp, _ := reader.GetGaugeValue(testMetrics.WriteAmplification); amp < 3 || amp > 3.3 {
+		t.Errorf("expected write amplification of about 3, got %v", amp)
+	}
+}
+
+// countingPeer records the most rpcs it saw in flight across all peers
+// sharing the same counters
+type countingPeer struct {
+	*fakePeer
+	inFlight    *atomic.Int32
+	maxInFlight *atomic.Int32
+}
+
+func (c *countingPeer) enter() {
+	n := c.inFlight.Add(1)
+	for {
+		seen := c.maxInFlight.Load()
+		if n <= seen || c.maxInFlight.CompareAndSwap(seen, n) {
+			return
+		}
+	}
+}
+
+func (c *countingPeer) Replicate(ctx context.Context, req *proto.ReplicateRequest, opts ...grpc.CallOption) (*proto.ReplicateResponse, error) {
+	c.enter()
+	defer c.inFlight.Add(-1)
+	return c.fakePeer.Replicate(ctx, req, opts...)
+}
+
+func (c *countingPeer) GetLocal(ctx context.Context, req *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	c.enter()
+	defer c.inFlight.Add(-1)
+	return c.fakePeer.GetLocal(ctx, req, opts...)
+}
+
+func TestCoordinator_MaxFanOutBoundsInFlightRPCs(t *testing.T) {
+	const numPeers, limit = 10, 3
+
+	var inFlight, maxInFlight atomic.Int32
+	peers := make(map[string]proto.ACPServiceClient)
+	fakes := make([]*fakePeer, 0, numPeers)
+	for i := range numPeers {
+		f := &fakePeer{delay: 20 * time.Millisecond, value: []byte("v")}
+		fakes = append(fakes, f)
+		peers[fmt.Sprintf("peer%d", i)] = &countingPeer{fakePeer: f, inFlight: &inFlight, maxInFlight: &maxInFlight}
+	}
+
+	coord := newTestCoordinator(peers, 5*time.Second)
+	coord.SetMaxFanOut(limit)
+
+	acks, _, err := coord.Replicate(context.Background(), "key1", []byte("v"), "", 1, 1, hlc.HLC{Physical: 1}, numPeers+1)
+	if err != nil {
+		t.Fatalf("replicate failed: %v", err)
+	}
+	if acks != numPeers+1 {
+		t.Errorf("expected %d acks, got %d", numPeers+1, acks)
+	}
+	if got := maxInFlight.Load(); got > limit {
+		t.Errorf("replicate had %d rpcs in flight, limit is %d", got, limit)
+	}
+
+	maxInFlight.Store(0)
+	values, err := coord.QueryReplicas(context.Background(), "key1", numPeers+1)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(values) != numPeers {
+		t.Errorf("expected %d values, got %d", numPeers, len(values))
+	}
+	if got := maxInFlight.Load(); got > limit {
+		t.Errorf("read had %d rpcs in flight, limit is %d", got, limit)
+	}
+
+	for i, f := range fakes {
+		if f.calls.Load() != 2 {
+			t.Errorf("peer%d got %d calls, want 2", i, f.calls.Load())
+		}
+	}
+}
+
+// replicaServer records the keys replicated to it
+type replicaServer struct {
+	proto.UnimplementedACPServiceServer
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *replicaServer) Replicate(ctx context.Context, req *proto.ReplicateRequest) (*proto.ReplicateResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, req.Key)
+	return &proto.ReplicateResponse{Success: true}, nil
+}
+
+func (r *replicaServer) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.keys)
+}
+
+func TestReconcilePeers_BootstrapsNewPeer(t *testing.T) {
+	servers := map[string]*replicaServer{"old:8080": {}, "new:8080": {}}
+	listeners := make(map[string]*bufconn.Listener)
+	for addr, srv := range servers {
+		lis := bufconn.Listen(1 << 20)
+		grpcServer := grpc.NewServer()
+		proto.RegisterACPServiceServer(grpcServer, srv)
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		listeners[addr] = lis
+	}
+
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient("passthrough:///"+addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listeners[addr].DialContext(ctx)
+			}))
+	}
+	defer coord.Close()
+
+	// old was part of the cluster when this node started
+	coord.markKnown("old:8080")
+	coord.SetBootstrapSource(func() []*proto.ReplicateRequest {
+		return []*proto.ReplicateRequest{{Key: "key1"}, {Key: "key2"}}
+	})
+
+	coord.UpdatePeers([]string{"old:8080", "new:8080"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(servers["new:8080"].received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := servers["new:8080"].received(); !slices.Equal(got, []string{"key1", "key2"}) {
+		t.Fatalf("expected new peer to be bootstrapped with key1 and key2, got %v", got)
+	}
+
+	// a peer that leaves and comes back is not new
+	coord.UpdatePeers([]string{"old:8080"})
+	coord.UpdatePeers([]string{"old:8080", "new:8080"})
+	time.Sleep(100 * time.Millisecond)
+
+	if got := servers["old:8080"].received(); len(got) != 0 {
+		t.Errorf("expected known peer not to be bootstrapped, got %v", got)
+	}
+	if got := servers["new:8080"].received(); len(got) != 2 {
+		t.Errorf("expected a returning peer not to be bootstrapped again, got %v", got)
+	}
+}
+
+// keyPartitioner assigns owners per key
+type keyPartitioner map[string][]string
+
+func (p keyPartitioner) Owners(key string) []string { return p[key] }
+
+func TestApplyDiscovered_SeedsKnownAndBootstrapsOwnedKeys(t *testing.T) {
+	servers := map[string]*replicaServer{"old:8080": {}, "new:8080": {}}
+	listeners := make(map[string]*bufconn.Listener)
+	for addr, srv := range servers {
+		lis := bufconn.Listen(1 << 20)
+		grpcServer := grpc.NewServer()
+		proto.RegisterACPServiceServer(grpcServer, srv)
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		listeners[addr] = lis
+	}
+
+	// empty PEERS, membership comes from discovery only
+	coord := newTestCoordinator(map[string]proto.ACPServiceClient{}, time.Second)
+	coord.known = make(map[string]bool)
+	coord.dial = func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient("passthrough:///"+addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listeners[addr].DialContext(ctx)
+			}))
+	}
+	defer coord.Close()
+
+	coord.SetPartitioner(keyPartitioner{
+		"key1": {"self:8080", "new:8080"},
+		"key2": {"self:8080", "old:8080"},
+	}, "self:8080")
+	coord.SetBootstrapSource(func() []*proto.ReplicateRequest {
+		return []*proto.ReplicateRequest{{Key: "key1"}, {Key: "key2"}}
+	})
+
+	// the first result is the cluster this node restarted into
+	coord.applyDiscovered([]string{"self:8080", "old:8080"})
+	coord.applyDiscovered([]string{"self:8080", "old:8080", "new:8080"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(servers["new:8080"].received()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := servers["new:8080"].received(); !slices.Equal(got, []string{"key1"}) {
+		t.Errorf("expected new peer to receive only the key it owns, got %v", got)
+	}
+	if got := servers["old:8080"].received(); len(got) != 0 {
+		t.Errorf("expected a peer from the first discovery not to be bootstrapped, got %v", got)
+	}
+}
@@ -0,0 +1,35 @@
+package replication
+
+import (
+	"errors"
+	"fmt"
+)
+
+// returned when a write or read needs more than self but no peers are connected
+var ErrNoPeers = errors.New("no peers available")
+
+// returned when a read is pinned to an address that is not a connected peer
+var ErrUnknownPeer = errors.New("not a connected peer")
+
+// returned when fewer than W replicas acknowledged a write
+type ErrInsufficientAcks struct {
+	Got  int
+	Need int
+}
+
+func (e *ErrInsufficientAcks) Error() string {
+	return fmt.Sprintf("insufficient acknowledgements: got %d, need %d", e.Got, e.Need)
+}
+
+// returned when fewer than R replicas answered a read
+type ErrInsufficientReplicas struct {
+	Got  int
+	Need int
+
+	// values the replicas that did answer returned
+	Partial []ReplicaValue
+}
+
+func (e *ErrInsufficientReplicas) Error() string {
+	return fmt.Sprintf("insufficient responses: got %d, need %d", e.Got, e.Need)
+}
@@ -0,0 +1,86 @@
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// quarantine tracks peers whose timestamps keep being rejected for clock
+// drift. a peer with threshold rejections inside window is quarantined: its
+// replication acks no longer count toward W and its read responses are
+// ignored. the first timestamp from it that is accepted again re-admits it
+type Quarantine struct {
+	threshold int
+	window    time.Duration
+	logger    *zap.Logger
+	metrics   *metrics.Metrics
+
+	mu          sync.Mutex
+	rejections  map[string][]time.Time // node id -> recent drift rejections
+	quarantined map[string]bool
+}
+
+func NewQuarantine(threshold int, window time.Duration, logger *zap.Logger, m *metrics.Metrics) *Quarantine {
+	return &Quarantine{
+		threshold:   max(threshold, 1),
+		window:      window,
+		logger:      logger,
+		metrics:     m,
+		rejections:  make(map[string][]time.Time),
+		quarantined: make(map[string]bool),
+	}
+}
+
+// recordrejection notes a timestamp from nodeID rejected for excessive drift
+func (q *Quarantine) RecordRejection(nodeID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	recent := q.rejections[nodeID][:0]
+	for _, t := range q.rejections[nodeID] {
+		if now.Sub(t) < q.window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	q.rejections[nodeID] = recent
+
+	if len(recent) < q.threshold || q.quarantined[nodeID] {
+		return
+	}
+
+	q.quarantined[nodeID] = true
+	q.metrics.PeersQuarantined.Set(float64(len(q.quarantined)))
+	q.logger.Error("QUARANTINING peer - repeated clock drift rejections, excluded from quorums until its clock recovers",
+		zap.String("peer", nodeID),
+		zap.Int("rejections", len(recent)),
+		zap.Duration("window", q.window))
+}
+
+// recordaccepted notes a timestamp from nodeID within the drift limit,
+// re-admitting the peer if it was quarantined
+func (q *Quarantine) RecordAccepted(nodeID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.quarantined[nodeID] {
+		return
+	}
+
+	delete(q.quarantined, nodeID)
+	delete(q.rejections, nodeID)
+	q.metrics.PeersQuarantined.Set(float64(len(q.quarantined)))
+	q.logger.Warn("peer re-admitted from quarantine - clock drift back within limit",
+		zap.String("peer", nodeID))
+}
+
+// isquarantined reports whether nodeID is currently excluded from quorums
+func (q *Quarantine) IsQuarantined(nodeID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.quarantined[nodeID]
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/replication"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
+)
+
+// signature of Coordinator.Replicate
+type replicateFunc func(ctx context.Context, key string, value []byte, contentType string, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int) (int, []replication.ReplicateResult, error)
+
+// writecoalescer merges puts to the same key that arrive within a window
+// into a single replication. every put is still applied locally with its own
+// hlc; when the window closes only the newest value is replicated, and all
+// the merged puts are answered with that write's outcome
+type writeCoalescer struct {
+	window    time.Duration
+	replicate replicateFunc
+	requiredW func() int
+	metrics   *metrics.Metrics
+
+	mu      sync.Mutex
+	pending map[string]*coalescedWrite // key -> open window
+}
+
+// puts to one key merged within a window
+type coalescedWrite struct {
+	value   storage.VersionedValue // newest write, replicated when the window closes
+	prev    storage.VersionedValue // value before the first write, for rollback
+	hadPrev bool
+
+	// set before done is closed
+	done chan struct{}
+	acks int
+	err  error
+}
+
+func newWriteCoalescer(window time.Duration, replicate replicateFunc, requiredW func() int, m *metrics.Metrics) *writeCoalescer {
+	return &writeCoalescer{
+		window:    window,
+		replicate: replicate,
+		requiredW: requiredW,
+		metrics:   m,
+		pending:   make(map[string]*coalescedWrite),
+	}
+}
+
+// add merges a locally applied write into the key's open window, opening
+// one if needed. wait on done for the replication outcome
+func (c *writeCoalescer) add(key string, vv, prev storage.VersionedValue, hadPrev bool) *coalescedWrite {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.pending[key]
+	if !ok {
+		w = &coalescedWrite{value: vv, prev: prev, hadPrev: hadPrev, done: make(chan struct{})}
+		c.pending[key] = w
+		time.AfterFunc(c.window, func() { c.flush(key, w) })
+		return w
+	}
+
+	// keep per-key hlc order, a write that lost the local race stays lost
+	c.metrics.WritesCoalesced.Inc()
+	if vv.HLC.Compare(w.value.HLC) > 0 {
+		w.value = vv
+	}
+	return w
+}
+
+// close the window and replicate its newest value once
+func (c *writeCoalescer) flush(key string, w *coalescedWrite) {
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+
+	// no put can join after the delete, w.value is final. the merged puts
+	// may have different deadlines, so none of them bounds the replication
+	w.acks, _, w.err = c.replicate(context.Background(), key, w.value.Value, w.value.ContentType, w.value.Version, w.value.Timestamp, w.value.HLC, c.requiredW())
+	close(w.done)
+}
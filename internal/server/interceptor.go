@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"path"
+	"runtime/debug"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// generic per-rpc metrics, recorded for every method on top of the
+// domain-specific counters in the handlers. the method label is the bare
+// rpc name, e.g. "Put" for /acp.ACPService/Put
+
+// unarymetricsinterceptor counts and times every unary rpc
+func UnaryMetricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeRPC(m, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// streammetricsinterceptor counts and times every streaming rpc, measured
+// over the lifetime of the stream
+func StreamMetricsInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeRPC(m, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeRPC(m *metrics.Metrics, fullMethod string, start time.Time, err error) {
+	method := path.Base(fullMethod)
+	m.GRPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.ObserveSampled(m.GRPCLatency.WithLabelValues(method), time.Since(start).Seconds())
+}
+
+// unaryrecoveryinterceptor turns a panic in a unary handler into an
+// Internal error for that call instead of crashing the node
+func UnaryRecoveryInterceptor(logger *zap.Logger, m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverRPC(logger, m, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// streamrecoveryinterceptor is UnaryRecoveryInterceptor for streaming rpcs
+func StreamRecoveryInterceptor(logger *zap.Logger, m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverRPC(logger, m, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recoverRPC(logger *zap.Logger, m *metrics.Metrics, fullMethod string, r any) error {
+	method := path.Base(fullMethod)
+	m.PanicsRecovered.WithLabelValues(method).Inc()
+	logger.Error("recovered panic in rpc handler",
+		zap.String("method", method),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()))
+	return status.Errorf(codes.Internal, "internal error in %s", method)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/partition"
+	"go.uber.org/zap"
+)
+
+// keys migrated between checkpoint writes and progress logs
+const migrationCheckpointEvery = 100
+
+// migrateplacement moves this node's keys from the from placement to the to
+// placement, e.g. from full replication (partition.AllNodes) to sharding:
+// each key is copied to every new owner, then dropped here if self is not a
+// new owner. new owners that were also old owners may have missed the latest
+// write (only W replicas are guaranteed it), so they are read first and only
+// sent the key if they lack this version. a key is only dropped once every
+// new owner holds it; a failed copy stops the run with an error. every old
+// owner pushes its own copy, so a key may be sent more than once, which is
+// harmless since replicas keep only newer writes.
+//
+// keys are visited in sorted order, at most keysPerSec per second (0 is
+// unlimited). with a checkpoint file the last migrated key is recorded there
+// and a later run resumes after it
+func (s *Server) MigratePlacement(ctx context.Context, from, to partition.Partitioner, self string, keysPerSec int, checkpoint string) error {
+	after, err := readCheckpoint(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to read migration checkpoint: %w", err)
+	}
+
+	var throttle <-chan time.Time
+	if keysPerSec > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(keysPerSec))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	keys := s.store.Keys(after, 0)
+	s.logger.Info("placement migration starting",
+		zap.Int("keys", len(keys)),
+		zap.String("resume_after", after))
+
+	last := after
+	for i, key := range keys {
+		if throttle != nil {
+			select {
+			case <-throttle:
+			case <-ctx.Done():
+				return errors.Join(ctx.Err(), writeCheckpoint(checkpoint, last))
+			}
+		}
+
+		if err := s.migrateKey(ctx, key, from.Owners(key), to.Owners(key), self); err != nil {
+			return errors.Join(fmt.Errorf("failed to migrate key %q: %w", key, err), writeCheckpoint(checkpoint, last))
+		}
+		last = key
+
+		done := i + 1
+		s.metrics.MigrationProgress.Set(float64(done) / float64(len(keys)))
+		if done%migrationCheckpointEvery == 0 {
+			if err := writeCheckpoint(checkpoint, last); err != nil {
+				return fmt.Errorf("failed to write migration checkpoint: %w", err)
+			}
+			s.logger.Info("placement migration progress",
+				zap.Int("migrated", done),
+				zap.Int("keys", len(keys)))
+		}
+	}
+
+	s.metrics.MigrationProgress.Set(1)
+	s.logger.Info("placement migration finished", zap.Int("keys", len(keys)))
+	return writeCheckpoint(checkpoint, last)
+}
+
+// copy key to its new owners and drop it here if self is no longer one
+func (s *Server) migrateKey(ctx context.Context, key string, oldOwners, newOwners []string, self string) error {
+	vv, ok := s.store.Peek(key)
+	if !ok {
+		return nil
+	}
+
+	req := bootstrapRequest(key, vv.Value, vv.NodeID, vv.HLC)
+	req.ContentType = vv.ContentType
+	for _, owner := range newOwners {
+		if owner == self {
+			continue
+		}
+		if slices.Contains(oldOwners, owner) {
+			// should already hold it, skip the copy if it does
+			resp, err := s.coordinator.GetLocalFrom(ctx, owner, key)
+			if err != nil {
+				return err
+			}
+			if resp.Found && !hlc.FromProto(resp.Hlc).HappensBefore(vv.HLC) {
+				s.metrics.MigrationKeys.WithLabelValues("verified").Inc()
+				continue
+			}
+		}
+		if err := s.coordinator.ReplicateTo(ctx, owner, req); err != nil {
+			return err
+		}
+		s.metrics.MigrationKeys.WithLabelValues("copied").Inc()
+	}
+
+	if slices.Contains(newOwners, self) {
+		s.metrics.MigrationKeys.WithLabelValues("kept").Inc()
+		return nil
+	}
+	// a newer write landed meanwhile, which only owners receive, so keep it
+	if s.store.RestoreIfCurrent(key, vv.HLC, vv, false) {
+		s.metrics.MigrationKeys.WithLabelValues("dropped").Inc()
+	}
+	return nil
+}
+
+// the last migrated key, empty without a checkpoint file
+func readCheckpoint(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+func writeCheckpoint(path, key string) error {
+	if path == "" || key == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(key), 0o644)
+}
@@ -2,10 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rachitkumar205/acp-kv/api/proto"
 	"github.com/rachitkumar205/acp-kv/internal/adaptive"
+	"github.com/rachitkumar205/acp-kv/internal/audit"
+	"github.com/rachitkumar205/acp-kv/internal/config"
+	"github.com/rachitkumar205/acp-kv/internal/health"
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
 	"github.com/rachitkumar205/acp-kv/internal/metrics"
 	"github.com/rachitkumar205/acp-kv/internal/reconcile"
@@ -13,6 +21,8 @@ import (
 	"github.com/rachitkumar205/acp-kv/internal/staleness"
 	"github.com/rachitkumar205/acp-kv/internal/storage"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -26,8 +36,84 @@ type Server struct {
 	hlcClock          *hlc.Clock            // hybrid logical clock
 	stalenessDetector *staleness.Detector   // staleness enforcement
 	reconciler        *reconcile.Engine     // reconciliation engine (optional)
+	writeLog          *reconcile.RecentWriteLog // recent writes, kept even without a reconciler
+	rollbackOnFailure bool                  // undo the local write when a put misses quorum
+
+	// R counts only replicas that returned a value, not every responding node
+	requireValueReplicas bool
+
+	// quorum reads where more than this fraction of replicas disagree with the
+	// winner are counted, and flagged on the response if annotate is set
+	divergenceThreshold float64
+	annotateDivergence  bool
+
+	// re-check quorum read results against the local store (canary)
+	verifyReads bool
+
+	// observers reject client writes and serve reads from the local store
+	observer bool
+
+	// leave replicas that flagged their value stale out of quorum winner
+	// selection, so a fresh older value beats a stale newer one
+	excludeStaleReplicas bool
+
+	// answer a quorum read with the local value when every replica queried
+	// holds the same version, skipping the merge
+	agreementFastPath bool
+
+	// serve every quorum read that misses R degraded instead of failing it
+	degradedReads bool
+
+	// notified of every write applied to the local store
+	writeHook WriteHook
+
+	// accept Flush, test environments only
+	flushEnabled bool
+
+	// accept ListKeys, which sorts the whole key set per page
+	listKeysEnabled bool
+
+	// honour GetRequest.IgnoreStaleness
+	stalenessBypass bool
+
+	// honour GetRequest.FromPeer
+	pinnedReads bool
+
+	// health checks check drift without advancing hlcClock
+	readOnlyHealthClock bool
+
+	// owners per key with sharding, 0 replicates every key to every node
+	shardReplicas int
+
+	// merges rapid puts to the same key into one replication (optional)
+	coalescer *writeCoalescer
+
+	// rejects puts while ccs is critically low (optional)
+	writeSuspender *adaptive.WriteSuspender
+
+	// client reads and writes are turned away until the startup barrier
+	// lifts, see SetStartupBarrier
+	holdingClients  atomic.Bool
+	barrierMinPeers int
+	barrierTimeout  time.Duration
+
+	// peers quarantined after repeated drift rejections (optional)
+	quarantine *replication.Quarantine
+
+	// health probe updated alongside the coordinator by UpdatePeers (optional)
+	probe *health.Probe
+
+	// records values discarded by the read merge's node id tiebreak (optional)
+	conflictAudit *audit.ConflictLog
+
+	// rate limiting for clock drift warning logs
+	driftWarnMu   sync.Mutex
+	lastDriftWarn map[string]time.Time
 }
 
+// minimum time between drift warning logs for the same peer
+const driftWarnLogInterval = 10 * time.Second
+
 func NewServer(
 	nodeID string,
 	store *storage.Store,
@@ -39,6 +125,13 @@ func NewServer(
 	stalenessDetector *staleness.Detector,
 	reconciler *reconcile.Engine,
 ) *Server {
+	// share the engine's log so its compaction setting applies
+	writeLog := reconcile.NewRecentWriteLog(reconcile.DefaultLogSize, reconcile.DefaultLogAge)
+	if reconciler != nil {
+		writeLog = reconciler.WriteLog()
+	}
+	writeLog.SetMetrics(metrics)
+
 	return &Server{
 		nodeID:            nodeID,
 		store:             store,
@@ -49,36 +142,452 @@ func NewServer(
 		hlcClock:          hlcClock,
 		stalenessDetector: stalenessDetector,
 		reconciler:        reconciler,
+		writeLog:          writeLog,
+		writeHook:         NopWriteHook{},
+		lastDriftWarn:     make(map[string]time.Time),
+	}
+}
+
+// enabledriftwarnings reports peers whose clocks run ahead by more than threshold
+func (s *Server) EnableDriftWarnings(threshold time.Duration) {
+	s.hlcClock.SetDriftWarning(threshold, s.warnClockDrift)
+}
+
+// count every drift warning, but log at most once per interval per peer
+func (s *Server) warnClockDrift(remote hlc.HLC, drift time.Duration) {
+	s.metrics.ClockDriftWarnings.WithLabelValues(remote.NodeID).Inc()
+
+	s.driftWarnMu.Lock()
+	last := s.lastDriftWarn[remote.NodeID]
+	shouldLog := time.Since(last) >= driftWarnLogInterval
+	if shouldLog {
+		s.lastDriftWarn[remote.NodeID] = time.Now()
+	}
+	s.driftWarnMu.Unlock()
+
+	if shouldLog {
+		s.logger.Warn("peer clock drift above warning threshold",
+			zap.String("peer", remote.NodeID),
+			zap.Duration("drift", drift))
+	}
+}
+
+// enablebackwardjumpwarnings reports local wall clock steps back by more
+// than threshold
+func (s *Server) EnableBackwardJumpWarnings(threshold time.Duration) {
+	s.hlcClock.SetBackwardJumpWarning(threshold, s.warnBackwardJump)
+}
+
+// setshardreplicas tells the server quorums are formed among replicas owners
+// per key, so runtime peer changes are validated against it and leave the
+// quorum's n alone
+func (s *Server) SetShardReplicas(replicas int) {
+	s.shardReplicas = replicas
+}
+
+// sethealthcheckclockreadonly makes health checks measure peer drift without
+// advancing the local clock, so only replication and reads move it forward
+func (s *Server) SetHealthCheckClockReadOnly(enabled bool) {
+	s.readOnlyHealthClock = enabled
+}
+
+func (s *Server) warnBackwardJump(jump, lead time.Duration) {
+	s.metrics.ClockBackwardJumps.Inc()
+	s.logger.Warn("local clock moved backwards, hlc now runs ahead of wall clock",
+		zap.Duration("jump", jump),
+		zap.Duration("hlc_lead", lead))
+}
+
+// setrollbackonfailure makes a put that misses W acks undo its local write
+// (and reconcile log entry) so the reported failure matches local state
+// peers that already acked keep the value; it can still return via reconciliation
+func (s *Server) SetRollbackOnFailure(enabled bool) {
+	s.rollbackOnFailure = enabled
+}
+
+// setrequirevaluereplicas changes what counts toward R on reads. by default
+// every responding node counts, including peers that do not have the key.
+// when enabled only nodes that returned a value count, so a key missing on
+// too many replicas fails the read instead of reading as not found
+func (s *Server) SetRequireValueReplicas(enabled bool) {
+	s.requireValueReplicas = enabled
+}
+
+// setreaddivergence configures split-brain monitoring on quorum reads. a read
+// is divergent when more than threshold of the collected replica values have a
+// different hlc than the returned one. a threshold of 0 or less disables it
+func (s *Server) SetReadDivergence(threshold float64, annotate bool) {
+	s.divergenceThreshold = threshold
+	s.annotateDivergence = annotate
+}
+
+// setreadverification enables a correctness canary: after each quorum read
+// the local value for the key is checked in the background, and a local value
+// newer than the one returned is logged and counted as an anomaly
+func (s *Server) SetReadVerification(enabled bool) {
+	s.verifyReads = enabled
+}
+
+// setexcludestalereplicas skips replicas that reported IsStale when picking
+// the quorum read winner. this trades recency for freshness: a read may
+// return an older value that its replica still considers within bounds
+// instead of failing the strict staleness check on a newer one. if every
+// replica is stale the newest value is checked as before
+func (s *Server) SetExcludeStaleReplicas(exclude bool) {
+	s.excludeStaleReplicas = exclude
+}
+
+// setagreementfastpath answers a quorum read straight from the local value
+// when a full read quorum of replicas holds exactly the local version,
+// skipping winner selection, conflict audit, divergence checks and read
+// verification. reads where any replica disagrees are merged as before
+func (s *Server) SetAgreementFastPath(enabled bool) {
+	s.agreementFastPath = enabled
+}
+
+// setdegradedreads answers a quorum read that reached fewer than R replicas
+// with the best value among those that answered, flagged Degraded, rather
+// than an error. clients can also ask for this per read with AllowDegraded
+func (s *Server) SetDegradedReads(enabled bool) {
+	s.degradedReads = enabled
+}
+
+// setobserver runs this node as an observer. it only receives replicated
+// writes and answers reads locally, it never takes part in a quorum
+func (s *Server) SetObserver(observer bool) {
+	s.observer = observer
+}
+
+// setwritehook registers a hook called after each local write, nil restores
+// the no-op hook. set before serving
+func (s *Server) SetWriteHook(hook WriteHook) {
+	if hook == nil {
+		hook = NopWriteHook{}
+	}
+	s.writeHook = hook
+}
+
+// setflushenabled allows the Flush rpc to wipe the node. never enable in
+// production
+func (s *Server) SetFlushEnabled(enabled bool) {
+	s.flushEnabled = enabled
+}
+
+// setlistkeysenabled allows the ListKeys admin rpc. off by default since each
+// page sorts every key on the node
+func (s *Server) SetListKeysEnabled(enabled bool) {
+	s.listKeysEnabled = enabled
+}
+
+// setpinnedreads lets gets set FromPeer to return one replica's local value,
+// for debugging divergent nodes
+func (s *Server) SetPinnedReads(enabled bool) {
+	s.pinnedReads = enabled
+}
+
+// setstalenessbypass lets gets set IgnoreStaleness to read values of any
+// age. off by default so clients can't quietly opt out of the bound
+func (s *Server) SetStalenessBypass(enabled bool) {
+	s.stalenessBypass = enabled
+}
+
+// setreconciler attaches a reconciliation engine after construction. the
+// engine takes over the server's write log, so writes recorded while
+// reconciliation was off are still available to it. set before Start
+func (s *Server) SetReconciler(engine *reconcile.Engine) {
+	if engine != nil {
+		engine.SetWriteLog(s.writeLog)
+	}
+	s.reconciler = engine
+}
+
+// enablepeerbootstrap pushes this node's data to peers discovered after it
+// started, so a node added by a scale-up doesn't serve incomplete data until
+// anti-entropy catches up. by default the recent write log is pushed; with
+// fullExport every key in the store is. pushes are marked bulk so they don't
+// churn the newcomer's own write log
+func (s *Server) EnablePeerBootstrap(fullExport bool) {
+	if fullExport {
+		s.coordinator.SetBootstrapSource(s.exportWrites)
+		return
+	}
+	s.coordinator.SetBootstrapSource(s.logWrites)
+}
+
+// the write log as replicate requests, elided values read back from the store
+func (s *Server) logWrites() []*proto.ReplicateRequest {
+	writes := s.writeLog.GetAll()
+	reqs := make([]*proto.ReplicateRequest, 0, len(writes))
+	for _, w := range writes {
+		stored, _ := s.store.Peek(w.Key)
+		value, ok := w.ValueFrom(stored)
+		if !ok {
+			continue
+		}
+		req := bootstrapRequest(w.Key, value, w.NodeID, w.HLC)
+		req.ContentType = w.ContentType
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// every stored key as replicate requests
+func (s *Server) exportWrites() []*proto.ReplicateRequest {
+	keys := s.store.Keys("", 0)
+	reqs := make([]*proto.ReplicateRequest, 0, len(keys))
+	for _, key := range keys {
+		if vv, ok := s.store.Peek(key); ok {
+			req := bootstrapRequest(key, vv.Value, vv.NodeID, vv.HLC)
+			req.ContentType = vv.ContentType
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+func bootstrapRequest(key string, value []byte, nodeID string, timestamp hlc.HLC) *proto.ReplicateRequest {
+	return &proto.ReplicateRequest{
+		Key:          key,
+		Value:        value,
+		Version:      timestamp.Physical,
+		Timestamp:    timestamp.Physical,
+		SourceNodeId: nodeID,
+		Hlc:          timestamp.ToProto(),
+		Bulk:         true,
+	}
+}
+
+// setwriteloglimits bounds the write log's memory, see
+// RecentWriteLog.SetMemoryLimits
+func (s *Server) SetWriteLogLimits(maxBytes int64, maxValueBytes int) {
+	s.writeLog.SetMemoryLimits(maxBytes, maxValueBytes)
+}
+
+// setasyncwritelog records writes into the log from a background goroutine
+// with a buffer of this many writes until ctx is done, see
+// RecentWriteLog.EnableAsync
+func (s *Server) SetAsyncWriteLog(ctx context.Context, buffer int) {
+	s.writeLog.EnableAsync(ctx, buffer)
+}
+
+// recentwrites returns the non-expired entries of the write log
+func (s *Server) RecentWrites() []reconcile.WriteEntry {
+	return s.writeLog.GetAll()
+}
+
+// setwritecoalescing merges puts to the same key that arrive within window
+// into one replication of the newest value, acked to every merged put. each
+// put still writes locally first. trades up to window of latency for fewer
+// replication rpcs on hot keys; 0 disables. bulk puts are never coalesced
+func (s *Server) SetWriteCoalescing(window time.Duration) {
+	if window <= 0 {
+		s.coalescer = nil
+		return
+	}
+	s.coalescer = newWriteCoalescer(window, s.coordinator.Replicate, func() int {
+		return s.quorumProvider.GetW()
+	}, s.metrics)
+}
+
+// setwritesuspender makes puts fail with Unavailable while ws reports the
+// node degraded. gets are unaffected
+func (s *Server) SetWriteSuspender(ws *adaptive.WriteSuspender) {
+	s.writeSuspender = ws
+}
+
+// how often the startup barrier checks peer connectivity
+const startupBarrierPoll = 100 * time.Millisecond
+
+// setstartupbarrier turns client reads and writes away with Unavailable
+// until StartStartupBarrier sees minPeers connected peers or timeout passes,
+// so a cold-starting node doesn't take writes it can't replicate. peer rpcs
+// are served throughout. call before serving
+func (s *Server) SetStartupBarrier(minPeers int, timeout time.Duration) {
+	s.barrierMinPeers = minPeers
+	s.barrierTimeout = timeout
+	s.holdingClients.Store(true)
+}
+
+// startstartupbarrier waits for the barrier set by SetStartupBarrier, logging
+// progress, then starts serving clients
+func (s *Server) StartStartupBarrier(ctx context.Context) {
+	defer s.holdingClients.Store(false)
+
+	ticker := time.NewTicker(startupBarrierPoll)
+	defer ticker.Stop()
+	deadline := time.After(s.barrierTimeout)
+	lastLog := time.Now()
+
+	for {
+		connected := len(s.coordinator.GetConnectedPeerAddresses())
+		if connected >= s.barrierMinPeers {
+			s.logger.Info("startup barrier met, serving clients",
+				zap.Int("connected_peers", connected))
+			return
+		}
+		if time.Since(lastLog) >= time.Second {
+			s.logger.Info("startup barrier waiting for peers",
+				zap.Int("connected_peers", connected),
+				zap.Int("min_peers", s.barrierMinPeers))
+			lastLog = time.Now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			s.logger.Warn("startup barrier timed out, serving clients with too few peers",
+				zap.Int("connected_peers", len(s.coordinator.GetConnectedPeerAddresses())),
+				zap.Int("min_peers", s.barrierMinPeers),
+				zap.Duration("timeout", s.barrierTimeout))
+			return
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
+// checkserving returns Unavailable while the startup barrier holds clients
+func (s *Server) checkServing() error {
+	if s.holdingClients.Load() {
+		return status.Error(codes.Unavailable, "node starting up, waiting for peers to connect")
+	}
+	return nil
+}
+
+// setquarantine feeds drift rejections seen on incoming timestamps into q.
+// pass the same quarantine to the coordinator so it takes effect on quorums
+func (s *Server) SetQuarantine(q *replication.Quarantine) {
+	s.quarantine = q
+}
+
+// record whether a peer's timestamp was accepted by the clock
+func (s *Server) observeClockDrift(nodeID string, updateErr error) {
+	if s.quarantine == nil || nodeID == "" {
+		return
+	}
+	if updateErr != nil {
+		s.quarantine.RecordRejection(nodeID)
+	} else {
+		s.quarantine.RecordAccepted(nodeID)
+	}
+}
+
+// setconflictaudit records the losing side of every concurrent write
+// resolved by a quorum read
+func (s *Server) SetConflictAudit(log *audit.ConflictLog) {
+	s.conflictAudit = log
+}
+
+// sethealthprobe lets UpdatePeers apply peer list changes to the health probe
+func (s *Server) SetHealthProbe(probe *health.Probe) {
+	s.probe = probe
+}
+
 // handle client write requests with quorum replication
 func (s *Server) Put(ctx context.Context, req *proto.PutRequest) (*proto.PutResponse, error) {
 	start := time.Now()
 	defer func() {
-		s.metrics.PutLatency.Observe(time.Since(start).Seconds())
+		s.metrics.ObserveSampled(s.metrics.PutLatency, time.Since(start).Seconds())
 	}()
 
 	s.logger.Info("PUT request received",
 		zap.String("key", req.Key),
 		zap.Int("value_size", len(req.Value)))
 
+	if err := s.checkAbandoned(ctx, "put"); err != nil {
+		return nil, err
+	}
+	if err := s.checkServing(); err != nil {
+		return nil, err
+	}
+
+	if s.observer {
+		s.metrics.RecordWriteFailure()
+		return &proto.PutResponse{
+			Success: false,
+			Error:   "observer nodes do not accept writes",
+		}, nil
+	}
+
+	// fail fast instead of attempting a write that can't reach W
+	if s.writeSuspender != nil && s.writeSuspender.Suspended() {
+		s.metrics.RecordWriteFailure()
+		return nil, status.Error(codes.Unavailable, "node degraded (ccs below critical threshold), writes suspended")
+	}
+
 	// generate hlc timestamp for this write
 	timestamp := s.hlcClock.Now()
 
-	// write to local store with hlc timestamp
-	vv := s.store.PutWithHLC(req.Key, req.Value, s.nodeID, timestamp)
+	// with sharding, a node that does not own the key only coordinates
+	// the write and keeps no copy
+	var (
+		prev    storage.VersionedValue
+		hadPrev bool
+		vv      = storage.VersionedValue{
+			Value:       req.Value,
+			ContentType: req.ContentType,
+			Version:     timestamp.Physical,
+			Timestamp:   timestamp.Physical,
+			NodeID:      s.nodeID,
+			HLC:         timestamp,
+		}
+	)
+	if s.coordinator.IsOwner(req.Key) {
+		// remember the previous value in case the write has to be rolled back
+		prev, hadPrev = s.store.Get(req.Key)
+
+		// write to local store with hlc timestamp
+		localStart := time.Now()
+		vv = s.store.PutWithContentType(req.Key, req.Value, req.ContentType, s.nodeID, timestamp)
+		s.writeHook.OnCommit(req.Key, req.Value, timestamp, s.nodeID)
 
-	// record write in reconciliation log
-	if s.reconciler != nil {
-		s.reconciler.RecordWrite(req.Key, req.Value, s.nodeID, timestamp)
+		// record write in reconciliation log, bulk loads are authoritative
+		// and would only churn the log
+		if !req.Bulk {
+			s.writeLog.AddWithContentType(req.Key, req.Value, req.ContentType, s.nodeID, timestamp)
+		}
+		s.metrics.ObserveSampled(s.metrics.PutLocalLatency, time.Since(localStart).Seconds())
+	}
+
+	// don't start replication for a client that has already gone away
+	if err := s.checkAbandoned(ctx, "put"); err != nil {
+		if s.rollbackOnFailure {
+			s.rollbackWrite(req.Key, timestamp, prev, hadPrev)
+		}
+		return nil, err
 	}
 
 	// get current write quorum size
 	requiredW := s.quorumProvider.GetW()
+	if req.StrongConsistency {
+		requiredW = s.coordinator.ReplicaCount(req.Key, s.quorumProvider.GetN())
+	}
+	s.metrics.ObserveSampled(s.metrics.EffectiveW, float64(requiredW))
 
 	// replicate to peers and wait for W acks
-	acks, _, err := s.coordinator.Replicate(ctx, req.Key, req.Value, vv.Version, vv.Timestamp, timestamp, requiredW)
+	replicateStart := time.Now()
+	var (
+		acks int
+		err  error
+	)
+	if s.coalescer != nil && !req.Bulk && !req.StrongConsistency {
+		// the ack reflects the coalesced write, which may be newer than ours
+		w := s.coalescer.add(req.Key, vv, prev, hadPrev)
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return nil, s.checkAbandoned(ctx, "put")
+		}
+		acks, err = w.acks, w.err
+		vv, timestamp, prev, hadPrev = w.value, w.value.HLC, w.prev, w.hadPrev
+	} else {
+		replicate := s.coordinator.Replicate
+		if req.Bulk {
+			replicate = s.coordinator.ReplicateBulk
+		}
+		acks, _, err = replicate(ctx, req.Key, req.Value, req.ContentType, vv.Version, vv.Timestamp, timestamp, requiredW)
+	}
+	s.metrics.ObserveSampled(s.metrics.PutReplicateLatency, time.Since(replicateStart).Seconds())
 
 	if err != nil {
 		s.logger.Error("PUT failed - insufficient acks",
@@ -87,11 +596,13 @@ func (s *Server) Put(ctx context.Context, req *proto.PutRequest) (*proto.PutResp
 			zap.Int("required", requiredW),
 			zap.Error(err))
 		s.metrics.RecordWriteFailure()
-		s.metrics.Errors.WithLabelValues("timeout").Inc()
-		return &proto.PutResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		s.metrics.Errors.WithLabelValues(errorType(err)).Inc()
+
+		if s.rollbackOnFailure {
+			s.rollbackWrite(req.Key, timestamp, prev, hadPrev)
+		}
+
+		return nil, replicationStatus(err)
 	}
 
 	s.logger.Info("PUT succeeded",
@@ -110,23 +621,163 @@ func (s *Server) Put(ctx context.Context, req *proto.PutRequest) (*proto.PutResp
 	}, nil
 }
 
+// checkabandoned returns a DeadlineExceeded (or Canceled) status if the
+// caller's context has already ended, so no further work is done for it
+func (s *Server) checkAbandoned(ctx context.Context, operation string) error {
+	if err := ctx.Err(); err != nil {
+		s.metrics.RequestsAbandoned.WithLabelValues(operation).Inc()
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// undo a local write that failed to reach quorum
+func (s *Server) rollbackWrite(key string, timestamp hlc.HLC, prev storage.VersionedValue, hadPrev bool) {
+	if !s.store.RestoreIfCurrent(key, timestamp, prev, hadPrev) {
+		// a newer write to the key landed in the meantime, leave it alone
+		s.logger.Debug("rollback skipped - key overwritten since failed put",
+			zap.String("key", key))
+		return
+	}
+
+	s.writeLog.Remove(key, timestamp)
+
+	s.logger.Info("PUT rolled back after quorum failure",
+		zap.String("key", key),
+		zap.Bool("restored_previous", hadPrev))
+}
+
+// pick the quorum read result, see SetExcludeStaleReplicas
+func (s *Server) selectWinner(values []replication.ReplicaValue) (replication.ReplicaValue, bool) {
+	if s.excludeStaleReplicas {
+		values = replication.FreshOnly(values)
+	}
+	return replication.GetMostRecent(values)
+}
+
+// audit replica values that carried the winner's hlc from another node, i.e.
+// concurrent writes the merge discarded on the node id tiebreak. each losing
+// write is recorded once however many replicas returned it
+func (s *Server) auditReadConflicts(key string, values []replication.ReplicaValue, winner replication.ReplicaValue) {
+	if s.conflictAudit == nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if !v.Found || !v.HLC.Equal(winner.HLC) || v.HLC.NodeID == winner.HLC.NodeID || seen[v.HLC.NodeID] {
+			continue
+		}
+		seen[v.HLC.NodeID] = true
+		s.conflictAudit.Record(audit.Conflict{
+			Key:        key,
+			Source:     "read",
+			WinnerNode: winner.HLC.NodeID,
+			WinnerHLC:  winner.HLC,
+			LoserNode:  v.HLC.NodeID,
+			LoserHLC:   v.HLC,
+		})
+	}
+}
+
+// handle client requests for a read barrier, the newest hlc stored on this
+// node. a Get with min_hlc set to it only succeeds on nodes that have caught up
+func (s *Server) ReadBarrier(ctx context.Context, req *proto.ReadBarrierRequest) (*proto.ReadBarrierResponse, error) {
+	return &proto.ReadBarrierResponse{Hlc: s.store.MaxHLC().ToProto()}, nil
+}
+
+// handle client requests for a fresh hlc from this node's clock. ticks the
+// clock but has no other side effects
+func (s *Server) Time(ctx context.Context, req *proto.TimeRequest) (*proto.TimeResponse, error) {
+	return &proto.TimeResponse{
+		Hlc:      s.hlcClock.Now().ToProto(),
+		WallTime: time.Now().UnixNano(),
+	}, nil
+}
+
 // handle client read requests with quorum reads
 func (s *Server) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	resp, err := s.get(ctx, req)
+	if resp != nil && resp.Error == "" {
+		resp.State = proto.KeyState_KEY_STATE_ABSENT
+		if resp.Found {
+			resp.State = proto.KeyState_KEY_STATE_PRESENT
+		}
+	}
+	return resp, err
+}
+
+func (s *Server) get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
 	start := time.Now()
 	defer func() {
-		s.metrics.GetLatency.Observe(time.Since(start).Seconds())
+		s.metrics.ObserveSampled(s.metrics.GetLatency, time.Since(start).Seconds())
 	}()
 
 	s.logger.Info("GET request received", zap.String("key", req.Key))
 
-	//query local store
-	localValue, localFound := s.store.Get(req.Key)
+	if err := s.checkAbandoned(ctx, "get"); err != nil {
+		return nil, err
+	}
+	if err := s.checkServing(); err != nil {
+		return nil, err
+	}
+
+	// a node that has not stored anything as new as the caller's barrier
+	// may be missing writes acknowledged before it
+	if req.MinHlc != nil {
+		minHLC := hlc.FromProto(req.MinHlc)
+		if maxHLC := s.store.MaxHLC(); maxHLC.HappensBefore(minHLC) {
+			s.logger.Info("GET rejected - node behind read barrier",
+				zap.String("key", req.Key),
+				zap.Stringer("min_hlc", minHLC),
+				zap.Stringer("max_hlc", maxHLC))
+			s.metrics.RecordReadFailure()
+			return &proto.GetResponse{
+				Error: fmt.Sprintf("node has not observed writes up to %s (latest %s)", minHLC, maxHLC),
+			}, nil
+		}
+	}
+
+	if req.FromPeer != "" {
+		return s.getFromPeer(ctx, req)
+	}
+
+	if req.IgnoreStaleness {
+		if !s.stalenessBypass {
+			s.metrics.RecordReadFailure()
+			return &proto.GetResponse{
+				Error: "ignore_staleness is disabled on this node (set STALENESS_BYPASS_ENABLED=true)",
+			}, nil
+		}
+		s.logger.Info("GET bypassing staleness checks", zap.String("key", req.Key))
+	}
+
+	//query local store, a node that does not own the key under sharding
+	// has nothing to contribute and must always ask the owners
+	owner := s.coordinator.IsOwner(req.Key)
+	var localValue storage.VersionedValue
+	var localFound bool
+	if owner {
+		localValue, localFound = s.store.Get(req.Key)
+	}
 
 	// get current read quorum size
 	requiredR := s.quorumProvider.GetR()
+	if req.StrongConsistency {
+		requiredR = s.coordinator.ReplicaCount(req.Key, s.quorumProvider.GetN())
+	}
 
 	// if R = 1, return local value immediately
-	if requiredR == 1 {
+	// (unless only value-holding replicas count and self has none).
+	// observers answer locally unless the read must see every replica
+	if (s.observer && !req.StrongConsistency) || (owner && requiredR == 1 && (localFound || !s.requireValueReplicas)) {
+		s.metrics.ReadsLocalServed.Inc()
+		s.metrics.ObserveSampled(s.metrics.EffectiveR, 1)
+		s.logger.Debug("GET served locally",
+			zap.String("key", req.Key),
+			zap.Int("r", requiredR),
+			zap.Bool("observer", s.observer))
+
 		if !localFound {
 			s.logger.Info("GET not found (local only)", zap.String("key", req.Key))
 			s.metrics.RecordReadSuccess()
@@ -134,7 +785,7 @@ func (s *Server) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResp
 		}
 
 		// check staleness in strict mode
-		if err := s.stalenessDetector.CheckStrict(localValue); err != nil {
+		if err := s.checkStaleness(req, localValue); err != nil {
 			s.logger.Warn("GET rejected - staleness bound exceeded",
 				zap.String("key", req.Key),
 				zap.Error(err))
@@ -151,49 +802,103 @@ func (s *Server) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResp
 			zap.Int64("version", localValue.Version))
 		s.metrics.RecordReadSuccess()
 
-		return &proto.GetResponse{
-			Found:     true,
-			Value:     localValue.Value,
-			Version:   localValue.Version,
-			Timestamp: localValue.Timestamp,
-			Hlc:       localValue.HLC.ToProto(),
-			IsStale:   false,
-		}, nil
+		if notModified(req.KnownHlc, localValue.HLC) {
+			return notModifiedResponse(localValue.Version, localValue.Timestamp, localValue.HLC), nil
+		}
+
+		return s.withAge(req, &proto.GetResponse{
+			Found:         true,
+			Value:         localValue.Value,
+			ContentType:   localValue.ContentType,
+			Version:       localValue.Version,
+			Timestamp:     localValue.Timestamp,
+			Hlc:           localValue.HLC.ToProto(),
+			IsStale:       false,
+			PastStaleness: s.stalenessDetector.IsStale(localValue.HLC, time.Now().UnixNano()),
+		}, localValue.HLC), nil
 	}
 
+	// don't fan out to replicas for a client that has already gone away
+	if err := s.checkAbandoned(ctx, "get"); err != nil {
+		return nil, err
+	}
+
+	s.metrics.ReadsQuorumServed.Inc()
+	s.metrics.ObserveSampled(s.metrics.EffectiveR, float64(requiredR))
+
 	// query R-1 replicas
-	replicaValues, err := s.coordinator.QueryReplicas(ctx, req.Key, requiredR)
+	var replicaValues []replication.ReplicaValue
+	var err error
+	if s.requireValueReplicas {
+		replicaValues, err = s.coordinator.QueryValueReplicas(ctx, req.Key, requiredR, localFound)
+	} else {
+		replicaValues, err = s.coordinator.QueryReplicas(ctx, req.Key, requiredR)
+	}
+	degraded := false
+	if partial, ok := s.degradedValues(req, err); ok {
+		s.logger.Warn("GET degraded - serving below read quorum",
+			zap.String("key", req.Key),
+			zap.Int("required", requiredR),
+			zap.Error(err))
+		s.metrics.ReadsDegraded.Inc()
+		replicaValues, err = partial, nil
+		degraded = true
+	}
 	if err != nil {
 		s.logger.Error("GET failed - insufficient responses",
 			zap.String("key", req.Key),
 			zap.Int("required", requiredR),
 			zap.Error(err))
 		s.metrics.RecordReadFailure()
-		s.metrics.Errors.WithLabelValues("timeout").Inc()
-		return &proto.GetResponse{
-			Found: false,
-			Error: err.Error(),
-		}, nil
+		s.metrics.Errors.WithLabelValues(errorType(err)).Inc()
+		return nil, replicationStatus(err)
 	}
 
-	allValues := replicaValues
-	if localFound {
-		allValues = append(allValues, replication.ReplicaValue{
-			PeerAddr:  "local",
-			Value:     localValue.Value,
-			Version:   localValue.Version,
-			Timestamp: localValue.Timestamp,
-			HLC:       localValue.HLC,
-			IsStale:   false,
-			Found:     true,
-		})
-	}
+	var (
+		mostRecent replication.ReplicaValue
+		divergent  bool
+	)
+	if s.agreementFastPath && !degraded && owner && localFound && agreesWith(localValue.HLC, replicaValues, requiredR-1) {
+		// nothing to merge, audit or repair
+		s.metrics.ReadsAgreementFastPath.Inc()
+		mostRecent = replication.ReplicaValue{
+			PeerAddr:    "local",
+			Value:       localValue.Value,
+			Version:     localValue.Version,
+			Timestamp:   localValue.Timestamp,
+			HLC:         localValue.HLC,
+			Found:       true,
+			ContentType: localValue.ContentType,
+		}
+	} else {
+		allValues := replicaValues
+		if localFound {
+			allValues = append(allValues, replication.ReplicaValue{
+				PeerAddr:    "local",
+				Value:       localValue.Value,
+				Version:     localValue.Version,
+				Timestamp:   localValue.Timestamp,
+				HLC:         localValue.HLC,
+				IsStale:     s.stalenessDetector.IsStale(localValue.HLC, time.Now().UnixNano()),
+				Found:       true,
+				ContentType: localValue.ContentType,
+			})
+		}
 
-	mostRecent, found := replication.GetMostRecent(allValues)
-	if !found {
-		s.logger.Info("GET not found (quorum) read", zap.String("key", req.Key))
-		s.metrics.RecordReadSuccess()
-		return &proto.GetResponse{Found: false}, nil
+		var found bool
+		mostRecent, found = s.selectWinner(allValues)
+		if !found {
+			s.logger.Info("GET not found (quorum) read", zap.String("key", req.Key))
+			s.metrics.RecordReadSuccess()
+			return &proto.GetResponse{Found: false, Degraded: degraded}, nil
+		}
+
+		s.auditReadConflicts(req.Key, allValues, mostRecent)
+		divergent = s.checkDivergence(req.Key, allValues, mostRecent)
+
+		if s.verifyReads {
+			go s.verifyReadResult(req.Key, mostRecent)
+		}
 	}
 
 	// check staleness of most recent value in strict mode
@@ -201,9 +906,9 @@ func (s *Server) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResp
 		Value:   mostRecent.Value,
 		Version: mostRecent.Version,
 		HLC:     mostRecent.HLC,
-		NodeID:  s.nodeID,
+		NodeID:  mostRecent.HLC.NodeID,
 	}
-	if err := s.stalenessDetector.CheckStrict(mostRecentVV); err != nil {
+	if err := s.checkStaleness(req, mostRecentVV); err != nil {
 		s.logger.Warn("GET rejected - staleness bound exceeded (quorum)",
 			zap.String("key", req.Key),
 			zap.String("source", mostRecent.PeerAddr),
@@ -224,66 +929,325 @@ func (s *Server) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResp
 
 	s.metrics.RecordReadSuccess()
 
-	return &proto.GetResponse{
-		Found:     true,
-		Value:     mostRecent.Value,
-		Version:   mostRecent.Version,
-		Timestamp: mostRecent.Timestamp,
-		Hlc:       mostRecent.HLC.ToProto(),
-		IsStale:   false,
-	}, nil
+	if notModified(req.KnownHlc, mostRecent.HLC) {
+		resp := notModifiedResponse(mostRecent.Version, mostRecent.Timestamp, mostRecent.HLC)
+		resp.Divergent = divergent
+		resp.Degraded = degraded
+		return resp, nil
+	}
+
+	return s.withAge(req, &proto.GetResponse{
+		Found:         true,
+		Value:         mostRecent.Value,
+		ContentType:   mostRecent.ContentType,
+		Version:       mostRecent.Version,
+		Timestamp:     mostRecent.Timestamp,
+		Hlc:           mostRecent.HLC.ToProto(),
+		IsStale:       false,
+		Divergent:     divergent,
+		Degraded:      degraded,
+		PastStaleness: s.stalenessDetector.IsStale(mostRecent.HLC, time.Now().UnixNano()),
+	}, mostRecent.HLC), nil
+
+}
+
+// the replica values to serve a read that missed its quorum with, when it
+// may be served degraded. reads that must see every replica never are
+func (s *Server) degradedValues(req *proto.GetRequest, err error) ([]replication.ReplicaValue, bool) {
+	if err == nil || req.StrongConsistency || !(s.degradedReads || req.AllowDegraded) {
+		return nil, false
+	}
+	var insufficient *replication.ErrInsufficientReplicas
+	if errors.As(err, &insufficient) {
+		return insufficient.Partial, true
+	}
+	if errors.Is(err, replication.ErrNoPeers) {
+		return nil, true
+	}
+	return nil, false
+}
+
+// strict staleness check, skipped for reads that asked to ignore staleness
+// (only honoured with the bypass enabled, see Get)
+func (s *Server) checkStaleness(req *proto.GetRequest, vv storage.VersionedValue) error {
+	if req.IgnoreStaleness {
+		return nil
+	}
+	return s.stalenessDetector.CheckStrict(vv)
+}
+
+// bypass reads report the value's age and whether it is past the bound
+// instead of being rejected for it
+func (s *Server) withAge(req *proto.GetRequest, resp *proto.GetResponse, ts hlc.HLC) *proto.GetResponse {
+	if !req.IgnoreStaleness {
+		return resp
+	}
+	now := time.Now().UnixNano()
+	resp.AgeMs = s.stalenessDetector.Age(ts, now).Milliseconds()
+	resp.IsStale = s.stalenessDetector.IsStale(ts, now)
+	return resp
+}
+
+// count a high-divergence read when too many replica values differ from the
+// winner. returns whether the response should be flagged as divergent
+func (s *Server) checkDivergence(key string, values []replication.ReplicaValue, winner replication.ReplicaValue) bool {
+	if s.divergenceThreshold <= 0 || len(values) == 0 {
+		return false
+	}
+
+	differing := 0
+	for _, v := range values {
+		if !v.HLC.Equal(winner.HLC) || v.HLC.NodeID != winner.HLC.NodeID {
+			differing++
+		}
+	}
+
+	fraction := float64(differing) / float64(len(values))
+	if fraction <= s.divergenceThreshold {
+		return false
+	}
+
+	s.metrics.ReadDivergenceHigh.Inc()
+	s.logger.Warn("GET replicas diverged from winner",
+		zap.String("key", key),
+		zap.Int("differing", differing),
+		zap.Int("replicas", len(values)),
+		zap.String("winner", winner.PeerAddr))
+
+	return s.annotateDivergence
+}
 
+// true when at least need replicas answered and every one holds a value at
+// exactly ts. replicas that answered not found are not in values, so fewer
+// than need means someone disagreed
+func agreesWith(ts hlc.HLC, values []replication.ReplicaValue, need int) bool {
+	if len(values) < need {
+		return false
+	}
+	for _, v := range values {
+		if !v.Found || !v.HLC.Equal(ts) || v.HLC.NodeID != ts.NodeID {
+			return false
+		}
+	}
+	return true
+}
+
+// flag a quorum read whose returned value is older than the local one, which
+// points at a merge bug or a race with a concurrent write
+func (s *Server) verifyReadResult(key string, returned replication.ReplicaValue) {
+	local, found := s.store.Get(key)
+	if !found || !local.HLC.HappensAfter(returned.HLC) {
+		return
+	}
+
+	s.metrics.ReadConsistencyAnomaly.Inc()
+	s.logger.Warn("GET returned value older than local store",
+		zap.String("key", key),
+		zap.String("source", returned.PeerAddr),
+		zap.String("returned_hlc", returned.HLC.String()),
+		zap.String("local_hlc", local.HLC.String()))
+}
+
+// check whether the client's cached hlc still matches the current value
+func notModified(known *proto.HLC, current hlc.HLC) bool {
+	if known == nil {
+		return false
+	}
+	knownHLC := hlc.FromProto(known)
+	return knownHLC.Equal(current) && knownHLC.NodeID == current.NodeID
+}
+
+// response for a conditional get whose value is unchanged, value bytes omitted
+func notModifiedResponse(version, timestamp int64, current hlc.HLC) *proto.GetResponse {
+	return &proto.GetResponse{
+		Found:       true,
+		NotModified: true,
+		Version:     version,
+		Timestamp:   timestamp,
+		Hlc:         current.ToProto(),
+	}
 }
 
 // handle local-only get requests from peer nodes during quorum reads
 func (s *Server) GetLocal(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
 	s.logger.Info("GET LOCAL request received", zap.String("key", req.Key))
+	start := time.Now()
+	defer func() {
+		s.metrics.ObserveSampled(s.metrics.GetLocalLatency, time.Since(start).Seconds())
+	}()
 
 	// only query local store, no quorum
 	localValue, localFound := s.store.Get(req.Key)
 
 	if !localFound {
-		return &proto.GetResponse{Found: false}, nil
+		s.metrics.GetLocalTotal.WithLabelValues("not_found").Inc()
+		return &proto.GetResponse{Found: false, NodeId: s.nodeID}, nil
 	}
 
 	// check staleness (for read repair decision)
 	now := time.Now().UnixNano()
 	isStale := s.stalenessDetector.IsStale(localValue.HLC, now)
+	s.metrics.GetLocalTotal.WithLabelValues("found").Inc()
 
 	return &proto.GetResponse{
-		Found:     true,
-		Value:     localValue.Value,
-		Version:   localValue.Version,
-		Timestamp: localValue.Timestamp,
-		Hlc:       localValue.HLC.ToProto(),
-		IsStale:   isStale,
+		Found:       true,
+		Value:       localValue.Value,
+		ContentType: localValue.ContentType,
+		Version:     localValue.Version,
+		Timestamp:   localValue.Timestamp,
+		Hlc:         localValue.HLC.ToProto(),
+		IsStale:     isStale,
+		NodeId:      s.nodeID,
 	}, nil
 }
 
+// answer a get pinned to one peer with that peer's GetLocal response
+func (s *Server) getFromPeer(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	if !s.pinnedReads {
+		return &proto.GetResponse{
+			Error: "from_peer is disabled on this node (set PINNED_READS_ENABLED=true)",
+		}, nil
+	}
+
+	s.logger.Info("GET pinned to peer", zap.String("key", req.Key), zap.String("peer", req.FromPeer))
+	resp, err := s.coordinator.GetLocalFrom(ctx, req.FromPeer, req.Key)
+	if err != nil {
+		return &proto.GetResponse{Error: err.Error()}, nil
+	}
+	return resp, nil
+}
+
+// handle client reads that want every concurrent sibling of a key rather than
+// the lww winner. merges the local siblings with the values of R-1 replicas;
+// a replica only reports its own winner, so siblings held elsewhere as losers
+// are seen once reconciliation has moved them here
+func (s *Server) GetSiblings(ctx context.Context, req *proto.GetRequest) (*proto.GetSiblingsResponse, error) {
+	if err := s.checkServing(); err != nil {
+		return nil, err
+	}
+	values := s.store.GetAll(req.Key)
+
+	requiredR := s.quorumProvider.GetR()
+	if req.StrongConsistency {
+		requiredR = s.coordinator.ReplicaCount(req.Key, s.quorumProvider.GetN())
+	}
+	if requiredR > 1 && (!s.observer || req.StrongConsistency) {
+		replicaValues, err := s.coordinator.QueryReplicas(ctx, req.Key, requiredR)
+		if err != nil {
+			s.metrics.Errors.WithLabelValues(errorType(err)).Inc()
+			return nil, replicationStatus(err)
+		}
+		for _, rv := range replicaValues {
+			if rv.Found {
+				values = append(values, storage.VersionedValue{
+					Value:       rv.Value,
+					ContentType: rv.ContentType,
+					NodeID:      rv.NodeID,
+					HLC:         rv.HLC,
+				})
+			}
+		}
+	}
+
+	resp := &proto.GetSiblingsResponse{}
+	for _, vv := range storage.Siblings(values) {
+		resp.Siblings = append(resp.Siblings, &proto.Sibling{
+			Value:       vv.Value,
+			Hlc:         vv.HLC.ToProto(),
+			NodeId:      vv.NodeID,
+			ContentType: vv.ContentType,
+		})
+	}
+	return resp, nil
+}
+
+// handle client reads of several keys as of one hlc barrier, from this
+// node's store only
+func (s *Server) SnapshotRead(ctx context.Context, req *proto.SnapshotReadRequest) (*proto.SnapshotReadResponse, error) {
+	if err := s.checkServing(); err != nil {
+		return nil, err
+	}
+	barrier := s.hlcClock.Now()
+	if req.AsOf != nil {
+		barrier = hlc.FromProto(req.AsOf)
+	}
+
+	resp := &proto.SnapshotReadResponse{Barrier: barrier.ToProto()}
+	for _, key := range req.Keys {
+		vv, found, complete := s.store.GetAsOf(key, barrier)
+		if !complete {
+			resp.Values = nil
+			resp.Error = fmt.Sprintf("history of key %q does not reach back to %s", key, barrier)
+			return resp, nil
+		}
+		sv := &proto.SnapshotValue{Key: key, Found: found}
+		if found {
+			sv.Value = vv.Value
+			sv.Hlc = vv.HLC.ToProto()
+			sv.ContentType = vv.ContentType
+		}
+		resp.Values = append(resp.Values, sv)
+	}
+	return resp, nil
+}
+
 // handle replication requests from other other nodes
 func (s *Server) Replicate(ctx context.Context, req *proto.ReplicateRequest) (*proto.ReplicateResponse, error) {
 	s.logger.Debug("REPLICATE request received",
 		zap.String("key", req.Key),
 		zap.String("source", req.SourceNodeId),
 		zap.Int64("version", req.Version))
+	start := time.Now()
+	defer func() {
+		s.metrics.ObserveSampled(s.metrics.ReplicateReceivedLatency, time.Since(start).Seconds())
+	}()
 
 	// extract hlc timestamp from request
 	remoteHLC := hlc.FromProto(req.Hlc)
 
-	// update local clock with remote timestamp (clock sync)
-	if err := s.hlcClock.Update(remoteHLC); err != nil {
-		s.logger.Warn("clock update failed during replication",
+	if remoteHLC.Physical == 0 {
+		// a missing or zero hlc would store a value every other write beats
+		// and that reads as stale immediately. stamp it with the local clock
+		// instead, as if this node had accepted the write itself
+		remoteHLC = s.hlcClock.Now()
+		s.logger.Warn("replicate without hlc, stamping with local clock",
+			zap.String("key", req.Key),
 			zap.String("source", req.SourceNodeId),
-			zap.Error(err))
-		// continue with replication despite clock drift warning
+			zap.Stringer("hlc", remoteHLC))
+	} else {
+		// update local clock with remote timestamp (clock sync)
+		err := s.hlcClock.Update(remoteHLC)
+		if err != nil {
+			s.logger.Warn("clock update failed during replication",
+				zap.String("source", req.SourceNodeId),
+				zap.Error(err))
+			// continue with replication despite clock drift warning
+		}
+		s.observeClockDrift(req.SourceNodeId, err)
+	}
+
+	// store with hlc timestamp, unless we already hold a newer value
+	// (out-of-order delivery or a late replay must not clobber it)
+	current, applied := s.store.PutIfNewerWithContentType(req.Key, req.Value, req.ContentType, req.SourceNodeId, remoteHLC)
+	if !applied {
+		s.logger.Debug("REPLICATE ignored - local value is newer",
+			zap.String("key", req.Key),
+			zap.String("source", req.SourceNodeId),
+			zap.Stringer("incoming_hlc", remoteHLC),
+			zap.Stringer("local_hlc", current.HLC))
+		s.metrics.ReplicateReceived.WithLabelValues("ignored").Inc()
+		return &proto.ReplicateResponse{
+			Success: true,
+			NodeId:  s.nodeID,
+		}, nil
 	}
 
-	// store with hlc timestamp
-	s.store.PutWithHLC(req.Key, req.Value, req.SourceNodeId, remoteHLC)
+	s.writeHook.OnCommit(req.Key, req.Value, remoteHLC, req.SourceNodeId)
+	s.metrics.ReplicateReceived.WithLabelValues("applied").Inc()
 
 	// record replicated write in reconciliation log
-	if s.reconciler != nil {
-		s.reconciler.RecordWrite(req.Key, req.Value, req.SourceNodeId, remoteHLC)
+	if !req.Bulk {
+		s.writeLog.AddWithContentType(req.Key, req.Value, req.ContentType, req.SourceNodeId, remoteHLC)
 	}
 
 	return &proto.ReplicateResponse{
@@ -294,23 +1258,360 @@ func (s *Server) Replicate(ctx context.Context, req *proto.ReplicateRequest) (*p
 
 // handle health check requests
 func (s *Server) HealthCheck(ctx context.Context, req *proto.HealthRequest) (*proto.HealthResponse, error) {
-	// update clock with remote timestamp if provided
+	// update clock with remote timestamp if provided, or only check its
+	// drift when health checks are read-only
 	if req.Hlc != nil {
 		remoteHLC := hlc.FromProto(req.Hlc)
-		if err := s.hlcClock.Update(remoteHLC); err != nil {
+		var err error
+		if s.readOnlyHealthClock {
+			err = s.hlcClock.Check(remoteHLC)
+		} else {
+			err = s.hlcClock.Update(remoteHLC)
+		}
+		if err != nil {
 			s.logger.Debug("clock update failed during health check",
 				zap.String("source", req.SourceNodeId),
 				zap.Error(err))
 		}
+		s.observeClockDrift(req.SourceNodeId, err)
 	}
 
-	// generate current hlc timestamp
-	currentHLC := s.hlcClock.Now()
+	// report the current hlc, without ticking it when health checks are read-only
+	var currentHLC hlc.HLC
+	if s.readOnlyHealthClock {
+		currentHLC = s.hlcClock.Peek()
+	} else {
+		currentHLC = s.hlcClock.Now()
+	}
 
 	return &proto.HealthResponse{
 		Healthy:   true,
+		Ready:     s.ready(),
 		NodeId:    s.nodeID,
 		Timestamp: time.Now().UnixNano(),
 		Hlc:       currentHLC.ToProto(),
 	}, nil
 }
+
+// ready reports whether this node plus its connected peers can meet the
+// current W. observers never accept writes, so they are never ready
+func (s *Server) ready() bool {
+	if s.observer {
+		return false
+	}
+	return 1+len(s.coordinator.GetConnectedPeerAddresses()) >= s.quorumProvider.GetW()
+}
+
+// handle admin requests to reconcile with a named peer immediately
+func (s *Server) TriggerReconcile(ctx context.Context, req *proto.TriggerReconcileRequest) (*proto.TriggerReconcileResponse, error) {
+	s.logger.Info("TRIGGER RECONCILE request received", zap.String("peer", req.Peer))
+
+	if s.reconciler == nil {
+		return &proto.TriggerReconcileResponse{
+			Success: false,
+			Error:   "reconciliation is disabled on this node",
+		}, nil
+	}
+
+	keys, err := s.reconciler.ReconcileNow(req.Peer)
+	if err != nil {
+		s.logger.Warn("manual reconciliation rejected",
+			zap.String("peer", req.Peer),
+			zap.Error(err))
+		return &proto.TriggerReconcileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &proto.TriggerReconcileResponse{
+		Success:        true,
+		KeysReconciled: int64(keys),
+	}, nil
+}
+
+// implemented by quorum providers whose cluster size can change at runtime
+type clusterSizer interface {
+	SetN(n int)
+}
+
+// handle admin requests to replace the static peer list at runtime
+func (s *Server) UpdatePeers(ctx context.Context, req *proto.UpdatePeersRequest) (*proto.UpdatePeersResponse, error) {
+	s.logger.Info("UPDATE PEERS request received", zap.Strings("peers", req.Peers))
+
+	peers := make([]string, 0, len(req.Peers))
+	for _, peer := range req.Peers {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			peers = append(peers, peer)
+		}
+	}
+
+	if err := config.ValidatePeers(peers, s.quorumProvider.GetR(), s.quorumProvider.GetW(), s.shardReplicas); err != nil {
+		s.logger.Warn("peer update rejected", zap.Error(err))
+		return &proto.UpdatePeersResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.coordinator.UpdatePeers(peers)
+	if s.probe != nil {
+		s.probe.UpdatePeers(peers)
+	}
+
+	// with sharding quorums stay among a key's owners whatever the cluster size
+	n := len(peers) + 1
+	if sizer, ok := s.quorumProvider.(clusterSizer); ok && s.shardReplicas == 0 {
+		sizer.SetN(n)
+	}
+
+	s.logger.Info("peer list updated",
+		zap.Int("cluster_size", n),
+		zap.Strings("peers", peers))
+
+	return &proto.UpdatePeersResponse{
+		Success:     true,
+		ClusterSize: int32(n),
+	}, nil
+}
+
+// implemented by quorum providers that accept an operator's quorum change
+type manualQuorumSetter interface {
+	SetQuorumManual(newR, newW int, reason string) error
+}
+
+// handle admin requests to set R and W by hand. the adaptive quorum holds the
+// change off the adjuster for its manual hold window
+func (s *Server) SetQuorum(ctx context.Context, req *proto.SetQuorumRequest) (*proto.SetQuorumResponse, error) {
+	s.logger.Info("SET QUORUM request received",
+		zap.Int32("r", req.R),
+		zap.Int32("w", req.W),
+		zap.String("reason", req.Reason))
+
+	setter, ok := s.quorumProvider.(manualQuorumSetter)
+	if !ok {
+		return &proto.SetQuorumResponse{
+			Success: false,
+			Error:   "quorum is static on this node (set ADAPTIVE_ENABLED=true)",
+		}, nil
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "manual"
+	}
+	if err := setter.SetQuorumManual(int(req.R), int(req.W), reason); err != nil {
+		s.logger.Warn("quorum change rejected", zap.Error(err))
+		return &proto.SetQuorumResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return &proto.SetQuorumResponse{Success: true}, nil
+}
+
+// handle admin requests to wipe the store and reconcile log
+func (s *Server) Flush(ctx context.Context, req *proto.FlushRequest) (*proto.FlushResponse, error) {
+	if !s.flushEnabled {
+		s.logger.Warn("FLUSH rejected - flush is disabled on this node")
+		return &proto.FlushResponse{
+			Success: false,
+			Error:   "flush is disabled on this node (set FLUSH_ENABLED=true, test environments only)",
+		}, nil
+	}
+
+	removed := s.store.Clear()
+	s.writeLog.Clear()
+
+	s.logger.Warn("FLUSH completed - all data removed", zap.Int("keys_removed", removed))
+
+	return &proto.FlushResponse{
+		Success:     true,
+		KeysRemoved: int32(removed),
+	}, nil
+}
+
+// page size used by ListKeys when the request does not set one
+const defaultListKeysLimit = 1000
+
+// handle admin requests for a page of keys in sorted order. values are never
+// returned, metadata only when asked for
+func (s *Server) ListKeys(ctx context.Context, req *proto.ListKeysRequest) (*proto.ListKeysResponse, error) {
+	if !s.listKeysEnabled {
+		return &proto.ListKeysResponse{
+			Error: "list keys is disabled on this node (set LIST_KEYS_ENABLED=true)",
+		}, nil
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultListKeysLimit
+	}
+
+	// fetch one extra key to tell whether another page follows
+	keys := s.store.Keys(req.After, limit+1)
+	resp := &proto.ListKeysResponse{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		resp.NextCursor = keys[limit-1]
+	}
+
+	resp.Keys = make([]*proto.KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		info := &proto.KeyInfo{Key: key}
+		if req.Metadata {
+			// the key may have been removed since it was listed
+			if vv, found := s.store.Peek(key); found {
+				info.Hlc = vv.HLC.ToProto()
+				info.NodeId = vv.NodeID
+				info.Size = int32(len(vv.Value))
+			}
+		}
+		resp.Keys = append(resp.Keys, info)
+	}
+	return resp, nil
+}
+
+// handle admin requests for the keys this node is missing, or holds an older
+// version of, relative to a peer. a diagnostic diff, nothing is repaired
+func (s *Server) MissingKeys(ctx context.Context, req *proto.MissingKeysRequest) (*proto.MissingKeysResponse, error) {
+	s.logger.Info("MISSING KEYS request received", zap.String("peer", req.Peer))
+
+	resp := &proto.MissingKeysResponse{}
+	cursor := ""
+	for {
+		page, err := s.coordinator.ListKeysFrom(ctx, req.Peer, &proto.ListKeysRequest{
+			After:    cursor,
+			Limit:    defaultListKeysLimit,
+			Metadata: true,
+		})
+		if err != nil {
+			return &proto.MissingKeysResponse{Error: err.Error()}, nil
+		}
+		if page.Error != "" {
+			return &proto.MissingKeysResponse{Error: "peer: " + page.Error}, nil
+		}
+
+		for _, info := range page.Keys {
+			// removed on the peer between listing and reading its metadata
+			if info.Hlc == nil {
+				continue
+			}
+			resp.KeysCompared++
+			local, found := s.store.Peek(info.Key)
+			switch {
+			case !found:
+				resp.Keys = append(resp.Keys, &proto.MissingKey{Key: info.Key, PeerHlc: info.Hlc})
+			case local.HLC.HappensBefore(hlc.FromProto(info.Hlc)):
+				resp.Keys = append(resp.Keys, &proto.MissingKey{Key: info.Key, LocalHlc: local.HLC.ToProto(), PeerHlc: info.Hlc})
+			}
+		}
+
+		if page.NextCursor == "" {
+			return resp, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// handle admin requests for the most frequently accessed keys
+func (s *Server) HotKeys(ctx context.Context, req *proto.HotKeysRequest) (*proto.HotKeysResponse, error) {
+	keys := s.store.HotKeys(int(req.Limit))
+	if keys == nil {
+		return &proto.HotKeysResponse{
+			Error: "hot key tracking is disabled on this node",
+		}, nil
+	}
+
+	resp := &proto.HotKeysResponse{Keys: make([]*proto.HotKey, 0, len(keys))}
+	for _, kc := range keys {
+		resp.Keys = append(resp.Keys, &proto.HotKey{Key: kc.Key, Count: kc.Count})
+	}
+	return resp, nil
+}
+
+// periodically evicts values whose hlc is older than maxAge. age comes from
+// the value's own hlc, so every node running with the same maxAge evicts the
+// same entries and the cluster converges without tombstones. an old value
+// replicated after a sweep is evicted again by the next one
+func (s *Server) StartAgeEviction(ctx context.Context, maxAge, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.evictOlderThan(maxAge)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) evictOlderThan(maxAge time.Duration) int {
+	evicted := s.store.EvictOlderThan(time.Now().Add(-maxAge).UnixNano())
+	if evicted > 0 {
+		s.metrics.AgeEvicted.Add(float64(evicted))
+		s.logger.Info("evicted values past the age threshold",
+			zap.Int("evicted", evicted),
+			zap.Duration("max_age", maxAge))
+	}
+	return evicted
+}
+
+// periodically publishes the tracked top-k keys to the hot_key gauge,
+// dropping keys that fell out of the top-k
+func (s *Server) StartHotKeyReporting(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reportHotKeys()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) reportHotKeys() {
+	keys := s.store.HotKeys(0)
+
+	s.metrics.HotKey.Reset()
+	for _, kc := range keys {
+		s.metrics.HotKey.WithLabelValues(kc.Key).Set(float64(kc.Count))
+	}
+}
+
+// map replication errors to grpc status errors so clients can branch on the
+// code. missed quorums are Unavailable, with got and need in the message
+func replicationStatus(err error) error {
+	var acksErr *replication.ErrInsufficientAcks
+	var replicasErr *replication.ErrInsufficientReplicas
+
+	switch {
+	case errors.Is(err, replication.ErrNoPeers), errors.As(err, &acksErr), errors.As(err, &replicasErr):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// map replication errors to the errors_total type label
+func errorType(err error) string {
+	var acksErr *replication.ErrInsufficientAcks
+	var replicasErr *replication.ErrInsufficientReplicas
+
+	switch {
+	case errors.Is(err, replication.ErrNoPeers):
+		return "no_peers"
+	case errors.As(err, &acksErr), errors.As(err, &replicasErr):
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,1662 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"github.com/rachitkumar205/acp-kv/internal/adaptive"
+	"github.com/rachitkumar205/acp-kv/internal/config"
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/partition"
+	"github.com/rachitkumar205/acp-kv/internal/reconcile"
+	"github.com/rachitkumar205/acp-kv/internal/replication"
+	"github.com/rachitkumar205/acp-kv/internal/staleness"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// shared metrics instance to avoid duplicate registration
+var testMetrics = metrics.NewMetrics("test")
+
+// single node server with no peers, r=1 w=1
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	logger := zap.NewNop()
+	cfg := &config.Config{NodeID: "node1", N: 1, R: 1, W: 1}
+
+	store := storage.NewStore()
+	coordinator, err := replication.NewCoordinator(cfg.NodeID, []string{}, logger, testMetrics, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create coordinator: %v", err)
+	}
+	t.Cleanup(func() { coordinator.Close() })
+
+	hlcClock := hlc.NewClock(cfg.NodeID, 500*time.Millisecond)
+	stalenessDetector := staleness.NewDetector(3*time.Second, testMetrics)
+	reconciler := reconcile.NewEngine(store, coordinator, 30*time.Second, true, logger, testMetrics)
+
+	return NewServer(cfg.NodeID, store, coordinator, cfg, logger, testMetrics, hlcClock, stalenessDetector, reconciler)
+}
+
+func TestPut_ObservesPhaseLatencies(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	localBefore, _ := reader.GetHistogramStats(testMetrics.PutLocalLatency)
+	replicateBefore, _ := reader.GetHistogramStats(testMetrics.PutReplicateLatency)
+
+	resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v")})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected put to succeed, got err=%v resp=%v", err, resp)
+	}
+
+	localAfter, _ := reader.GetHistogramStats(testMetrics.PutLocalLatency)
+	replicateAfter, _ := reader.GetHistogramStats(testMetrics.PutReplicateLatency)
+
+	if localAfter.Count != localBefore.Count+1 {
+		t.Errorf("expected one local latency observation, got %d", localAfter.Count-localBefore.Count)
+	}
+	if replicateAfter.Count != replicateBefore.Count+1 {
+		t.Errorf("expected one replicate latency observation, got %d", replicateAfter.Count-replicateBefore.Count)
+	}
+}
+
+func TestPut_RollbackOnQuorumFailure(t *testing.T) {
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	srv.SetRollbackOnFailure(true)
+
+	// no peers and w=2, put must fail and leave nothing behind
+	_, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v1")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected put to fail without quorum with Unavailable, got %v", err)
+	}
+	if _, found := srv.store.Get("key1"); found {
+		t.Error("expected failed put to be rolled back from the local store")
+	}
+	if writes := srv.reconciler.RecentWrites(); len(writes) != 0 {
+		t.Errorf("expected failed put to be removed from reconcile log, got %d entries", len(writes))
+	}
+}
+
+func TestPut_RollbackRestoresPreviousValue(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetRollbackOnFailure(true)
+
+	// successful write at w=1
+	if resp, _ := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v1")}); !resp.Success {
+		t.Fatal("expected first put to succeed")
+	}
+
+	// failed write at w=2
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	if _, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v2")}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected second put to fail without quorum, got %v", err)
+	}
+
+	value, found := srv.store.Get("key1")
+	if !found || string(value.Value) != "v1" {
+		t.Errorf("expected previous value v1 to be restored, got found=%v value=%s", found, string(value.Value))
+	}
+}
+
+func TestPut_KeepOnQuorumFailure(t *testing.T) {
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+
+	if _, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v1")}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected put to fail without quorum, got %v", err)
+	}
+	if _, found := srv.store.Get("key1"); !found {
+		t.Error("expected failed put to remain locally in keep mode")
+	}
+}
+
+func TestGet_Conditional(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	putResp, _ := srv.Put(ctx, &proto.PutRequest{Key: "key1", Value: []byte("v1")})
+	if !putResp.Success {
+		t.Fatal("expected put to succeed")
+	}
+
+	// known hlc matches current value
+	resp, err := srv.Get(ctx, &proto.GetRequest{Key: "key1", KnownHlc: putResp.Hlc})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Found || !resp.NotModified || len(resp.Value) != 0 {
+		t.Errorf("expected not modified without value, got found=%v not_modified=%v value=%s", resp.Found, resp.NotModified, resp.Value)
+	}
+
+	// value changed since known hlc
+	srv.Put(ctx, &proto.PutRequest{Key: "key1", Value: []byte("v2")})
+	resp, _ = srv.Get(ctx, &proto.GetRequest{Key: "key1", KnownHlc: putResp.Hlc})
+	if resp.NotModified || string(resp.Value) != "v2" {
+		t.Errorf("expected modified value v2, got not_modified=%v value=%s", resp.NotModified, resp.Value)
+	}
+
+	// key does not exist
+	resp, _ = srv.Get(ctx, &proto.GetRequest{Key: "missing", KnownHlc: putResp.Hlc})
+	if resp.Found || resp.NotModified {
+		t.Errorf("expected not found, got found=%v not_modified=%v", resp.Found, resp.NotModified)
+	}
+}
+
+func TestReplicate_StaleDoesNotClobberNewer(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	now := time.Now().UnixNano()
+	newer := &proto.HLC{Physical: now, Logical: 0, NodeId: "node2"}
+	older := &proto.HLC{Physical: now - int64(time.Second), Logical: 0, NodeId: "node3"}
+
+	srv.Replicate(ctx, &proto.ReplicateRequest{Key: "key1", Value: []byte("new"), SourceNodeId: "node2", Hlc: newer})
+
+	// late delivery of an older write
+	resp, err := srv.Replicate(ctx, &proto.ReplicateRequest{Key: "key1", Value: []byte("old"), SourceNodeId: "node3", Hlc: older})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected stale replicate to be acknowledged, got err=%v resp=%v", err, resp)
+	}
+
+	value, _ := srv.store.Get("key1")
+	if string(value.Value) != "new" {
+		t.Errorf("expected newer value to be kept, got %s", value.Value)
+	}
+}
+
+func TestPeerHandlers_CountResults(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+	ctx := context.Background()
+
+	count := func(vec *prometheus.CounterVec, result string) float64 {
+		v, _ := reader.GetCounterValue(vec.WithLabelValues(result))
+		return v
+	}
+	applied := count(testMetrics.ReplicateReceived, "applied")
+	ignored := count(testMetrics.ReplicateReceived, "ignored")
+	found := count(testMetrics.GetLocalTotal, "found")
+	notFound := count(testMetrics.GetLocalTotal, "not_found")
+	replicateLatency, _ := reader.GetHistogramStats(testMetrics.ReplicateReceivedLatency)
+	getLocalLatency, _ := reader.GetHistogramStats(testMetrics.GetLocalLatency)
+
+	now := time.Now().UnixNano()
+	srv.Replicate(ctx, &proto.ReplicateRequest{Key: "key1", Value: []byte("new"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: now, NodeId: "node2"}})
+	srv.Replicate(ctx, &proto.ReplicateRequest{Key: "key1", Value: []byte("old"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: now - 1, NodeId: "node2"}})
+	srv.GetLocal(ctx, &proto.GetRequest{Key: "key1"})
+	srv.GetLocal(ctx, &proto.GetRequest{Key: "missing"})
+
+	if d := count(testMetrics.ReplicateReceived, "applied") - applied; d != 1 {
+		t.Errorf("expected 1 applied replicate, got %v", d)
+	}
+	if d := count(testMetrics.ReplicateReceived, "ignored") - ignored; d != 1 {
+		t.Errorf("expected 1 ignored replicate, got %v", d)
+	}
+	if d := count(testMetrics.GetLocalTotal, "found") - found; d != 1 {
+		t.Errorf("expected 1 found getlocal, got %v", d)
+	}
+	if d := count(testMetrics.GetLocalTotal, "not_found") - notFound; d != 1 {
+		t.Errorf("expected 1 not found getlocal, got %v", d)
+	}
+
+	if after, _ := reader.GetHistogramStats(testMetrics.ReplicateReceivedLatency); after.Count != replicateLatency.Count+2 {
+		t.Errorf("expected 2 replicate latency observations, got %d", after.Count-replicateLatency.Count)
+	}
+	if after, _ := reader.GetHistogramStats(testMetrics.GetLocalLatency); after.Count != getLocalLatency.Count+2 {
+		t.Errorf("expected 2 getlocal latency observations, got %d", after.Count-getLocalLatency.Count)
+	}
+}
+
+func TestPut_BulkSkipsReconcileLog(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	srv.Put(ctx, &proto.PutRequest{Key: "bulk1", Value: []byte("v"), Bulk: true})
+	srv.Replicate(ctx, &proto.ReplicateRequest{Key: "bulk2", Value: []byte("v"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: 1, NodeId: "node2"}, Bulk: true})
+
+	if n := len(srv.reconciler.RecentWrites()); n != 0 {
+		t.Fatalf("expected bulk writes to skip reconcile log, got %d entries", n)
+	}
+	if _, found := srv.store.Get("bulk1"); !found {
+		t.Error("expected bulk put to be stored")
+	}
+	if _, found := srv.store.Get("bulk2"); !found {
+		t.Error("expected bulk replicate to be stored")
+	}
+
+	srv.Put(ctx, &proto.PutRequest{Key: "live", Value: []byte("v")})
+	if n := len(srv.reconciler.RecentWrites()); n != 1 {
+		t.Errorf("expected normal put to be recorded, got %d entries", n)
+	}
+}
+
+func TestWriteLog_RecordedWithoutReconciler(t *testing.T) {
+	srv := newTestServer(t)
+	srv.reconciler = nil
+	ctx := context.Background()
+
+	srv.Put(ctx, &proto.PutRequest{Key: "local", Value: []byte("v")})
+	srv.Replicate(ctx, &proto.ReplicateRequest{Key: "remote", Value: []byte("v"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: 1, NodeId: "node2"}})
+
+	if n := len(srv.RecentWrites()); n != 2 {
+		t.Fatalf("expected writes to be recorded with reconciliation off, got %d entries", n)
+	}
+
+	// an engine attached later sees the history
+	engine := reconcile.NewEngine(srv.store, srv.coordinator, 30*time.Second, true, zap.NewNop(), testMetrics)
+	srv.SetReconciler(engine)
+	if n := len(engine.RecentWrites()); n != 2 {
+		t.Errorf("expected attached engine to share the write log, got %d entries", n)
+	}
+}
+
+func TestCheckDivergence(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetReadDivergence(0.5, true)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	winner := replication.ReplicaValue{PeerAddr: "local", HLC: hlc.HLC{Physical: 300, NodeID: "node1"}}
+	older := func(addr string, physical int64) replication.ReplicaValue {
+		return replication.ReplicaValue{PeerAddr: addr, HLC: hlc.HLC{Physical: physical, NodeID: "node2"}}
+	}
+
+	before, _ := reader.GetCounterValue(testMetrics.ReadDivergenceHigh)
+
+	// all replicas agree with the winner
+	agreeing := []replication.ReplicaValue{winner, winner, winner}
+	if srv.checkDivergence("key1", agreeing, winner) {
+		t.Error("expected agreeing replicas not to be flagged")
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadDivergenceHigh); v != before {
+		t.Errorf("expected counter unchanged for agreeing replicas, got %v", v-before)
+	}
+
+	// two of three replicas hold different versions
+	diverging := []replication.ReplicaValue{winner, older("peer1", 100), older("peer2", 200)}
+	if !srv.checkDivergence("key1", diverging, winner) {
+		t.Error("expected diverging replicas to be flagged")
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadDivergenceHigh); v != before+1 {
+		t.Errorf("expected counter to increment once, got %v", v-before)
+	}
+
+	// without annotation the read is still counted but not flagged
+	srv.SetReadDivergence(0.5, false)
+	if srv.checkDivergence("key1", diverging, winner) {
+		t.Error("expected no flag when annotation is disabled")
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadDivergenceHigh); v != before+2 {
+		t.Errorf("expected counter to increment again, got %v", v-before)
+	}
+}
+
+func TestVerifyReadResult_LocalNewer(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetReadVerification(true)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	returned := replication.ReplicaValue{PeerAddr: "peer1", Value: []byte("v1"), HLC: hlc.HLC{Physical: 100, NodeID: "node2"}}
+	before, _ := reader.GetCounterValue(testMetrics.ReadConsistencyAnomaly)
+
+	// local matches the returned value, no anomaly
+	srv.store.PutWithHLC("key1", []byte("v1"), "node2", returned.HLC)
+	srv.verifyReadResult("key1", returned)
+	if v, _ := reader.GetCounterValue(testMetrics.ReadConsistencyAnomaly); v != before {
+		t.Fatalf("expected no anomaly when local matches, got %v", v-before)
+	}
+
+	// local value newer than what the merge returned
+	srv.store.PutWithHLC("key1", []byte("v2"), "node1", hlc.HLC{Physical: 200, NodeID: "node1"})
+	srv.verifyReadResult("key1", returned)
+	if v, _ := reader.GetCounterValue(testMetrics.ReadConsistencyAnomaly); v != before+1 {
+		t.Errorf("expected anomaly counter to fire once, got %v", v-before)
+	}
+}
+
+func TestPut_HistogramSampling(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	testMetrics.SetHistogramSampling(10)
+	t.Cleanup(func() { testMetrics.SetHistogramSampling(1) })
+
+	before, _ := reader.GetHistogramStats(testMetrics.PutLatency)
+
+	const ops = 2000
+	for i := 0; i < ops; i++ {
+		resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v")})
+		if err != nil || !resp.Success {
+			t.Fatalf("expected sampled put to succeed, got err=%v resp=%v", err, resp)
+		}
+	}
+
+	after, _ := reader.GetHistogramStats(testMetrics.PutLatency)
+
+	// expect ~200 observations, allow wide margin for randomness
+	observed := after.Count - before.Count
+	if observed < 100 || observed > 300 {
+		t.Errorf("expected about %d sampled observations, got %d", ops/10, observed)
+	}
+}
+
+func TestPut_ExpiredDeadlineShortCircuits(t *testing.T) {
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	reader := metrics.NewMetricsReader(testMetrics)
+	abandoned := testMetrics.RequestsAbandoned.WithLabelValues("put")
+	before, _ := reader.GetCounterValue(abandoned)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := srv.Put(ctx, &proto.PutRequest{Key: "key1", Value: []byte("v")})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if _, found := srv.store.Get("key1"); found {
+		t.Error("expected expired put not to reach the local store")
+	}
+	if v, _ := reader.GetCounterValue(abandoned); v != before+1 {
+		t.Errorf("expected one abandoned put, got %v", v-before)
+	}
+}
+
+func TestGet_ExpiredDeadlineShortCircuits(t *testing.T) {
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	reader := metrics.NewMetricsReader(testMetrics)
+	abandoned := testMetrics.RequestsAbandoned.WithLabelValues("get")
+	before, _ := reader.GetCounterValue(abandoned)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := srv.Get(ctx, &proto.GetRequest{Key: "key1"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if v, _ := reader.GetCounterValue(abandoned); v != before+1 {
+		t.Errorf("expected one abandoned get, got %v", v-before)
+	}
+}
+
+// recordingHook captures commits, optionally blocking until released
+type recordingHook struct {
+	mu      sync.Mutex
+	keys    []string
+	nodeIDs []string
+	release chan struct{}
+}
+
+func (h *recordingHook) OnCommit(key string, value []byte, timestamp hlc.HLC, nodeID string) {
+	if h.release != nil {
+		<-h.release
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keys = append(h.keys, key)
+	h.nodeIDs = append(h.nodeIDs, nodeID)
+}
+
+func TestWriteHook_ReceivesCommits(t *testing.T) {
+	srv := newTestServer(t)
+	hook := &recordingHook{}
+	srv.SetWriteHook(hook)
+
+	if resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v")}); err != nil || !resp.Success {
+		t.Fatalf("expected put to succeed, got err=%v resp=%v", err, resp)
+	}
+	_, err := srv.Replicate(context.Background(), &proto.ReplicateRequest{
+		Key:          "key2",
+		Value:        []byte("v"),
+		SourceNodeId: "node2",
+		Hlc:          &proto.HLC{Physical: time.Now().UnixNano(), NodeId: "node2"},
+	})
+	if err != nil {
+		t.Fatalf("replicate failed: %v", err)
+	}
+
+	if len(hook.keys) != 2 || hook.keys[0] != "key1" || hook.keys[1] != "key2" {
+		t.Fatalf("expected commits for key1 and key2, got %v", hook.keys)
+	}
+	if hook.nodeIDs[0] != "node1" || hook.nodeIDs[1] != "node2" {
+		t.Errorf("expected writer node ids node1 and node2, got %v", hook.nodeIDs)
+	}
+}
+
+func TestReplicate_MissingHLCStampedLocally(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	before := time.Now().UnixNano()
+
+	for _, ts := range []*proto.HLC{nil, {}} {
+		if _, err := srv.Replicate(ctx, &proto.ReplicateRequest{Key: "k", Value: []byte("v"), SourceNodeId: "node2", Hlc: ts}); err != nil {
+			t.Fatalf("replicate failed: %v", err)
+		}
+		vv, ok := srv.store.Get("k")
+		if !ok {
+			t.Fatal("expected replicated value to be stored")
+		}
+		if vv.HLC.Physical < before {
+			t.Errorf("expected hlc %v to be stamped from the local clock, not zero", ts)
+		}
+	}
+}
+
+func TestReplicate_ConvergesInEitherOrder(t *testing.T) {
+	now := time.Now().UnixNano()
+	writes := []*proto.ReplicateRequest{
+		{Key: "key1", Value: []byte("from-node2"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: now, Logical: 1, NodeId: "node2"}},
+		{Key: "key1", Value: []byte("from-node3"), SourceNodeId: "node3", Hlc: &proto.HLC{Physical: now, Logical: 1, NodeId: "node3"}},
+	}
+
+	apply := func(order ...int) storage.VersionedValue {
+		srv := newTestServer(t)
+		for _, i := range order {
+			if _, err := srv.Replicate(context.Background(), writes[i]); err != nil {
+				t.Fatalf("replicate failed: %v", err)
+			}
+		}
+		vv, _ := srv.store.Get("key1")
+		return vv
+	}
+
+	forward, backward := apply(0, 1), apply(1, 0)
+	if string(forward.Value) != string(backward.Value) {
+		t.Fatalf("expected both arrival orders to converge, got %s and %s", forward.Value, backward.Value)
+	}
+
+	// and the read merge picks the same write
+	winner, _ := replication.GetMostRecent([]replication.ReplicaValue{
+		{PeerAddr: "a", HLC: hlc.FromProto(writes[0].Hlc), Value: writes[0].Value, Found: true},
+		{PeerAddr: "b", HLC: hlc.FromProto(writes[1].Hlc), Value: writes[1].Value, Found: true},
+	})
+	if string(winner.Value) != string(forward.Value) {
+		t.Errorf("expected read merge to pick %s, got %s", forward.Value, winner.Value)
+	}
+}
+
+func TestAsyncWriteHook_DoesNotBlockWrites(t *testing.T) {
+	srv := newTestServer(t)
+	slow := &recordingHook{release: make(chan struct{})}
+	async := NewAsyncWriteHook(slow, 1, zap.NewNop(), testMetrics)
+	srv.SetWriteHook(async)
+
+	reader := metrics.NewMetricsReader(testMetrics)
+	droppedBefore, _ := reader.GetCounterValue(testMetrics.WriteHookDropped)
+
+	// the first commit blocks the hook, the second fills the buffer and
+	// the rest are dropped; none of the puts may wait on the hook
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("v")})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected puts to complete while the hook is blocked")
+	}
+
+	close(slow.release)
+	async.Close()
+
+	if len(slow.keys) < 1 || len(slow.keys) > 2 {
+		t.Errorf("expected the blocked and buffered commits to be delivered, got %d", len(slow.keys))
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.WriteHookDropped); v-droppedBefore != float64(5-len(slow.keys)) {
+		t.Errorf("expected %d dropped commits, got %v", 5-len(slow.keys), v-droppedBefore)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, key := range []string{"key1", "key2"} {
+		if resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: key, Value: []byte("v")}); err != nil || !resp.Success {
+			t.Fatalf("expected put to succeed, got err=%v resp=%v", err, resp)
+		}
+	}
+
+	// rejected unless explicitly enabled
+	resp, err := srv.Flush(context.Background(), &proto.FlushRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success {
+		t.Fatal("expected flush to be rejected when disabled")
+	}
+	if srv.store.Size() != 2 {
+		t.Fatalf("expected rejected flush to leave the store alone, got %d keys", srv.store.Size())
+	}
+
+	srv.SetFlushEnabled(true)
+	resp, err = srv.Flush(context.Background(), &proto.FlushRequest{})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected flush to succeed, got err=%v resp=%v", err, resp)
+	}
+	if resp.KeysRemoved != 2 {
+		t.Errorf("expected 2 keys removed, got %d", resp.KeysRemoved)
+	}
+	if srv.store.Size() != 0 {
+		t.Errorf("expected empty store after flush, got %d keys", srv.store.Size())
+	}
+	if n := len(srv.reconciler.RecentWrites()); n != 0 {
+		t.Errorf("expected empty reconcile log after flush, got %d entries", n)
+	}
+}
+
+func TestBackwardJump_CountedInMetrics(t *testing.T) {
+	srv := newTestServer(t)
+	srv.EnableBackwardJumpWarnings(time.Second)
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.ClockBackwardJumps)
+
+	// the clock invokes this on a step back beyond the threshold
+	srv.warnBackwardJump(5*time.Second, 5*time.Second)
+
+	if v, _ := reader.GetCounterValue(testMetrics.ClockBackwardJumps); v-before != 1 {
+		t.Errorf("expected one backward jump counted, got %v", v-before)
+	}
+}
+
+func TestGet_IgnoreStalenessReturnsStaleValue(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	// older than the 3s bound of the test server
+	old := hlc.HLC{Physical: time.Now().Add(-10 * time.Second).UnixNano(), NodeID: "node1"}
+	srv.store.PutWithHLC("key1", []byte("old"), "node1", old)
+
+	if resp, _ := srv.Get(ctx, &proto.GetRequest{Key: "key1"}); !resp.IsStale || resp.Error == "" {
+		t.Fatalf("expected stale value to be rejected, got %v", resp)
+	}
+
+	// the bypass must be enabled on the node
+	if resp, _ := srv.Get(ctx, &proto.GetRequest{Key: "key1", IgnoreStaleness: true}); resp.Error == "" || resp.Found {
+		t.Fatalf("expected ignore_staleness to be refused while disabled, got %v", resp)
+	}
+
+	srv.SetStalenessBypass(true)
+	resp, err := srv.Get(ctx, &proto.GetRequest{Key: "key1", IgnoreStaleness: true})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("expected bypass read to succeed, got err=%v resp=%v", err, resp)
+	}
+	if !resp.Found || string(resp.Value) != "old" || !resp.IsStale {
+		t.Errorf("expected the old value flagged stale, got %v", resp)
+	}
+	if resp.AgeMs < 10000 || resp.AgeMs > 11000 {
+		t.Errorf("expected age of about 10s, got %dms", resp.AgeMs)
+	}
+
+	// fresh values are not flagged
+	srv.store.PutWithHLC("key2", []byte("new"), "node1", hlc.HLC{Physical: time.Now().UnixNano(), NodeID: "node1"})
+	if resp, _ := srv.Get(ctx, &proto.GetRequest{Key: "key2", IgnoreStaleness: true}); resp.IsStale || resp.AgeMs > 1000 {
+		t.Errorf("expected fresh value unflagged, got %v", resp)
+	}
+}
+
+func TestAgeEviction_SweepsOnlyOldValues(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+	before, _ := reader.GetCounterValue(testMetrics.AgeEvicted)
+
+	now := time.Now()
+	srv.store.PutWithHLC("old1", []byte("v"), "node1", hlc.HLC{Physical: now.Add(-2 * time.Hour).UnixNano(), NodeID: "node1"})
+	srv.store.PutWithHLC("old2", []byte("v"), "node2", hlc.HLC{Physical: now.Add(-90 * time.Minute).UnixNano(), NodeID: "node2"})
+	srv.store.PutWithHLC("fresh", []byte("v"), "node1", hlc.HLC{Physical: now.Add(-time.Minute).UnixNano(), NodeID: "node1"})
+
+	if n := srv.evictOlderThan(time.Hour); n != 2 {
+		t.Errorf("expected 2 values evicted, got %d", n)
+	}
+	for _, key := range []string{"old1", "old2"} {
+		if _, found := srv.store.Get(key); found {
+			t.Errorf("expected %s to be swept", key)
+		}
+	}
+	if _, found := srv.store.Get("fresh"); !found {
+		t.Error("expected fresh value to remain")
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.AgeEvicted); v-before != 2 {
+		t.Errorf("expected 2 evictions counted, got %v", v-before)
+	}
+}
+
+func TestListKeys_PagesWithoutDupesOrGaps(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	if resp, _ := srv.ListKeys(ctx, &proto.ListKeysRequest{}); resp.Error == "" {
+		t.Fatal("expected list keys to be rejected when disabled")
+	}
+	srv.SetListKeysEnabled(true)
+
+	want := make([]string, 0, 25)
+	for i := 0; i < 25; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		want = append(want, key)
+		srv.store.PutWithHLC(key, []byte("value"), "node1", hlc.HLC{Physical: int64(i + 1), NodeID: "node1"})
+	}
+
+	var got []string
+	cursor, pages := "", 0
+	for {
+		resp, err := srv.ListKeys(ctx, &proto.ListKeysRequest{After: cursor, Limit: 10, Metadata: true})
+		if err != nil || resp.Error != "" {
+			t.Fatalf("list keys failed: err=%v resp=%v", err, resp)
+		}
+		pages++
+		for _, k := range resp.Keys {
+			got = append(got, k.Key)
+			if k.Size != 5 || k.NodeId != "node1" || k.Hlc.GetPhysical() == 0 {
+				t.Errorf("expected metadata for %s, got %v", k.Key, k)
+			}
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	if pages != 3 {
+		t.Errorf("expected 3 pages of at most 10 keys, got %d", pages)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected every key once in sorted order, got %v", got)
+	}
+
+	// metadata is opt-in
+	resp, _ := srv.ListKeys(ctx, &proto.ListKeysRequest{Limit: 1})
+	if len(resp.Keys) != 1 || resp.Keys[0].Hlc != nil || resp.Keys[0].Size != 0 {
+		t.Errorf("expected a bare key without metadata, got %v", resp.Keys)
+	}
+}
+
+func TestReadBarrier(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	var latest hlc.HLC
+	for _, key := range []string{"key1", "key2", "key3"} {
+		resp, err := srv.Put(ctx, &proto.PutRequest{Key: key, Value: []byte("v")})
+		if err != nil || !resp.Success {
+			t.Fatalf("expected put to succeed, got err=%v resp=%v", err, resp)
+		}
+		latest = hlc.FromProto(resp.Hlc)
+	}
+
+	barrier, err := srv.ReadBarrier(ctx, &proto.ReadBarrierRequest{})
+	if err != nil {
+		t.Fatalf("read barrier failed: %v", err)
+	}
+	if hlc.FromProto(barrier.Hlc).HappensBefore(latest) {
+		t.Fatalf("expected barrier %v to cover completed write %v", barrier.Hlc, latest)
+	}
+
+	// a read at the barrier succeeds
+	resp, err := srv.Get(ctx, &proto.GetRequest{Key: "key1", MinHlc: barrier.Hlc})
+	if err != nil || resp.Error != "" || !resp.Found {
+		t.Fatalf("expected read at barrier to succeed, got err=%v resp=%v", err, resp)
+	}
+
+	// a barrier beyond anything the node has stored is refused
+	ahead := &proto.HLC{Physical: barrier.Hlc.Physical + int64(time.Second)}
+	resp, err = srv.Get(ctx, &proto.GetRequest{Key: "key1", MinHlc: ahead})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Error == "" || resp.Found {
+		t.Errorf("expected read beyond barrier to be refused, got %v", resp)
+	}
+}
+
+func TestGet_CountsLocalAndQuorumServed(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	localBefore, _ := reader.GetCounterValue(testMetrics.ReadsLocalServed)
+	quorumBefore, _ := reader.GetCounterValue(testMetrics.ReadsQuorumServed)
+
+	// r=1 is answered locally
+	if _, err := srv.Get(context.Background(), &proto.GetRequest{Key: "key1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsLocalServed); v != localBefore+1 {
+		t.Errorf("expected one local-served read, got %v", v-localBefore)
+	}
+
+	// r=2 consults peers, even though none answer
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	if _, err := srv.Get(context.Background(), &proto.GetRequest{Key: "key1"}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable without peers, got %v", err)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsQuorumServed); v != quorumBefore+1 {
+		t.Errorf("expected one quorum-served read, got %v", v-quorumBefore)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsLocalServed); v != localBefore+1 {
+		t.Errorf("expected quorum read not to count as local, got %v", v-localBefore)
+	}
+}
+
+func TestSelectWinner_ExcludeStaleReplicas(t *testing.T) {
+	srv := newTestServer(t)
+
+	values := []replication.ReplicaValue{
+		{PeerAddr: "peer1", Value: []byte("old"), HLC: hlc.HLC{Physical: 100, NodeID: "node2"}},
+		{PeerAddr: "peer2", Value: []byte("new"), HLC: hlc.HLC{Physical: 200, NodeID: "node3"}, IsStale: true},
+	}
+
+	// by default the newest value wins even if stale
+	if winner, _ := srv.selectWinner(values); winner.PeerAddr != "peer2" {
+		t.Errorf("expected stale newest value to win by default, got %s", winner.PeerAddr)
+	}
+
+	srv.SetExcludeStaleReplicas(true)
+	if winner, _ := srv.selectWinner(values); winner.PeerAddr != "peer1" {
+		t.Errorf("expected fresh older value to win, got %s", winner.PeerAddr)
+	}
+
+	// with every replica stale the newest is still chosen
+	values[0].IsStale = true
+	if winner, found := srv.selectWinner(values); !found || winner.PeerAddr != "peer2" {
+		t.Errorf("expected newest value when all are stale, got %s", winner.PeerAddr)
+	}
+}
+
+func TestReplicate_DriftRejectionsQuarantinePeer(t *testing.T) {
+	srv := newTestServer(t)
+	q := replication.NewQuarantine(3, time.Minute, zap.NewNop(), testMetrics)
+	srv.SetQuarantine(q)
+	ctx := context.Background()
+
+	replicate := func(physical int64) {
+		srv.Replicate(ctx, &proto.ReplicateRequest{Key: "k", Value: []byte("v"), SourceNodeId: "node2", Hlc: &proto.HLC{Physical: physical, NodeId: "node2"}})
+	}
+
+	// an hour ahead, well past the 500ms drift limit
+	for i := 0; i < 3; i++ {
+		replicate(time.Now().Add(time.Hour).UnixNano())
+	}
+	if !q.IsQuarantined("node2") {
+		t.Fatal("expected peer with repeated drift rejections to be quarantined")
+	}
+
+	replicate(time.Now().UnixNano())
+	if q.IsQuarantined("node2") {
+		t.Error("expected peer to be re-admitted after an accepted timestamp")
+	}
+}
+
+func TestPut_SuspendedWhileCCSCritical(t *testing.T) {
+	srv := newTestServer(t)
+	ws := adaptive.NewWriteSuspender(0.2, zap.NewNop(), testMetrics)
+	srv.SetWriteSuspender(ws)
+	reader := metrics.NewMetricsReader(testMetrics)
+	ctx := context.Background()
+
+	ws.Update(0.1)
+	if v, _ := reader.GetGaugeValue(testMetrics.WriteSuspended); v != 1 {
+		t.Errorf("expected write suspended gauge 1, got %v", v)
+	}
+
+	_, err := srv.Put(ctx, &proto.PutRequest{Key: "key1", Value: []byte("v")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable while suspended, got %v", err)
+	}
+	if _, found := srv.store.Get("key1"); found {
+		t.Error("expected suspended put not to touch the store")
+	}
+
+	// reads keep working
+	srv.store.Put("key2", []byte("v"), "node1")
+	if resp, err := srv.Get(ctx, &proto.GetRequest{Key: "key2"}); err != nil || !resp.Found {
+		t.Errorf("expected get to be served while suspended, got err=%v resp=%v", err, resp)
+	}
+
+	ws.Update(0.5)
+	if v, _ := reader.GetGaugeValue(testMetrics.WriteSuspended); v != 0 {
+		t.Errorf("expected write suspended gauge 0, got %v", v)
+	}
+	if resp, err := srv.Put(ctx, &proto.PutRequest{Key: "key1", Value: []byte("v")}); err != nil || !resp.Success {
+		t.Errorf("expected put to succeed after ccs recovered, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestUnaryMetricsInterceptor_RecordsEveryMethod(t *testing.T) {
+	srv := newTestServer(t)
+	reader := metrics.NewMetricsReader(testMetrics)
+	intercept := UnaryMetricsInterceptor(testMetrics)
+	ctx := context.Background()
+
+	okBefore, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("HealthCheck", "OK"))
+	latencyBefore, _ := reader.GetHistogramStats(testMetrics.GRPCLatency.WithLabelValues("HealthCheck"))
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/acp.ACPService/HealthCheck"}
+	_, err := intercept(ctx, &proto.HealthRequest{SourceNodeId: "test"}, info, func(ctx context.Context, req any) (any, error) {
+		return srv.HealthCheck(ctx, req.(*proto.HealthRequest))
+	})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+
+	if after, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("HealthCheck", "OK")); after != okBefore+1 {
+		t.Errorf("expected one OK HealthCheck request, got %v", after-okBefore)
+	}
+	if after, _ := reader.GetHistogramStats(testMetrics.GRPCLatency.WithLabelValues("HealthCheck")); after.Count != latencyBefore.Count+1 {
+		t.Errorf("expected one HealthCheck latency observation, got %d", after.Count-latencyBefore.Count)
+	}
+
+	// handler errors are labelled with their status code
+	unavailableBefore, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("Put", "Unavailable"))
+	intercept(ctx, &proto.PutRequest{}, &grpc.UnaryServerInfo{FullMethod: "/acp.ACPService/Put"}, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.Unavailable, "writes suspended")
+	})
+	if after, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("Put", "Unavailable")); after != unavailableBefore+1 {
+		t.Errorf("expected one Unavailable Put request, got %v", after-unavailableBefore)
+	}
+}
+
+func TestPut_CoalescesRapidWritesToOneReplication(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetWriteCoalescing(50 * time.Millisecond)
+
+	var (
+		mu         sync.Mutex
+		replicated [][]byte
+	)
+	srv.coalescer.replicate = func(ctx context.Context, key string, value []byte, contentType string, version, timestamp int64, hlcTimestamp hlc.HLC, requiredAcks int) (int, []replication.ReplicateResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		replicated = append(replicated, value)
+		return requiredAcks, nil, nil
+	}
+
+	const n = 10
+	resps := make([]*proto.PutResponse, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		value := fmt.Sprintf("v%d", i)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := srv.Put(context.Background(), &proto.PutRequest{Key: "hot", Value: []byte(value)})
+			if err != nil {
+				t.Errorf("put %d failed: %v", i, err)
+			}
+			resps[i] = r
+		}(i)
+
+		// wait for the local write so each put gets a later hlc than the last
+		for {
+			if v, _ := srv.store.Get("hot"); string(v.Value) == value {
+				break
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+	wg.Wait()
+
+	if len(replicated) != 1 {
+		t.Fatalf("expected %d rapid writes to replicate once, got %d", n, len(replicated))
+	}
+	if string(replicated[0]) != "v9" {
+		t.Errorf("expected the final value to be replicated, got %s", replicated[0])
+	}
+	value, _ := srv.store.Get("hot")
+	if string(value.Value) != "v9" {
+		t.Errorf("expected the final value to win locally, got %s", value.Value)
+	}
+	for i, r := range resps {
+		if r == nil || !r.Success || hlc.FromProto(r.Hlc) != value.HLC {
+			t.Errorf("expected put %d to be acked with the coalesced write's hlc, got %v", i, r)
+		}
+	}
+}
+
+func TestHealthCheck_ReadySeparateFromHealthy(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	resp, err := srv.HealthCheck(ctx, &proto.HealthRequest{SourceNodeId: "test"})
+	if err != nil || !resp.Healthy || !resp.Ready {
+		t.Fatalf("expected single node at w=1 to be healthy and ready, got err=%v resp=%v", err, resp)
+	}
+
+	// w=2 with no connected peers can't be met
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 2, W: 2}
+	resp, _ = srv.HealthCheck(ctx, &proto.HealthRequest{SourceNodeId: "test"})
+	if !resp.Healthy || resp.Ready {
+		t.Errorf("expected healthy but not ready with too few peers, got healthy=%v ready=%v", resp.Healthy, resp.Ready)
+	}
+}
+
+func TestHealthCheck_ReadOnlyClock(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetHealthCheckClockReadOnly(true)
+	ctx := context.Background()
+
+	// ahead of local time but inside the 500ms drift limit
+	remote := &proto.HLC{Physical: time.Now().Add(200 * time.Millisecond).UnixNano(), NodeId: "node2"}
+
+	if _, err := srv.HealthCheck(ctx, &proto.HealthRequest{SourceNodeId: "node2", Hlc: remote}); err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if got := srv.hlcClock.Now().Physical; got >= remote.Physical {
+		t.Errorf("expected read-only health check to leave the clock behind the peer, got %d >= %d", got, remote.Physical)
+	}
+
+	if _, err := srv.Replicate(ctx, &proto.ReplicateRequest{Key: "k", Value: []byte("v"), SourceNodeId: "node2", Hlc: remote}); err != nil {
+		t.Fatalf("replicate failed: %v", err)
+	}
+	before := srv.hlcClock.Now()
+	if before.Physical < remote.Physical {
+		t.Errorf("expected replication to advance the clock to the peer, got %d < %d", before.Physical, remote.Physical)
+	}
+
+	// the clock is now ahead of the wall clock, so only the logical counter
+	// moves and any tick from a health check would show up in it
+	for i := 0; i < 5; i++ {
+		resp, err := srv.HealthCheck(ctx, &proto.HealthRequest{SourceNodeId: "node2", Hlc: remote})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		if got := hlc.FromProto(resp.Hlc); !got.Equal(before) {
+			t.Errorf("expected health check to report %v, got %v", before, got)
+		}
+	}
+	after := srv.hlcClock.Now()
+	if after.Physical != before.Physical || after.Logical != before.Logical+1 {
+		t.Errorf("expected read-only health checks to leave the clock at %v, next timestamp was %v", before, after)
+	}
+}
+
+func TestStrongConsistency_IgnoresRelaxedQuorum(t *testing.T) {
+	srv := newTestServer(t)
+	// relaxed to r=1 w=1 on a 3 node cluster whose peers are unreachable
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 1, W: 1}
+
+	if resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: "lease", Value: []byte("v1")}); err != nil || !resp.Success {
+		t.Fatalf("expected relaxed put to succeed, got err=%v resp=%v", err, resp)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func(strong bool) {
+			defer wg.Done()
+
+			put, putErr := srv.Put(context.Background(), &proto.PutRequest{Key: "lease", Value: []byte("v2"), StrongConsistency: strong})
+			get, getErr := srv.Get(context.Background(), &proto.GetRequest{Key: "lease", StrongConsistency: strong})
+
+			if strong {
+				// w=n and r=n can't be met without the peers
+				if status.Code(putErr) != codes.Unavailable {
+					t.Errorf("expected strong put to fail without every replica, got %v", putErr)
+				}
+				if status.Code(getErr) != codes.Unavailable {
+					t.Errorf("expected strong get to fail without every replica, got %v", getErr)
+				}
+			} else {
+				if putErr != nil || getErr != nil {
+					t.Errorf("expected relaxed put and get to succeed, got %v, %v", putErr, getErr)
+					return
+				}
+				if !put.Success {
+					t.Errorf("expected relaxed put to succeed, got %s", put.Error)
+				}
+				if !get.Found || get.Error != "" {
+					t.Errorf("expected relaxed get to succeed, got %v", get)
+				}
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
+
+func TestPeerBootstrap_Writes(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetWriteLogLimits(0, 4)
+
+	for _, kv := range []struct{ key, value string }{{"a", "1"}, {"b", "large value"}} {
+		if resp, err := srv.Put(context.Background(), &proto.PutRequest{Key: kv.key, Value: []byte(kv.value), ContentType: "text/plain"}); err != nil || !resp.Success {
+			t.Fatalf("put %s failed: err=%v resp=%v", kv.key, err, resp)
+		}
+	}
+
+	// elided values are read back from the store
+	for name, reqs := range map[string][]*proto.ReplicateRequest{"log": srv.logWrites(), "full": srv.exportWrites()} {
+		values := make(map[string]string)
+		for _, req := range reqs {
+			if !req.Bulk || req.SourceNodeId != "node1" || req.Hlc == nil || req.ContentType != "text/plain" {
+				t.Errorf("%s: unexpected request %v", name, req)
+			}
+			values[req.Key] = string(req.Value)
+		}
+		if values["a"] != "1" || values["b"] != "large value" || len(values) != 2 {
+			t.Errorf("%s: expected both writes, got %v", name, values)
+		}
+	}
+}
+
+// single node server reachable over tcp, for use as another server's peer
+func newPeerServer(t *testing.T, nodeID string) (*Server, string) {
+	t.Helper()
+
+	cfg := &config.Config{NodeID: nodeID, N: 1, R: 1, W: 1}
+	coordinator, err := replication.NewCoordinator(nodeID, []string{}, zap.NewNop(), testMetrics, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create coordinator: %v", err)
+	}
+	t.Cleanup(func() { coordinator.Close() })
+
+	srv := NewServer(nodeID, storage.NewStore(), coordinator, cfg, zap.NewNop(), testMetrics,
+		hlc.NewClock(nodeID, 500*time.Millisecond), staleness.NewDetector(3*time.Second, testMetrics), nil)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	proto.RegisterACPServiceServer(grpcServer, srv)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return srv, lis.Addr().String()
+}
+
+func TestGet_AgreementFastPath(t *testing.T) {
+	peer1, addr1 := newPeerServer(t, "node2")
+	peer2, addr2 := newPeerServer(t, "node3")
+
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 3, W: 1}
+	srv.coordinator.UpdatePeers([]string{addr1, addr2})
+	srv.SetAgreementFastPath(true)
+	reader := metrics.NewMetricsReader(testMetrics)
+
+	ts := hlc.HLC{Physical: time.Now().UnixNano(), NodeID: "node1"}
+	for _, s := range []*Server{srv, peer1, peer2} {
+		s.store.PutWithHLC("key1", []byte("v1"), "node1", ts)
+	}
+
+	// every replica holds the local version
+	before, _ := reader.GetCounterValue(testMetrics.ReadsAgreementFastPath)
+	resp, err := srv.Get(context.Background(), &proto.GetRequest{Key: "key1"})
+	if err != nil || !resp.Found || string(resp.Value) != "v1" {
+		t.Fatalf("expected v1, got err=%v resp=%v", err, resp)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsAgreementFastPath); v != before+1 {
+		t.Errorf("expected the read to take the fast path, counter moved by %v", v-before)
+	}
+
+	// one replica has a newer value, the read merges and returns it
+	newer := hlc.HLC{Physical: ts.Physical + 1, NodeID: "node3"}
+	peer2.store.PutWithHLC("key1", []byte("v2"), "node3", newer)
+
+	resp, err = srv.Get(context.Background(), &proto.GetRequest{Key: "key1"})
+	if err != nil || !resp.Found || string(resp.Value) != "v2" {
+		t.Fatalf("expected the newer v2 after merging, got err=%v resp=%v", err, resp)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsAgreementFastPath); v != before+1 {
+		t.Error("expected a disagreeing read not to take the fast path")
+	}
+
+	// a replica without the key is a disagreement too
+	peer2.store.Clear()
+	if _, err := srv.Get(context.Background(), &proto.GetRequest{Key: "key1"}); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.ReadsAgreementFastPath); v != before+1 {
+		t.Error("expected a read with a missing replica not to take the fast path")
+	}
+}
+
+func TestGetSiblings_MergesConcurrentReplicas(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 2, R: 2, W: 1}
+	srv.coordinator.UpdatePeers([]string{addr})
+
+	// three writers generate the same hlc; node3's write reaches only the
+	// local store, node2's only the peer
+	physical := time.Now().UnixNano()
+	srv.store.PutWithHLC("key1", []byte("a"), "node1", hlc.HLC{Physical: physical, NodeID: "node1"})
+	srv.store.PutIfNewer("key1", []byte("c"), "node3", hlc.HLC{Physical: physical, NodeID: "node3"})
+	peer.store.PutWithHLC("key1", []byte("b"), "node2", hlc.HLC{Physical: physical, NodeID: "node2"})
+
+	resp, err := srv.GetSiblings(context.Background(), &proto.GetRequest{Key: "key1"})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("get siblings failed: err=%v resp=%v", err, resp)
+	}
+	var got []string
+	for _, sib := range resp.Siblings {
+		got = append(got, string(sib.Value))
+	}
+	if len(got) != 3 || got[0] != "c" || got[1] != "b" || got[2] != "a" {
+		t.Fatalf("expected siblings [c b a], got %v", got)
+	}
+
+	// a subsequent write is newer than every sibling
+	if _, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("d")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	resp, err = srv.GetSiblings(context.Background(), &proto.GetRequest{Key: "key1"})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("get siblings failed: err=%v resp=%v", err, resp)
+	}
+	if len(resp.Siblings) != 1 || string(resp.Siblings[0].Value) != "d" {
+		t.Errorf("expected the write to collapse the siblings to d, got %v", resp.Siblings)
+	}
+
+	// siblings keep the content type they were written with
+	if _, err := srv.Put(context.Background(), &proto.PutRequest{Key: "key1", Value: []byte("{}"), ContentType: "application/json"}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	resp, err = srv.GetSiblings(context.Background(), &proto.GetRequest{Key: "key1"})
+	if err != nil || len(resp.Siblings) != 1 || resp.Siblings[0].ContentType != "application/json" {
+		t.Errorf("expected a json sibling, got err=%v resp=%v", err, resp)
+	}
+}
+
+// fixed key placement for migration tests
+type staticPlacement map[string][]string
+
+func (p staticPlacement) Owners(key string) []string {
+	return p[key]
+}
+
+func TestMigratePlacement_KeysEndOnNewOwners(t *testing.T) {
+	peer1, addr1 := newPeerServer(t, "node2")
+	peer2, addr2 := newPeerServer(t, "node3")
+	srv := newTestServer(t)
+	srv.coordinator.UpdatePeers([]string{addr1, addr2})
+	ctx := context.Background()
+
+	// a single node held everything before the peers joined
+	for _, key := range []string{"a", "b", "c", "d"} {
+		srv.store.Put(key, []byte(key), "node1")
+	}
+	to := staticPlacement{
+		"a": {"self"},
+		"b": {addr1},
+		"c": {addr1, addr2},
+		"d": {"self", addr2},
+	}
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	if err := srv.MigratePlacement(ctx, partition.AllNodes{"self"}, to, "self", 0, checkpoint); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+
+	nodes := map[string]*Server{"self": srv, addr1: peer1, addr2: peer2}
+	for key, owners := range to {
+		for addr, node := range nodes {
+			_, found := node.store.Peek(key)
+			if want := slices.Contains(owners, addr); found != want {
+				t.Errorf("key %s on %s: expected present=%v, got %v", key, addr, want, found)
+			}
+		}
+	}
+
+	// a rerun resumes after the last migrated key and visits nothing
+	srv.store.Put("a", []byte("a2"), "node1")
+	if err := srv.MigratePlacement(ctx, partition.AllNodes{"self"}, staticPlacement{}, "self", 0, checkpoint); err != nil {
+		t.Fatalf("resumed migration failed: %v", err)
+	}
+	if _, found := srv.store.Peek("a"); !found {
+		t.Error("expected the resumed migration to skip keys before the checkpoint")
+	}
+}
+
+func TestMigratePlacement_OldOwnerMissingLatestWrite(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.coordinator.UpdatePeers([]string{addr})
+	ctx := context.Background()
+
+	// both nodes were replicas, but with W<N the peer missed some writes
+	now := time.Now().UnixNano()
+	older := hlc.HLC{Physical: now, NodeID: "node1"}
+	newer := hlc.HLC{Physical: now + 10, NodeID: "node1"}
+	srv.store.PutWithHLC("missing", []byte("v"), "node1", newer)
+	srv.store.PutWithHLC("stale", []byte("v2"), "node1", newer)
+	peer.store.PutWithHLC("stale", []byte("v1"), "node1", older)
+	srv.store.PutWithHLC("current", []byte("v"), "node1", newer)
+	peer.store.PutWithHLC("current", []byte("v"), "node1", newer)
+
+	to := staticPlacement{"missing": {addr}, "stale": {addr}, "current": {"self", addr}}
+	if err := srv.MigratePlacement(ctx, partition.AllNodes{"self", addr}, to, "self", 0, ""); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+
+	for key, want := range map[string]string{"missing": "v", "stale": "v2", "current": "v"} {
+		if vv, found := peer.store.Peek(key); !found || string(vv.Value) != want {
+			t.Errorf("key %s: expected the peer to hold %q, got %q (found=%v)", key, want, vv.Value, found)
+		}
+	}
+	for key, want := range map[string]bool{"missing": false, "stale": false, "current": true} {
+		if _, found := srv.store.Peek(key); found != want {
+			t.Errorf("key %s: expected present here=%v, got %v", key, want, found)
+		}
+	}
+}
+
+func TestStartupBarrier_HoldsClientsUntilPeersConnect(t *testing.T) {
+	_, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.SetStartupBarrier(1, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	released := make(chan struct{})
+	go func() {
+		srv.StartStartupBarrier(ctx)
+		close(released)
+	}()
+
+	_, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before peers connect, got %v", err)
+	}
+	if _, err := srv.Get(ctx, &proto.GetRequest{Key: "k"}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before peers connect, got %v", err)
+	}
+	// peer traffic is still served
+	if _, err := srv.GetLocal(ctx, &proto.GetRequest{Key: "k"}); err != nil {
+		t.Fatalf("expected GetLocal to be served, got %v", err)
+	}
+
+	srv.coordinator.UpdatePeers([]string{addr})
+	select {
+	case <-released:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the barrier to lift once a peer connected")
+	}
+	if resp, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v")}); err != nil || !resp.Success {
+		t.Errorf("expected puts after the barrier, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestStartupBarrier_TimesOut(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetStartupBarrier(1, 50*time.Millisecond)
+
+	start := time.Now()
+	srv.StartStartupBarrier(context.Background())
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the barrier to wait for its timeout, lifted after %v", elapsed)
+	}
+	if _, err := srv.Get(context.Background(), &proto.GetRequest{Key: "k"}); err != nil {
+		t.Errorf("expected gets after the timeout, got %v", err)
+	}
+}
+
+// cumulative bucket counts of h by upper bound
+func bucketCounts(t *testing.T, h prometheus.Histogram) map[float64]uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	counts := make(map[float64]uint64)
+	for _, b := range m.GetHistogram().GetBucket() {
+		counts[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+	return counts
+}
+
+func TestEffectiveQuorumHistograms(t *testing.T) {
+	_, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 2, R: 1, W: 1}
+	srv.coordinator.UpdatePeers([]string{addr})
+	ctx := context.Background()
+
+	beforeR := bucketCounts(t, testMetrics.EffectiveR)
+	beforeW := bucketCounts(t, testMetrics.EffectiveW)
+
+	// one op at the adaptive quorum and one strong override (N=2) of each
+	for _, strong := range []bool{false, true} {
+		if resp, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v"), StrongConsistency: strong}); err != nil || !resp.Success {
+			t.Fatalf("put failed: err=%v resp=%v", err, resp)
+		}
+		if resp, err := srv.Get(ctx, &proto.GetRequest{Key: "k", StrongConsistency: strong}); err != nil || resp.Error != "" {
+			t.Fatalf("get failed: err=%v resp=%v", err, resp)
+		}
+	}
+
+	afterR := bucketCounts(t, testMetrics.EffectiveR)
+	afterW := bucketCounts(t, testMetrics.EffectiveW)
+	for _, h := range []struct {
+		name          string
+		before, after map[float64]uint64
+	}{{"r", beforeR, afterR}, {"w", beforeW, afterW}} {
+		if got := h.after[1] - h.before[1]; got != 1 {
+			t.Errorf("expected one op at %s=1, got %d", h.name, got)
+		}
+		if got := h.after[2] - h.before[2]; got != 2 {
+			t.Errorf("expected two ops at %s<=2, got %d", h.name, got)
+		}
+	}
+}
+
+func TestGet_DegradedBelowReadQuorum(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 3, R: 3, W: 1}
+	srv.coordinator.UpdatePeers([]string{addr})
+	ctx := context.Background()
+
+	// only self and one peer can answer an R=3 read, the peer is newer
+	if _, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("old")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if _, err := peer.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("new")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	resp, err := srv.Get(ctx, &proto.GetRequest{Key: "k"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the sub-R read to fail, got err=%v resp=%v", err, resp)
+	}
+
+	resp, err = srv.Get(ctx, &proto.GetRequest{Key: "k", AllowDegraded: true})
+	if err != nil || resp.Error != "" || !resp.Degraded || string(resp.Value) != "new" {
+		t.Fatalf("expected the peer's value flagged degraded, got err=%v resp=%v", err, resp)
+	}
+
+	// enabled for every read, except those that must see every replica
+	srv.SetDegradedReads(true)
+	if resp, _ := srv.Get(ctx, &proto.GetRequest{Key: "k"}); !resp.Degraded || string(resp.Value) != "new" {
+		t.Errorf("expected a degraded read, got %v", resp)
+	}
+	if _, err := srv.Get(ctx, &proto.GetRequest{Key: "k", StrongConsistency: true}); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected a strong read to fail, got %v", err)
+	}
+}
+
+func TestGet_PinnedToPeer(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.coordinator.UpdatePeers([]string{addr})
+	ctx := context.Background()
+
+	// the two nodes disagree, neither has replicated to the other
+	if _, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("local")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if _, err := peer.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("peer")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	req := &proto.GetRequest{Key: "k", FromPeer: addr}
+	resp, err := srv.Get(ctx, req)
+	if err != nil || resp.Error == "" {
+		t.Fatalf("expected pinned reads to be rejected when disabled, got err=%v resp=%v", err, resp)
+	}
+
+	srv.SetPinnedReads(true)
+	resp, err = srv.Get(ctx, req)
+	if err != nil || !resp.Found || string(resp.Value) != "peer" || resp.NodeId != "node2" {
+		t.Fatalf("expected node2's value, got err=%v resp=%v", err, resp)
+	}
+
+	resp, err = srv.Get(ctx, &proto.GetRequest{Key: "k", FromPeer: "127.0.0.1:1"})
+	if err != nil || resp.Error == "" {
+		t.Errorf("expected an error for an unknown peer, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestSnapshotRead_IgnoresWritesAfterBarrier(t *testing.T) {
+	srv := newTestServer(t)
+	srv.store.EnableVersionHistory(4)
+	ctx := context.Background()
+
+	put := func(key, value string) *proto.PutResponse {
+		t.Helper()
+		resp, err := srv.Put(ctx, &proto.PutRequest{Key: key, Value: []byte(value)})
+		if err != nil || !resp.Success {
+			t.Fatalf("put failed: err=%v resp=%v", err, resp)
+		}
+		return resp
+	}
+	put("k1", "old")
+	barrier := put("k2", "old").Hlc
+	put("k1", "new")
+	put("k3", "new")
+
+	resp, err := srv.SnapshotRead(ctx, &proto.SnapshotReadRequest{Keys: []string{"k1", "k2", "k3"}, AsOf: barrier})
+	if err != nil || resp.Error != "" || len(resp.Values) != 3 {
+		t.Fatalf("snapshot read failed: err=%v resp=%v", err, resp)
+	}
+	if v := resp.Values[0]; !v.Found || string(v.Value) != "old" {
+		t.Errorf("expected k1 as of the barrier, got %v", v)
+	}
+	if v := resp.Values[1]; !v.Found || string(v.Value) != "old" {
+		t.Errorf("expected k2, got %v", v)
+	}
+	if v := resp.Values[2]; v.Found {
+		t.Errorf("expected k3 written after the barrier to be absent, got %v", v)
+	}
+
+	// without a barrier the node reads as of now
+	resp, err = srv.SnapshotRead(ctx, &proto.SnapshotReadRequest{Keys: []string{"k1"}})
+	if err != nil || len(resp.Values) != 1 || string(resp.Values[0].Value) != "new" {
+		t.Errorf("expected the current k1, got err=%v resp=%v", err, resp)
+	}
+
+	// without history an overwritten key can't be answered
+	srv.store.EnableVersionHistory(0)
+	put("k2", "new")
+	resp, err = srv.SnapshotRead(ctx, &proto.SnapshotReadRequest{Keys: []string{"k2"}, AsOf: barrier})
+	if err != nil || resp.Error == "" || len(resp.Values) != 0 {
+		t.Errorf("expected an error for a key overwritten past its history, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestContentType_RoundTripsThroughReplication(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 2, R: 2, W: 2}
+	srv.coordinator.UpdatePeers([]string{addr})
+
+	ctx := context.Background()
+	put, err := srv.Put(ctx, &proto.PutRequest{Key: "doc", Value: []byte(`{"a":1}`), ContentType: "application/json"})
+	if err != nil || !put.Success {
+		t.Fatalf("put failed: err=%v resp=%v", err, put)
+	}
+
+	// the replica stored it and the quorum read returns it
+	local, err := peer.GetLocal(ctx, &proto.GetRequest{Key: "doc"})
+	if err != nil || local.ContentType != "application/json" {
+		t.Errorf("expected the replica to store the content type, got err=%v resp=%v", err, local)
+	}
+	resp, err := srv.Get(ctx, &proto.GetRequest{Key: "doc"})
+	if err != nil || resp.ContentType != "application/json" {
+		t.Errorf("expected the read to return the content type, got err=%v resp=%v", err, resp)
+	}
+
+	// a newer untyped write on the peer wins the merge without one
+	if _, err := peer.Put(ctx, &proto.PutRequest{Key: "doc", Value: []byte("raw")}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	resp, err = srv.Get(ctx, &proto.GetRequest{Key: "doc"})
+	if err != nil || string(resp.Value) != "raw" || resp.ContentType != "" {
+		t.Errorf("expected raw without a content type, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestRecoveryInterceptor_ReturnsInternal(t *testing.T) {
+	reader := metrics.NewMetricsReader(testMetrics)
+	ctx := context.Background()
+
+	// recovery inside metrics, as wired in main
+	metricsIntercept := UnaryMetricsInterceptor(testMetrics)
+	recoverIntercept := UnaryRecoveryInterceptor(zap.NewNop(), testMetrics)
+	info := &grpc.UnaryServerInfo{FullMethod: "/acp.ACPService/Get"}
+	panicking := func(ctx context.Context, req any) (any, error) {
+		var vv *storage.VersionedValue
+		return vv.Value, nil // nil pointer dereference
+	}
+
+	recoveredBefore, _ := reader.GetCounterValue(testMetrics.PanicsRecovered.WithLabelValues("Get"))
+	internalBefore, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("Get", "Internal"))
+
+	_, err := metricsIntercept(ctx, &proto.GetRequest{Key: "key1"}, info, func(ctx context.Context, req any) (any, error) {
+		return recoverIntercept(ctx, req, info, panicking)
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.PanicsRecovered.WithLabelValues("Get")); v != recoveredBefore+1 {
+		t.Errorf("expected one recovered panic, got %v", v-recoveredBefore)
+	}
+	if v, _ := reader.GetCounterValue(testMetrics.GRPCRequests.WithLabelValues("Get", "Internal")); v != internalBefore+1 {
+		t.Errorf("expected the panic to be counted as an Internal request, got %v", v-internalBefore)
+	}
+
+	// streams are recovered the same way
+	streamIntercept := StreamRecoveryInterceptor(zap.NewNop(), testMetrics)
+	err = streamIntercept(nil, nil, &grpc.StreamServerInfo{FullMethod: "/acp.ACPService/Watch"}, func(any, grpc.ServerStream) error {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal from a panicking stream, got %v", err)
+	}
+}
+
+func TestMissingKeys_ReportsKeysBehindPeer(t *testing.T) {
+	peer, addr := newPeerServer(t, "node2")
+	srv := newTestServer(t)
+	srv.coordinator.UpdatePeers([]string{addr})
+	ctx := context.Background()
+
+	now := time.Now().UnixNano()
+	older := hlc.HLC{Physical: now, NodeID: "node1"}
+	newer := hlc.HLC{Physical: now + 10, NodeID: "node2"}
+	peer.store.PutWithHLC("missing", []byte("v"), "node2", newer)
+	peer.store.PutWithHLC("stale", []byte("v2"), "node2", newer)
+	srv.store.PutWithHLC("stale", []byte("v1"), "node1", older)
+	peer.store.PutWithHLC("same", []byte("v"), "node2", newer)
+	srv.store.PutWithHLC("same", []byte("v"), "node2", newer)
+	peer.store.PutWithHLC("ahead", []byte("v1"), "node2", older)
+	srv.store.PutWithHLC("ahead", []byte("v2"), "node1", newer)
+	srv.store.PutWithHLC("local-only", []byte("v"), "node1", older)
+
+	resp, err := srv.MissingKeys(ctx, &proto.MissingKeysRequest{Peer: addr})
+	if err != nil || resp.Error == "" {
+		t.Fatalf("expected an error while the peer rejects ListKeys, got err=%v resp=%v", err, resp)
+	}
+
+	peer.SetListKeysEnabled(true)
+	resp, err = srv.MissingKeys(ctx, &proto.MissingKeysRequest{Peer: addr})
+	if err != nil || resp.Error != "" {
+		t.Fatalf("missing keys failed: err=%v resp=%v", err, resp)
+	}
+	if resp.KeysCompared != 4 || len(resp.Keys) != 2 {
+		t.Fatalf("expected 2 of 4 keys behind the peer, got %v", resp)
+	}
+	if k := resp.Keys[0]; k.Key != "missing" || k.LocalHlc != nil {
+		t.Errorf("expected missing key without a local hlc, got %v", k)
+	}
+	if k := resp.Keys[1]; k.Key != "stale" || k.LocalHlc.GetPhysical() != older.Physical || k.PeerHlc.GetPhysical() != newer.Physical {
+		t.Errorf("expected stale key with both hlcs, got %v", k)
+	}
+
+	// a diff never repairs
+	if vv, _ := srv.store.Get("stale"); string(vv.Value) != "v1" {
+		t.Errorf("expected the stale value untouched, got %q", vv.Value)
+	}
+}
+
+func TestUpdatePeers_ShardingKeepsQuorumAmongOwners(t *testing.T) {
+	srv := newTestServer(t)
+	quorum := adaptive.NewAdaptiveQuorum(2, 2, 3, 1, 3, 1, 3, zap.NewNop(), testMetrics)
+	srv.quorumProvider = quorum
+	srv.SetShardReplicas(3)
+
+	// r+w=4 does not intersect over 5 nodes, but does over 3 owners
+	peers := []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3", "127.0.0.1:4"}
+	resp, err := srv.UpdatePeers(context.Background(), &proto.UpdatePeersRequest{Peers: peers})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected the peer update to be accepted, got err=%v resp=%v", err, resp)
+	}
+	if quorum.GetN() != 3 {
+		t.Errorf("expected n to stay at SHARD_REPLICAS=3, got %d", quorum.GetN())
+	}
+}
+
+func TestSetQuorum(t *testing.T) {
+	srv := newTestServer(t)
+
+	// static quorum rejects manual changes
+	resp, err := srv.SetQuorum(context.Background(), &proto.SetQuorumRequest{R: 1, W: 1})
+	if err != nil || resp.Success {
+		t.Fatalf("expected static quorum to reject the change, got err=%v resp=%v", err, resp)
+	}
+
+	quorum := adaptive.NewAdaptiveQuorum(2, 2, 3, 1, 3, 1, 3, zap.NewNop(), testMetrics)
+	quorum.SetManualHold(time.Minute)
+	srv.quorumProvider = quorum
+
+	resp, err = srv.SetQuorum(context.Background(), &proto.SetQuorumRequest{R: 1, W: 3, Reason: "maintenance"})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected the change to be accepted, got err=%v resp=%v", err, resp)
+	}
+	if quorum.GetR() != 1 || quorum.GetW() != 3 {
+		t.Errorf("expected r=1 w=3, got r=%d w=%d", quorum.GetR(), quorum.GetW())
+	}
+	if !quorum.IsHeld() {
+		t.Error("expected the manual change to hold off the adjuster")
+	}
+	if err := quorum.SetQuorum(2, 2, "adjuster"); err == nil {
+		t.Error("expected automatic adjustment to be rejected during the hold")
+	}
+
+	// quorums that do not intersect are rejected
+	resp, err = srv.SetQuorum(context.Background(), &proto.SetQuorumRequest{R: 1, W: 1})
+	if err != nil || resp.Success {
+		t.Errorf("expected r+w<=n to be rejected, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestPut_FailsUntilPeersConnect(t *testing.T) {
+	srv := newTestServer(t)
+	srv.quorumProvider = &config.Config{NodeID: "node1", N: 2, R: 2, W: 2}
+	ctx := context.Background()
+
+	// right after startup no peer is connected, only the self ack counts
+	_, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v1")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before peers connect, got %v", err)
+	}
+	if _, err := srv.Get(ctx, &proto.GetRequest{Key: "k"}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable read before peers connect, got %v", err)
+	}
+
+	_, addr := newPeerServer(t, "node2")
+	srv.coordinator.UpdatePeers([]string{addr})
+
+	if resp, err := srv.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v2")}); err != nil || !resp.Success {
+		t.Fatalf("expected put to succeed once the peer connected, got err=%v resp=%v", err, resp)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// writehook is notified of every write applied to the local store, both
+// client puts and replicated writes. puts are reported before replication,
+// so a put that later misses quorum has still been seen. implementations
+// run on the write path and must not block; wrap slow sinks in an
+// AsyncWriteHook
+type WriteHook interface {
+	OnCommit(key string, value []byte, timestamp hlc.HLC, nodeID string)
+}
+
+// nopwritehook ignores every commit, used when no hook is registered
+type NopWriteHook struct{}
+
+func (NopWriteHook) OnCommit(string, []byte, hlc.HLC, string) {}
+
+// committed write queued for an async hook
+type commit struct {
+	key       string
+	value     []byte
+	timestamp hlc.HLC
+	nodeID    string
+}
+
+// asyncwritehook buffers commits and hands them to the wrapped hook on a
+// background goroutine. when the buffer is full commits are dropped and
+// counted rather than blocking the write
+type AsyncWriteHook struct {
+	next    WriteHook
+	queue   chan commit
+	done    chan struct{}
+	once    sync.Once
+	logger  *zap.Logger
+	metrics *metrics.Metrics
+}
+
+// newasyncwritehook starts delivering to next with room for buffer pending commits
+func NewAsyncWriteHook(next WriteHook, buffer int, logger *zap.Logger, m *metrics.Metrics) *AsyncWriteHook {
+	h := &AsyncWriteHook{
+		next:    next,
+		queue:   make(chan commit, buffer),
+		done:    make(chan struct{}),
+		logger:  logger,
+		metrics: m,
+	}
+	go h.run()
+	return h
+}
+
+func (h *AsyncWriteHook) OnCommit(key string, value []byte, timestamp hlc.HLC, nodeID string) {
+	select {
+	case h.queue <- commit{key: key, value: value, timestamp: timestamp, nodeID: nodeID}:
+	default:
+		h.metrics.WriteHookDropped.Inc()
+		h.logger.Debug("write hook buffer full, dropping commit", zap.String("key", key))
+	}
+}
+
+func (h *AsyncWriteHook) run() {
+	defer close(h.done)
+	for c := range h.queue {
+		h.next.OnCommit(c.key, c.value, c.timestamp, c.nodeID)
+	}
+}
+
+// close stops accepting commits and waits for buffered ones to be delivered.
+// OnCommit must not be called after close
+func (h *AsyncWriteHook) Close() {
+	h.once.Do(func() { close(h.queue) })
+	<-h.done
+}
@@ -2,6 +2,7 @@ package staleness
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/rachitkumar205/acp-kv/internal/hlc"
@@ -13,14 +14,39 @@ import (
 type Detector struct {
 	maxAge  time.Duration // maximum age before data is considered stale
 	metrics *metrics.Metrics
+
+	// age above which strict reads are rejected, maxAge unless loosened
+	// by SetRejectAge
+	rejectAge atomic.Int64
 }
 
 // create new staleness detector
 func NewDetector(maxAge time.Duration, m *metrics.Metrics) *Detector {
-	return &Detector{
+	d := &Detector{
 		maxAge:  maxAge,
 		metrics: m,
 	}
+	d.SetRejectAge(maxAge)
+	return d
+}
+
+// maxage returns the configured staleness bound
+func (d *Detector) MaxAge() time.Duration {
+	return d.maxAge
+}
+
+// rejectage returns the age above which CheckStrict rejects reads
+func (d *Detector) RejectAge() time.Duration {
+	return time.Duration(d.rejectAge.Load())
+}
+
+// setrejectage loosens the age at which CheckStrict rejects reads. values
+// between maxAge and the new bound still count as stale, they are just
+// served. the bound never drops below maxAge
+func (d *Detector) SetRejectAge(age time.Duration) {
+	age = max(age, d.maxAge)
+	d.rejectAge.Store(int64(age))
+	d.metrics.StalenessRejectAge.Set(age.Seconds())
 }
 
 // check if value is stale
@@ -38,13 +64,14 @@ func (d *Detector) Age(timestamp hlc.HLC, now int64) time.Duration {
 func (d *Detector) CheckStrict(value storage.VersionedValue) error {
 	now := time.Now().UnixNano()
 	age := value.HLC.Age(now)
+	d.metrics.ObserveSampled(d.metrics.DataAge, age.Seconds())
 
-	if age > d.maxAge {
+	if rejectAge := d.RejectAge(); age > rejectAge {
 		d.metrics.StaleReadsRejected.Inc()
 		d.metrics.StalenessViolations.Inc()
 
 		return fmt.Errorf("staleness bound exceeded: data age %v > max %v",
-			age, d.maxAge)
+			age, rejectAge)
 	}
 
 	return nil
@@ -84,6 +84,30 @@ func TestDetector_CheckStrict(t *testing.T) {
 	}
 }
 
+func TestDetector_LoosenedRejectAge(t *testing.T) {
+	detector := NewDetector(3*time.Second, testMetrics)
+	now := time.Now().UnixNano()
+	old := storage.VersionedValue{HLC: hlc.HLC{Physical: now - int64(5*time.Second), NodeID: "node1"}}
+
+	detector.SetRejectAge(10 * time.Second)
+	if err := detector.CheckStrict(old); err != nil {
+		t.Errorf("expected 5s old value to be served under a 10s reject age, got %v", err)
+	}
+	// still reported as past the configured bound
+	if !detector.IsStale(old.HLC, time.Now().UnixNano()) {
+		t.Error("expected value to remain flagged stale")
+	}
+
+	// never tighter than maxAge
+	detector.SetRejectAge(time.Second)
+	if got := detector.RejectAge(); got != 3*time.Second {
+		t.Errorf("expected reject age floored at 3s, got %v", got)
+	}
+	if err := detector.CheckStrict(old); err == nil {
+		t.Error("expected restored bound to reject the old value")
+	}
+}
+
 func TestDetector_CheckMultiple(t *testing.T) {
 	detector := NewDetector(3*time.Second, testMetrics)
 
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomfilter answers "definitely not present" for keys without a map lookup
+// bits are never cleared, so deleted keys may still report as present
+type BloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// newbloomfilter sizes a filter for expectedItems at the target false-positive rate
+func NewBloomFilter(expectedItems int, fpRate float64) *BloomFilter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	// standard sizing: m = -n ln(p) / (ln 2)^2, k = (m/n) ln 2
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// add sets the bits for key
+func (bf *BloomFilter) Add(key string) {
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < bf.k; i++ {
+		bit := (h1 + i*h2) % bf.m
+		bf.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// mightcontain returns false only if key was never added
+func (bf *BloomFilter) MightContain(key string) bool {
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < bf.k; i++ {
+		bit := (h1 + i*h2) % bf.m
+		if bf.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reset clears every bit
+func (bf *BloomFilter) Reset() {
+	clear(bf.bits)
+}
+
+// two independent hashes for double hashing (kirsch-mitzenmacher)
+func bloomHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+
+	h2 := fnv.New64()
+	h2.Write([]byte(key))
+	return h1, h2.Sum64() | 1 // odd step so probes don't collapse
+}
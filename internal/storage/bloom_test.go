@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter_NoFalseNegatives(t *testing.T) {
+	bf := NewBloomFilter(10000, 0.01)
+
+	for i := 0; i < 10000; i++ {
+		bf.Add(fmt.Sprintf("key%d", i))
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !bf.MightContain(fmt.Sprintf("key%d", i)) {
+			t.Fatalf("false negative for key%d", i)
+		}
+	}
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	bf := NewBloomFilter(10000, 0.01)
+
+	for i := 0; i < 10000; i++ {
+		bf.Add(fmt.Sprintf("key%d", i))
+	}
+
+	falsePositives := 0
+	trials := 100000
+	for i := 0; i < trials; i++ {
+		if bf.MightContain(fmt.Sprintf("absent%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// allow some slack over the 1% target
+	rate := float64(falsePositives) / float64(trials)
+	if rate > 0.03 {
+		t.Errorf("false positive rate too high: %.4f", rate)
+	}
+}
+
+func TestStore_MightContain(t *testing.T) {
+	store := NewStore()
+	store.Put("before", []byte("v"), "node1")
+
+	store.EnableBloomFilter(1000, 0.01)
+	store.Put("after", []byte("v"), "node1")
+
+	if !store.MightContain("before") {
+		t.Error("expected key stored before enabling bloom filter to be present")
+	}
+	if !store.MightContain("after") {
+		t.Error("expected key stored after enabling bloom filter to be present")
+	}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// count-min sketch dimensions, ~0.1% overestimate of total accesses per key
+// with high probability
+const (
+	sketchWidth = 2048
+	sketchDepth = 4
+)
+
+// hotkeytracker estimates per-key access counts with a count-min sketch and
+// keeps the k keys with the highest estimates. counts are cumulative since
+// the tracker was created
+type HotKeyTracker struct {
+	counters [sketchDepth][sketchWidth]atomic.Uint64
+
+	k      int
+	mu     sync.Mutex        // protect top
+	top    map[string]uint64 // current top-k key -> estimated count
+	topMin atomic.Uint64     // smallest count in top once it holds k keys
+}
+
+// key and its estimated access count
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// newhotkeytracker tracks the k most frequently accessed keys
+func NewHotKeyTracker(k int) *HotKeyTracker {
+	if k < 1 {
+		k = 1
+	}
+	return &HotKeyTracker{
+		k:   k,
+		top: make(map[string]uint64, k+1),
+	}
+}
+
+// record counts one access to key. lock-free unless the key is (or may
+// become) one of the top k
+func (t *HotKeyTracker) Record(key string) {
+	h1, h2 := bloomHashes(key)
+
+	estimate := ^uint64(0)
+	for i := uint64(0); i < sketchDepth; i++ {
+		count := t.counters[i][(h1+i*h2)%sketchWidth].Add(1)
+		estimate = min(estimate, count)
+	}
+
+	// fast path: too cold to enter a full top-k
+	if estimate <= t.topMin.Load() {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.top[key] = estimate
+	if len(t.top) <= t.k {
+		return
+	}
+
+	// evict the coldest key and raise the admission threshold
+	coldest, coldestCount := "", ^uint64(0)
+	for k, c := range t.top {
+		if c < coldestCount {
+			coldest, coldestCount = k, c
+		}
+	}
+	delete(t.top, coldest)
+
+	newMin := ^uint64(0)
+	for _, c := range t.top {
+		newMin = min(newMin, c)
+	}
+	t.topMin.Store(newMin)
+}
+
+// topkeys returns up to n of the hottest keys, hottest first
+func (t *HotKeyTracker) TopKeys(n int) []KeyCount {
+	t.mu.Lock()
+	keys := make([]KeyCount, 0, len(t.top))
+	for key, count := range t.top {
+		keys = append(keys, KeyCount{Key: key, Count: count})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	if n > 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+)
+
+func TestHotKeyTracker_HeavyKeyInTopK(t *testing.T) {
+	tracker := NewHotKeyTracker(3)
+
+	// interleave one hot key with many rarely accessed keys
+	for i := 0; i < 5000; i++ {
+		tracker.Record("hot")
+		tracker.Record(fmt.Sprintf("cold-%d", i))
+	}
+
+	top := tracker.TopKeys(3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 top keys, got %d", len(top))
+	}
+	if top[0].Key != "hot" {
+		t.Errorf("expected hot key first, got %v", top)
+	}
+	if top[0].Count < 5000 {
+		t.Errorf("expected hot key estimate of at least 5000, got %d", top[0].Count)
+	}
+	for _, kc := range top[1:] {
+		if kc.Count > 100 {
+			t.Errorf("expected cold key estimate to stay low, got %s=%d", kc.Key, kc.Count)
+		}
+	}
+}
+
+func TestStore_HotKeys(t *testing.T) {
+	store := NewStore()
+	if store.HotKeys(10) != nil {
+		t.Error("expected nil hot keys when tracking is disabled")
+	}
+
+	store.EnableHotKeyTracking(2)
+	for i := 0; i < 100; i++ {
+		store.Get("read-heavy")
+		store.PutWithHLC("write-heavy", []byte("v"), "node1", hlc.HLC{Physical: int64(i), NodeID: "node1"})
+	}
+	for i := 0; i < 50; i++ {
+		store.Get(fmt.Sprintf("rare-%d", i))
+	}
+
+	top := store.HotKeys(10)
+	if len(top) != 2 {
+		t.Fatalf("expected 2 tracked keys, got %v", top)
+	}
+	for _, kc := range top {
+		if kc.Key != "read-heavy" && kc.Key != "write-heavy" {
+			t.Errorf("expected only heavily accessed keys, got %s", kc.Key)
+		}
+	}
+}
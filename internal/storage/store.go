@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"slices"
 	"sync"
 	"time"
 
@@ -8,26 +9,181 @@ import (
 )
 
 type VersionedValue struct {
-	Value      []byte
-	Version    int64
-	Timestamp  int64    // deprecated, kept for backward compatibility
-	NodeID     string
-	HLC        hlc.HLC  // hybrid logical clock timestamp
-	ReceivedAt int64    // local time when value was received
-	IsLocal    bool     // true if originated on this node
+	Value       []byte
+	ContentType string // opaque to the store, set by the writer
+	Version     int64
+	Timestamp   int64 // deprecated, kept for backward compatibility
+	NodeID      string
+	HLC         hlc.HLC // hybrid logical clock timestamp
+	ReceivedAt  int64   // local time when value was received
+	IsLocal     bool    // true if originated on this node
 }
 
 // thread safe in-memory kv store
 type Store struct {
-	mu   sync.RWMutex
-	data map[string]VersionedValue
+	mu    sync.RWMutex
+	data  map[string]VersionedValue
+	bloom *BloomFilter   // optional, for fast negative lookups
+	hot   *HotKeyTracker // optional, per-key access frequency
+
+	// highest hlc ever stored, never lowered by rollbacks or clears
+	maxHLC hlc.HLC
+
+	// concurrent writes (same hlc, different writer) that lost lww to the
+	// current value, dropped by the next write that is not concurrent
+	siblings map[string][]VersionedValue
+
+	// up to historyDepth values each key held before its current one, oldest
+	// first, for reads as of a past hlc (disabled when zero)
+	history      map[string][]VersionedValue
+	historyDepth int
 }
 
 // create new store instance
 func NewStore() *Store {
 	return &Store{
-		data: make(map[string]VersionedValue),
+		data:     make(map[string]VersionedValue),
+		siblings: make(map[string][]VersionedValue),
+	}
+}
+
+// enablebloomfilter maintains a bloom filter over the key set, seeded with
+// the keys already stored
+func (s *Store) EnableBloomFilter(expectedKeys int, fpRate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bloom = NewBloomFilter(expectedKeys, fpRate)
+	for key := range s.data {
+		s.bloom.Add(key)
+	}
+}
+
+// enableversionhistory keeps the depth values each key held before its
+// current one, see GetAsOf
+func (s *Store) EnableVersionHistory(depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.historyDepth = depth
+	s.history = make(map[string][]VersionedValue)
+}
+
+// enablehotkeytracking counts reads and writes per key and keeps the k
+// hottest, see HotKeys
+func (s *Store) EnableHotKeyTracking(k int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hot = NewHotKeyTracker(k)
+}
+
+// hotkeys returns up to n of the most accessed keys, hottest first
+// returns nil when hot key tracking is disabled
+func (s *Store) HotKeys(n int) []KeyCount {
+	s.mu.RLock()
+	hot := s.hot
+	s.mu.RUnlock()
+
+	if hot == nil {
+		return nil
+	}
+	return hot.TopKeys(n)
+}
+
+// count an access for hot key tracking (caller holds the lock)
+func (s *Store) recordAccess(key string) {
+	if s.hot != nil {
+		s.hot.Record(key)
+	}
+}
+
+// mightcontain returns false if key is definitely absent
+// without a bloom filter this is an exact lookup
+func (s *Store) MightContain(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.bloom != nil {
+		return s.bloom.MightContain(key)
+	}
+	_, exists := s.data[key]
+	return exists
+}
+
+// set value and keep the bloom filter in sync (caller holds the lock)
+func (s *Store) set(key string, vv VersionedValue) {
+	if current, exists := s.data[key]; exists && current.HLC.Equal(vv.HLC) {
+		// a replay of the same write keeps the siblings
+		if current.HLC.NodeID != vv.HLC.NodeID {
+			s.addSibling(key, vv, current)
+		}
+	} else {
+		delete(s.siblings, key)
+	}
+	if current, exists := s.data[key]; exists && s.historyDepth > 0 {
+		s.recordHistory(key, current, vv)
+	}
+	s.data[key] = vv
+	if vv.HLC.HappensAfter(s.maxHLC) {
+		s.maxHLC = vv.HLC
+	}
+	if s.bloom != nil {
+		s.bloom.Add(key)
+	}
+}
+
+// keep current in the key's history when vv supersedes it. a rollback to
+// an older value instead drops the history entry it restores (caller holds
+// the lock)
+func (s *Store) recordHistory(key string, current, vv VersionedValue) {
+	h := s.history[key]
+	if !vv.HLC.HappensAfter(current.HLC) {
+		if n := len(h); n > 0 && h[n-1].HLC.Equal(vv.HLC) && h[n-1].HLC.NodeID == vv.HLC.NodeID {
+			s.history[key] = h[:n-1]
+		}
+		return
+	}
+	if len(h) == s.historyDepth {
+		h = h[1:]
+	}
+	s.history[key] = append(h, current)
+}
+
+// getasof returns the newest value of key with an hlc at or before barrier.
+// complete is false when the key has changed since barrier and its history
+// no longer reaches back that far, so the answer is unknown
+func (s *Store) GetAsOf(key string, barrier hlc.HLC) (vv VersionedValue, found, complete bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current, exists := s.data[key]
+	if !exists {
+		return VersionedValue{}, false, true
+	}
+	if !current.HLC.HappensAfter(barrier) {
+		return current, true, true
+	}
+
+	h := s.history[key]
+	for i := len(h) - 1; i >= 0; i-- {
+		if !h[i].HLC.HappensAfter(barrier) {
+			return h[i], true, true
+		}
 	}
+	// a full history may have dropped the version we need
+	return VersionedValue{}, false, len(h) < s.historyDepth
+}
+
+// keep loser as a sibling of winner, replacing any earlier copy of either
+// write (caller holds the lock)
+func (s *Store) addSibling(key string, winner, loser VersionedValue) {
+	var kept []VersionedValue
+	for _, sib := range s.siblings[key] {
+		if sib.HLC.NodeID != winner.HLC.NodeID && sib.HLC.NodeID != loser.HLC.NodeID {
+			kept = append(kept, sib)
+		}
+	}
+	s.siblings[key] = append(kept, loser)
 }
 
 // put kv pair with version and timestamp
@@ -45,7 +201,8 @@ func (s *Store) Put(key string, value []byte, nodeID string) VersionedValue {
 		NodeID:    nodeID,
 	}
 
-	s.data[key] = vv
+	s.recordAccess(key)
+	s.set(key, vv)
 	return vv
 }
 
@@ -54,21 +211,31 @@ func (s *Store) Get(key string) (VersionedValue, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	s.recordAccess(key)
+	vv, exists := s.data[key]
+	return vv, exists
+}
+
+// retrieve value by key without counting it as an access, for admin tooling
+func (s *Store) Peek(key string) (VersionedValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	vv, exists := s.data[key]
 	return vv, exists
 }
 
-// returns all values for a key (future impl)
-// return single value for now
+// returns the current value of a key followed by its concurrent siblings,
+// lww winner first
 func (s *Store) GetAll(key string) []VersionedValue {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if vv, exists := s.data[key]; exists {
-		return []VersionedValue{vv}
+	vv, exists := s.data[key]
+	if !exists {
+		return []VersionedValue{}
 	}
-
-	return []VersionedValue{}
+	return Siblings(append([]VersionedValue{vv}, s.siblings[key]...))
 }
 
 // returns number of keys in the store
@@ -78,27 +245,161 @@ func (s *Store) Size() int {
 	return len(s.data)
 }
 
+// maxhlc returns the highest hlc of any value stored so far
+func (s *Store) MaxHLC() hlc.HLC {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxHLC
+}
+
+// clear removes every key and returns how many were removed. hot key
+// counts are kept
+func (s *Store) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := len(s.data)
+	s.data = make(map[string]VersionedValue)
+	s.siblings = make(map[string][]VersionedValue)
+	if s.history != nil {
+		s.history = make(map[string][]VersionedValue)
+	}
+	if s.bloom != nil {
+		s.bloom.Reset()
+	}
+	return removed
+}
+
 // put kv pair with hlc timestamp
 func (s *Store) PutWithHLC(key string, value []byte, nodeID string, timestamp hlc.HLC) VersionedValue {
+	return s.PutWithContentType(key, value, "", nodeID, timestamp)
+}
+
+// same as PutWithHLC, recording the value's content type
+func (s *Store) PutWithContentType(key string, value []byte, contentType, nodeID string, timestamp hlc.HLC) VersionedValue {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	now := time.Now().UnixNano()
 
 	vv := VersionedValue{
-		Value:      value,
-		Version:    timestamp.Physical,  // use hlc physical as version
-		Timestamp:  timestamp.Physical,  // backward compatibility
-		NodeID:     nodeID,
-		HLC:        timestamp,
-		ReceivedAt: now,
-		IsLocal:    nodeID == timestamp.NodeID,
+		Value:       value,
+		ContentType: contentType,
+		Version:     timestamp.Physical, // use hlc physical as version
+		Timestamp:   timestamp.Physical, // backward compatibility
+		NodeID:      nodeID,
+		HLC:         timestamp,
+		ReceivedAt:  now,
+		IsLocal:     nodeID == timestamp.NodeID,
 	}
 
-	s.data[key] = vv
+	s.recordAccess(key)
+	s.set(key, vv)
 	return vv
 }
 
+// shouldreplace is the canonical last-writer-wins rule, shared by replicate
+// apply, reconciliation and the read merge so they all pick the same winner
+// whatever order writes arrive in. incoming wins if it has:
+//  1. a higher hlc (physical, then logical)
+//  2. an equal hlc generated by a higher node id
+//  3. the same hlc from a higher storing node id
+//
+// identical values never replace each other, so replays are no-ops
+func ShouldReplace(existing, incoming VersionedValue) bool {
+	if !incoming.HLC.Equal(existing.HLC) {
+		return incoming.HLC.HappensAfter(existing.HLC)
+	}
+	if incoming.HLC.NodeID != existing.HLC.NodeID {
+		return incoming.HLC.NodeID > existing.HLC.NodeID
+	}
+	return incoming.NodeID > existing.NodeID
+}
+
+// siblings returns the values tied with the newest hlc, one per writing node,
+// lww winner first. for merging sibling sets read from several replicas
+func Siblings(values []VersionedValue) []VersionedValue {
+	var newest []VersionedValue
+	for _, vv := range values {
+		switch {
+		case len(newest) == 0 || vv.HLC.HappensAfter(newest[0].HLC):
+			newest = []VersionedValue{vv}
+		case vv.HLC.Equal(newest[0].HLC):
+			i := slices.IndexFunc(newest, func(n VersionedValue) bool {
+				return n.HLC.NodeID == vv.HLC.NodeID
+			})
+			if i < 0 {
+				newest = append(newest, vv)
+			} else if ShouldReplace(newest[i], vv) {
+				newest[i] = vv
+			}
+		}
+	}
+
+	slices.SortFunc(newest, func(a, b VersionedValue) int {
+		if ShouldReplace(b, a) {
+			return -1
+		}
+		return 1
+	})
+	return newest
+}
+
+// put kv pair only if it wins last-writer-wins against the current value,
+// see ShouldReplace. returns the stored value and whether the incoming write
+// was applied
+func (s *Store) PutIfNewer(key string, value []byte, nodeID string, timestamp hlc.HLC) (VersionedValue, bool) {
+	return s.PutIfNewerWithContentType(key, value, "", nodeID, timestamp)
+}
+
+// same as PutIfNewer, recording the value's content type
+func (s *Store) PutIfNewerWithContentType(key string, value []byte, contentType, nodeID string, timestamp hlc.HLC) (VersionedValue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordAccess(key)
+
+	vv := VersionedValue{
+		Value:       value,
+		ContentType: contentType,
+		Version:     timestamp.Physical,
+		Timestamp:   timestamp.Physical,
+		NodeID:      nodeID,
+		HLC:         timestamp,
+		ReceivedAt:  time.Now().UnixNano(),
+		IsLocal:     nodeID == timestamp.NodeID,
+	}
+	if current, exists := s.data[key]; exists && !ShouldReplace(current, vv) {
+		if current.HLC.Equal(timestamp) && current.HLC.NodeID != timestamp.NodeID {
+			s.addSibling(key, current, vv)
+		}
+		return current, false
+	}
+
+	s.set(key, vv)
+	return vv, true
+}
+
+// keys returns up to limit keys sorted ascending, starting strictly after
+// after. an empty after starts from the first key. sorts the whole key set,
+// so paging through a large store is expensive
+func (s *Store) Keys(after string, limit int) []string {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		if key > after {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 // retrieve value with staleness check
 // returns (value, found, isStale)
 func (s *Store) GetWithStaleness(key string, maxAge time.Duration) (VersionedValue, bool, bool) {
@@ -118,14 +419,52 @@ func (s *Store) GetWithStaleness(key string, maxAge time.Duration) (VersionedVal
 	return vv, true, isStale
 }
 
-// detect conflicting values (concurrent writes)
-// for now returns single value as we implement LWW
+// returns the concurrent writes that lost lww to the current value of a key,
+// empty when there is no conflict
 func (s *Store) GetConflicts(key string) []VersionedValue {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if vv, exists := s.data[key]; exists {
-		return []VersionedValue{vv}
+	return slices.Clone(s.siblings[key])
+}
+
+// restore the previous value of a key, but only if the current value is still
+// the one written with expected (so a newer concurrent write is not clobbered)
+// deletes the key if there was no previous value
+func (s *Store) RestoreIfCurrent(key string, expected hlc.HLC, prev VersionedValue, hadPrev bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.data[key]
+	if !exists || !current.HLC.Equal(expected) || current.HLC.NodeID != expected.NodeID {
+		return false
+	}
+
+	if hadPrev {
+		s.set(key, prev)
+	} else {
+		delete(s.data, key)
+		delete(s.siblings, key)
+		delete(s.history, key)
+	}
+	return true
+}
+
+// evictolderthan removes every value whose hlc physical time is before
+// cutoff (unix nanos) and returns how many were removed. the bloom filter
+// keeps the evicted keys, which only costs false positives
+func (s *Store) EvictOlderThan(cutoff int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evicted := 0
+	for key, vv := range s.data {
+		if vv.HLC.Physical < cutoff {
+			delete(s.data, key)
+			delete(s.siblings, key)
+			delete(s.history, key)
+			evicted++
+		}
 	}
-	return []VersionedValue{}
+	return evicted
 }
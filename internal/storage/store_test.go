@@ -4,6 +4,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
 )
 
 func TestStore_PutAndGet(t *testing.T) {
@@ -174,3 +176,154 @@ func TestStore_Size(t *testing.T) {
 		t.Errorf("expected size 2, got %d", store.Size())
 	}
 }
+
+func TestStore_PutIfNewer(t *testing.T) {
+	store := NewStore()
+
+	newer := hlc.HLC{Physical: 200, Logical: 0, NodeID: "node1"}
+	older := hlc.HLC{Physical: 100, Logical: 0, NodeID: "node2"}
+
+	if _, applied := store.PutIfNewer("key", []byte("new"), "node1", newer); !applied {
+		t.Fatal("expected first write to be applied")
+	}
+
+	// older write must not clobber newer value
+	if _, applied := store.PutIfNewer("key", []byte("old"), "node2", older); applied {
+		t.Error("expected older write to be rejected")
+	}
+	vv, _ := store.Get("key")
+	if string(vv.Value) != "new" {
+		t.Errorf("expected value new, got %s", vv.Value)
+	}
+
+	// equal hlc: higher node id wins, replay of the same write is a no-op
+	if _, applied := store.PutIfNewer("key", []byte("tie"), "node0", newer); applied {
+		t.Error("expected lower node id to lose the tiebreak")
+	}
+	if _, applied := store.PutIfNewer("key", []byte("tie"), "node3", newer); !applied {
+		t.Error("expected higher node id to win the tiebreak")
+	}
+}
+
+func TestShouldReplace_ArrivalOrderIndependent(t *testing.T) {
+	pairs := []struct {
+		name string
+		a, b VersionedValue
+	}{
+		{"newer physical", VersionedValue{NodeID: "node1", HLC: hlc.HLC{Physical: 200, NodeID: "node1"}}, VersionedValue{NodeID: "node2", HLC: hlc.HLC{Physical: 100, NodeID: "node2"}}},
+		{"newer logical", VersionedValue{NodeID: "node1", HLC: hlc.HLC{Physical: 100, Logical: 1, NodeID: "node1"}}, VersionedValue{NodeID: "node2", HLC: hlc.HLC{Physical: 100, NodeID: "node2"}}},
+		{"concurrent", VersionedValue{NodeID: "node1", HLC: hlc.HLC{Physical: 100, NodeID: "node1"}}, VersionedValue{NodeID: "node2", HLC: hlc.HLC{Physical: 100, NodeID: "node2"}}},
+		{"same hlc, different stores", VersionedValue{NodeID: "node1", HLC: hlc.HLC{Physical: 100, NodeID: "node3"}}, VersionedValue{NodeID: "node2", HLC: hlc.HLC{Physical: 100, NodeID: "node3"}}},
+	}
+
+	for _, tt := range pairs {
+		t.Run(tt.name, func(t *testing.T) {
+			if ShouldReplace(tt.a, tt.b) == ShouldReplace(tt.b, tt.a) {
+				t.Fatalf("expected exactly one side to win, got %v both ways", ShouldReplace(tt.a, tt.b))
+			}
+			if ShouldReplace(tt.a, tt.a) {
+				t.Error("expected a value not to replace itself")
+			}
+
+			forward, backward := NewStore(), NewStore()
+			for _, vv := range []VersionedValue{tt.a, tt.b} {
+				forward.PutIfNewer("key", []byte(vv.NodeID), vv.NodeID, vv.HLC)
+			}
+			for _, vv := range []VersionedValue{tt.b, tt.a} {
+				backward.PutIfNewer("key", []byte(vv.NodeID), vv.NodeID, vv.HLC)
+			}
+
+			f, _ := forward.Get("key")
+			b, _ := backward.Get("key")
+			if string(f.Value) != string(b.Value) || f.HLC != b.HLC {
+				t.Errorf("expected both arrival orders to converge, got %s and %s", f.Value, b.Value)
+			}
+		})
+	}
+}
+
+func TestStore_MaxHLC(t *testing.T) {
+	store := NewStore()
+
+	store.PutWithHLC("key1", []byte("v1"), "node1", hlc.HLC{Physical: 200, NodeID: "node1"})
+	store.PutWithHLC("key2", []byte("v2"), "node2", hlc.HLC{Physical: 100, Logical: 5, NodeID: "node2"})
+	if got := store.MaxHLC(); got.Physical != 200 {
+		t.Fatalf("expected max hlc physical 200, got %v", got)
+	}
+
+	// rollback and clear never lower the barrier
+	store.RestoreIfCurrent("key1", hlc.HLC{Physical: 200, NodeID: "node1"}, VersionedValue{}, false)
+	store.Clear()
+	if got := store.MaxHLC(); got.Physical != 200 {
+		t.Errorf("expected max hlc to stay at 200, got %v", got)
+	}
+}
+
+func TestStore_ConcurrentWritesKeepSiblings(t *testing.T) {
+	store := NewStore()
+	ts := hlc.HLC{Physical: 100, NodeID: "node1"}
+
+	store.PutIfNewer("key", []byte("a"), "node1", ts)
+	// same hlc from other writers: node3 wins lww, node2 loses
+	store.PutIfNewer("key", []byte("c"), "node3", hlc.HLC{Physical: 100, NodeID: "node3"})
+	store.PutIfNewer("key", []byte("b"), "node2", hlc.HLC{Physical: 100, NodeID: "node2"})
+	// a replay is not a new sibling
+	store.PutIfNewer("key", []byte("a"), "node1", ts)
+
+	versions := store.GetAll("key")
+	var got []string
+	for _, vv := range versions {
+		got = append(got, string(vv.Value))
+	}
+	if len(got) != 3 || got[0] != "c" || got[1] != "b" || got[2] != "a" {
+		t.Fatalf("expected siblings [c b a], got %v", got)
+	}
+	if conflicts := store.GetConflicts("key"); len(conflicts) != 2 {
+		t.Errorf("expected 2 conflicts, got %d", len(conflicts))
+	}
+
+	// a later write collapses the siblings
+	store.PutIfNewer("key", []byte("d"), "node2", hlc.HLC{Physical: 101, NodeID: "node2"})
+	if versions := store.GetAll("key"); len(versions) != 1 || string(versions[0].Value) != "d" {
+		t.Errorf("expected only d after a newer write, got %v", versions)
+	}
+	if conflicts := store.GetConflicts("key"); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts after a newer write, got %d", len(conflicts))
+	}
+}
+
+func TestStore_GetAsOf(t *testing.T) {
+	store := NewStore()
+	store.EnableVersionHistory(2)
+	for i, v := range []string{"a", "b", "c"} {
+		store.PutIfNewer("key", []byte(v), "node1", hlc.HLC{Physical: int64(100 * (i + 1)), NodeID: "node1"})
+	}
+
+	// current value, then history back to depth 2, then truncated
+	cases := []struct {
+		barrier  int64
+		want     string
+		complete bool
+	}{
+		{350, "c", true},
+		{250, "b", true},
+		{100, "a", true},
+		{50, "", false},
+	}
+	for _, tc := range cases {
+		vv, found, complete := store.GetAsOf("key", hlc.HLC{Physical: tc.barrier})
+		if complete != tc.complete || found != (tc.want != "") || string(vv.Value) != tc.want {
+			t.Errorf("as of %d: expected %q complete=%v, got %q found=%v complete=%v",
+				tc.barrier, tc.want, tc.complete, vv.Value, found, complete)
+		}
+	}
+
+	// one more write drops a from the history
+	store.PutIfNewer("key", []byte("d"), "node1", hlc.HLC{Physical: 400, NodeID: "node1"})
+	if _, _, complete := store.GetAsOf("key", hlc.HLC{Physical: 150}); complete {
+		t.Error("expected the read past the history depth to be incomplete")
+	}
+	if _, found, complete := store.GetAsOf("missing", hlc.HLC{Physical: 150}); found || !complete {
+		t.Error("expected a missing key to be absent")
+	}
+}
@@ -13,10 +13,18 @@ import (
 type Client struct {
 	conn   *grpc.ClientConn
 	client proto.ACPServiceClient
+
+	// further endpoints gets are hedged to, see EnableHedging
+	hedgeConns []*grpc.ClientConn
+	hedges     []proto.ACPServiceClient
+	hedgeDelay time.Duration
 }
 
-func NewClient(addr string) (*Client, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// newclient connects without tls. extra dial options (e.g. a custom dialer)
+// are applied after the defaults
+func NewClient(addr string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(addr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
@@ -27,7 +35,31 @@ func NewClient(addr string) (*Client, error) {
 	}, nil
 }
 
+// dialclient connects and blocks until the node is reachable, failing with
+// an error once timeout passes instead of deferring it to the first rpc
+func DialClient(addr string, timeout time.Duration, opts ...grpc.DialOption) (*Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	}, opts...)
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s within %v: %w", addr, timeout, err)
+	}
+
+	return &Client{
+		conn:   conn,
+		client: proto.NewACPServiceClient(conn),
+	}, nil
+}
+
 func (c *Client) Close() error {
+	for _, conn := range c.hedgeConns {
+		conn.Close()
+	}
 	return c.conn.Close()
 }
 
@@ -38,15 +70,168 @@ func (c *Client) Put(ctx context.Context, key string, value []byte) (*proto.PutR
 	})
 }
 
+// put without recording the write in the reconcile log, for bulk imports
+func (c *Client) PutBulk(ctx context.Context, key string, value []byte) (*proto.PutResponse, error) {
+	return c.client.Put(ctx, &proto.PutRequest{
+		Key:   key,
+		Value: value,
+		Bulk:  true,
+	})
+}
+
+// put that must be acknowledged by every replica (W=N) whatever the node's
+// adaptive quorum, for keys like leases that must never be relaxed
+func (c *Client) PutStrong(ctx context.Context, key string, value []byte) (*proto.PutResponse, error) {
+	return c.client.Put(ctx, &proto.PutRequest{
+		Key:               key,
+		Value:             value,
+		StrongConsistency: true,
+	})
+}
+
+// put tagged with how the value is encoded, e.g. application/json. the
+// server stores it opaquely and returns it as GetResponse.ContentType
+func (c *Client) PutWithContentType(ctx context.Context, key string, value []byte, contentType string) (*proto.PutResponse, error) {
+	return c.client.Put(ctx, &proto.PutRequest{
+		Key:         key,
+		Value:       value,
+		ContentType: contentType,
+	})
+}
+
 func (c *Client) Get(ctx context.Context, key string) (*proto.GetResponse, error) {
-	return c.client.Get(ctx, &proto.GetRequest{
+	return c.get(ctx, &proto.GetRequest{
 		Key: key,
 	})
 }
 
+// getfrom returns the local value of peer, a peer address of the node, with
+// no quorum merge. the node needs PINNED_READS_ENABLED
+func (c *Client) GetFrom(ctx context.Context, key, peer string) (*proto.GetResponse, error) {
+	return c.get(ctx, &proto.GetRequest{
+		Key:      key,
+		FromPeer: peer,
+	})
+}
+
+// get that returns the best value gathered, with Degraded set, instead of
+// failing when fewer than R replicas answer
+func (c *Client) GetDegraded(ctx context.Context, key string) (*proto.GetResponse, error) {
+	return c.get(ctx, &proto.GetRequest{
+		Key:           key,
+		AllowDegraded: true,
+	})
+}
+
+// get that reads every replica (R=N) whatever the node's adaptive quorum
+func (c *Client) GetStrong(ctx context.Context, key string) (*proto.GetResponse, error) {
+	return c.get(ctx, &proto.GetRequest{
+		Key:               key,
+		StrongConsistency: true,
+	})
+}
+
+// getsiblings returns every concurrent write of key, lww winner first, for
+// callers that resolve conflicts themselves
+func (c *Client) GetSiblings(ctx context.Context, key string) (*proto.GetSiblingsResponse, error) {
+	return c.client.GetSiblings(ctx, &proto.GetRequest{Key: key})
+}
+
+// snapshotread returns keys as they stood at one hlc barrier on the node,
+// as_of nil lets the node pick now. see SnapshotReadRequest for the limits
+func (c *Client) SnapshotRead(ctx context.Context, keys []string, asOf *proto.HLC) (*proto.SnapshotReadResponse, error) {
+	return c.client.SnapshotRead(ctx, &proto.SnapshotReadRequest{Keys: keys, AsOf: asOf})
+}
+
+// getifmodified returns the value only if its hlc differs from known;
+// otherwise the response has NotModified set and no value bytes
+func (c *Client) GetIfModified(ctx context.Context, key string, known *proto.HLC) (*proto.GetResponse, error) {
+	return c.get(ctx, &proto.GetRequest{
+		Key:      key,
+		KnownHlc: known,
+	})
+}
+
+// readbarrier returns the newest hlc stored on the node, pass it to
+// GetAtLeast to read from a node that has seen every write up to it
+func (c *Client) ReadBarrier(ctx context.Context) (*proto.HLC, error) {
+	resp, err := c.client.ReadBarrier(ctx, &proto.ReadBarrierRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Hlc, nil
+}
+
+// time returns a fresh hlc from the node's clock and its wall time, without
+// writing anything. every call returns a later hlc than the last
+func (c *Client) Time(ctx context.Context) (*proto.TimeResponse, error) {
+	return c.client.Time(ctx, &proto.TimeRequest{})
+}
+
+// getatleast fails unless the node has observed writes up to min
+func (c *Client) GetAtLeast(ctx context.Context, key string, min *proto.HLC) (*proto.GetResponse, error) {
+	return c.get(ctx, &proto.GetRequest{
+		Key:    key,
+		MinHlc: min,
+	})
+}
+
 func (c *Client) HealthCheck(ctx context.Context, sourceNodeID string) (*proto.HealthResponse, error) {
 	return c.client.HealthCheck(ctx, &proto.HealthRequest{
 		SourceNodeId: sourceNodeID,
 		Timestamp:    time.Now().UnixNano(),
 	})
 }
+
+func (c *Client) TriggerReconcile(ctx context.Context, peer string) (*proto.TriggerReconcileResponse, error) {
+	return c.client.TriggerReconcile(ctx, &proto.TriggerReconcileRequest{
+		Peer: peer,
+	})
+}
+
+// replace the node's static peer list at runtime
+func (c *Client) UpdatePeers(ctx context.Context, peers []string) (*proto.UpdatePeersResponse, error) {
+	return c.client.UpdatePeers(ctx, &proto.UpdatePeersRequest{
+		Peers: peers,
+	})
+}
+
+// set the node's R and W by hand, only accepted by nodes running the adaptive
+// quorum. the change holds off automatic adjustments for QUORUM_MANUAL_HOLD
+func (c *Client) SetQuorum(ctx context.Context, r, w int, reason string) (*proto.SetQuorumResponse, error) {
+	return c.client.SetQuorum(ctx, &proto.SetQuorumRequest{
+		R:      int32(r),
+		W:      int32(w),
+		Reason: reason,
+	})
+}
+
+// most frequently accessed keys on the node, limit 0 for all tracked
+func (c *Client) HotKeys(ctx context.Context, limit int) (*proto.HotKeysResponse, error) {
+	return c.client.HotKeys(ctx, &proto.HotKeysRequest{
+		Limit: int32(limit),
+	})
+}
+
+// list one page of the node's keys after the cursor, only accepted by nodes
+// started with LIST_KEYS_ENABLED. pass the response's NextCursor to continue
+func (c *Client) ListKeys(ctx context.Context, after string, limit int, metadata bool) (*proto.ListKeysResponse, error) {
+	return c.client.ListKeys(ctx, &proto.ListKeysRequest{
+		After:    after,
+		Limit:    int32(limit),
+		Metadata: metadata,
+	})
+}
+
+// keys the node lacks or holds an older version of compared to peer, which
+// must run with LIST_KEYS_ENABLED
+func (c *Client) MissingKeys(ctx context.Context, peer string) (*proto.MissingKeysResponse, error) {
+	return c.client.MissingKeys(ctx, &proto.MissingKeysRequest{
+		Peer: peer,
+	})
+}
+
+// wipe the node's data, only accepted by nodes started with FLUSH_ENABLED
+func (c *Client) Flush(ctx context.Context) (*proto.FlushResponse, error) {
+	return c.client.Flush(ctx, &proto.FlushRequest{})
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// enablehedging connects to further nodes of the cluster and hedges every
+// get across them: if the node the client was created for hasn't answered
+// within delay, the same get goes to the next endpoint, and so on. the first
+// answer wins and the others are cancelled. an endpoint that fails is hedged
+// immediately. dial options are applied as in NewClient
+func (c *Client) EnableHedging(delay time.Duration, addrs []string, opts ...grpc.DialOption) error {
+	if delay <= 0 {
+		return errors.New("hedge delay must be positive")
+	}
+
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	for _, addr := range addrs {
+		conn, err := grpc.NewClient(addr, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		}
+		c.hedgeConns = append(c.hedgeConns, conn)
+		c.hedges = append(c.hedges, proto.NewACPServiceClient(conn))
+	}
+	c.hedgeDelay = delay
+	return nil
+}
+
+// send a get, hedged across endpoints when enabled
+func (c *Client) get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	if len(c.hedges) == 0 {
+		return c.client.Get(ctx, req)
+	}
+
+	// cancels the losers once one endpoint has answered
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp *proto.GetResponse
+		err  error
+	}
+
+	endpoints := append([]proto.ACPServiceClient{c.client}, c.hedges...)
+	results := make(chan result, len(endpoints))
+	launched, pending := 0, 0
+	launch := func() {
+		endpoint := endpoints[launched]
+		launched++
+		pending++
+		go func() {
+			resp, err := endpoint.Get(ctx, req)
+			results <- result{resp, err}
+		}()
+	}
+
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+
+	launch()
+	var lastErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.resp, nil
+			}
+			lastErr = r.err
+			if launched < len(endpoints) {
+				launch()
+				timer.Reset(c.hedgeDelay)
+			} else if pending == 0 {
+				return nil, lastErr
+			}
+
+		case <-timer.C:
+			if launched < len(endpoints) {
+				launch()
+				timer.Reset(c.hedgeDelay)
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rachitkumar205/acp-kv/api/proto"
+)
+
+// typed wrappers over the raw rpc methods. they return go values and errors
+// instead of proto responses with Success/Error fields. the server has no
+// delete, scan, watch, cas or multiget rpcs yet, so batches fan out over
+// single key rpcs client side
+
+var (
+	// key does not exist on the replicas that answered
+	ErrNotFound = errors.New("key not found")
+
+	// value exists but is older than the node's staleness bound
+	ErrStale = errors.New("value exceeds staleness bound")
+)
+
+// max in-flight rpcs per batch call
+const BatchConcurrency = 16
+
+// hybrid logical clock timestamp of a stored value
+type Timestamp struct {
+	Physical int64 // nanoseconds
+	Logical  int64
+	NodeID   string
+}
+
+// stored value and its version metadata
+type Entry struct {
+	Key       string
+	Value     []byte
+	Version   int64
+	Timestamp Timestamp
+	Divergent bool // replicas disagreed with the returned value beyond the server threshold
+	Stale     bool // older than the server's staleness bound, served because the cluster is degraded
+}
+
+// estimated access count of a hot key
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// server-side failure reported in a response rather than as an rpc error
+type ServerError struct {
+	Op      string
+	Key     string
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s failed: %s", e.Op, e.Message)
+	}
+	return fmt.Sprintf("%s %q failed: %s", e.Op, e.Key, e.Message)
+}
+
+func timestampFromProto(h *proto.HLC) Timestamp {
+	if h == nil {
+		return Timestamp{}
+	}
+	return Timestamp{Physical: h.Physical, Logical: h.Logical, NodeID: h.NodeId}
+}
+
+// set writes value under key and returns the committed entry
+func (c *Client) Set(ctx context.Context, key string, value []byte) (*Entry, error) {
+	resp, err := c.Put(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, &ServerError{Op: "put", Key: key, Message: resp.Error}
+	}
+	return &Entry{
+		Key:       key,
+		Value:     value,
+		Version:   resp.Version,
+		Timestamp: timestampFromProto(resp.Hlc),
+	}, nil
+}
+
+// lookup reads key at the node's read quorum. returns ErrNotFound for a
+// missing key and an error wrapping ErrStale for a value past the
+// staleness bound
+func (c *Client) Lookup(ctx context.Context, key string) (*Entry, error) {
+	resp, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsStale {
+		return nil, fmt.Errorf("get %q: %w: %s", key, ErrStale, resp.Error)
+	}
+	if resp.Error != "" {
+		return nil, &ServerError{Op: "get", Key: key, Message: resp.Error}
+	}
+	if !resp.Found {
+		return nil, ErrNotFound
+	}
+
+	// an empty value arrives as nil, keep it distinguishable from no entry
+	value := resp.Value
+	if value == nil {
+		value = []byte{}
+	}
+	return &Entry{
+		Key:       key,
+		Value:     value,
+		Version:   resp.Version,
+		Timestamp: timestampFromProto(resp.Hlc),
+		Divergent: resp.Divergent,
+		Stale:     resp.PastStaleness,
+	}, nil
+}
+
+// setmany writes every key in values. all writes are attempted; the
+// returned error joins the failures of individual keys
+func (c *Client) SetMany(ctx context.Context, values map[string][]byte) (map[string]*Entry, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	return c.batch(ctx, keys, func(key string) (*Entry, error) {
+		return c.Set(ctx, key, values[key])
+	})
+}
+
+// lookupmany reads every key. missing keys are left out of the result
+// rather than reported as errors
+func (c *Client) LookupMany(ctx context.Context, keys []string) (map[string]*Entry, error) {
+	return c.batch(ctx, keys, func(key string) (*Entry, error) {
+		entry, err := c.Lookup(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return entry, err
+	})
+}
+
+// run op for each key with at most BatchConcurrency in flight
+func (c *Client) batch(ctx context.Context, keys []string, op func(key string) (*Entry, error)) (map[string]*Entry, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		entries = make(map[string]*Entry, len(keys))
+		errs    []error
+		sem     = make(chan struct{}, BatchConcurrency)
+	)
+
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return entries, errors.Join(append(errs, ctx.Err())...)
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry, err := op(key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			if entry != nil {
+				entries[key] = entry
+			}
+		}(key)
+	}
+
+	wg.Wait()
+	return entries, errors.Join(errs...)
+}
+
+// ping returns nil if the node reports itself healthy
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.HealthCheck(ctx, "client")
+	if err != nil {
+		return err
+	}
+	if !resp.Healthy {
+		return &ServerError{Op: "health check", Message: "node " + resp.NodeId + " unhealthy"}
+	}
+	return nil
+}
+
+// ready reports whether the node has enough peers connected to meet its
+// write quorum. a healthy node may still be unready
+func (c *Client) Ready(ctx context.Context) (bool, error) {
+	resp, err := c.HealthCheck(ctx, "client")
+	if err != nil {
+		return false, err
+	}
+	return resp.Healthy && resp.Ready, nil
+}
+
+// setpeers replaces the node's peer list and returns the new cluster size
+func (c *Client) SetPeers(ctx context.Context, peers []string) (int, error) {
+	resp, err := c.UpdatePeers(ctx, peers)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, &ServerError{Op: "update peers", Message: resp.Error}
+	}
+	return int(resp.ClusterSize), nil
+}
+
+// topkeys returns the node's hottest keys, hottest first
+func (c *Client) TopKeys(ctx context.Context, limit int) ([]KeyCount, error) {
+	resp, err := c.HotKeys(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, &ServerError{Op: "hot keys", Message: resp.Error}
+	}
+
+	keys := make([]KeyCount, len(resp.Keys))
+	for i, k := range resp.Keys {
+		keys[i] = KeyCount{Key: k.Key, Count: k.Count}
+	}
+	return keys, nil
+}
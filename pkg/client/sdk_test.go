@@ -0,0 +1,346 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rachitkumar205/acp-kv/api/proto"
+	"github.com/rachitkumar205/acp-kv/internal/config"
+	"github.com/rachitkumar205/acp-kv/internal/hlc"
+	"github.com/rachitkumar205/acp-kv/internal/metrics"
+	"github.com/rachitkumar205/acp-kv/internal/replication"
+	"github.com/rachitkumar205/acp-kv/internal/server"
+	"github.com/rachitkumar205/acp-kv/internal/staleness"
+	"github.com/rachitkumar205/acp-kv/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// shared metrics instance to avoid duplicate registration
+var testMetrics = metrics.NewMetrics("test")
+
+// client connected over bufconn to a single node server, r=1 w=1
+func newTestClient(t *testing.T) (*Client, *storage.Store) {
+	t.Helper()
+
+	logger := zap.NewNop()
+	cfg := &config.Config{NodeID: "node1", N: 1, R: 1, W: 1}
+
+	store := storage.NewStore()
+	store.EnableHotKeyTracking(10)
+	coordinator, err := replication.NewCoordinator(cfg.NodeID, []string{}, logger, testMetrics, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create coordinator: %v", err)
+	}
+	t.Cleanup(func() { coordinator.Close() })
+
+	hlcClock := hlc.NewClock(cfg.NodeID, 500*time.Millisecond)
+	stalenessDetector := staleness.NewDetector(time.Hour, testMetrics)
+	srv := server.NewServer(cfg.NodeID, store, coordinator, cfg, logger, testMetrics, hlcClock, stalenessDetector, nil)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	proto.RegisterACPServiceServer(grpcServer, srv)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := NewClient("passthrough:///bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c, store
+}
+
+func TestSetAndLookup(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	written, err := c.Set(ctx, "key1", []byte("v1"))
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if written.Version == 0 || written.Timestamp.Physical == 0 || written.Timestamp.NodeID != "node1" {
+		t.Errorf("expected version and timestamp on set, got %+v", written)
+	}
+
+	entry, err := c.Lookup(ctx, "key1")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !bytes.Equal(entry.Value, []byte("v1")) || entry.Version != written.Version || entry.Timestamp != written.Timestamp {
+		t.Errorf("expected lookup to return written entry %+v, got %+v", written, entry)
+	}
+}
+
+func TestLookup_NotFound(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if _, err := c.Lookup(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLookup_Stale(t *testing.T) {
+	c, store := newTestClient(t)
+
+	// written two hours ago, past the one hour bound
+	store.PutWithHLC("old", []byte("v"), "node1", hlc.HLC{Physical: time.Now().Add(-2 * time.Hour).UnixNano(), NodeID: "node1"})
+
+	if _, err := c.Lookup(context.Background(), "old"); !errors.Is(err, ErrStale) {
+		t.Errorf("expected ErrStale, got %v", err)
+	}
+}
+
+func TestSetManyAndLookupMany(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	values := make(map[string][]byte)
+	for i := 0; i < 3*BatchConcurrency; i++ {
+		values[fmt.Sprintf("key%d", i)] = []byte(fmt.Sprintf("v%d", i))
+	}
+
+	written, err := c.SetMany(ctx, values)
+	if err != nil {
+		t.Fatalf("set many failed: %v", err)
+	}
+	if len(written) != len(values) {
+		t.Fatalf("expected %d entries written, got %d", len(values), len(written))
+	}
+
+	keys := []string{"key0", "key1", "missing"}
+	entries, err := c.LookupMany(ctx, keys)
+	if err != nil {
+		t.Fatalf("lookup many failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, missing key left out, got %d", len(entries))
+	}
+	for _, key := range keys[:2] {
+		if !bytes.Equal(entries[key].Value, values[key]) {
+			t.Errorf("expected %s=%s, got %s", key, values[key], entries[key].Value)
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("expected healthy node, got %v", err)
+	}
+}
+
+func TestSetPeers_ServerError(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	// duplicate peers are rejected by validation
+	_, err := c.SetPeers(context.Background(), []string{"a:8080", "a:8080"})
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("expected ServerError, got %v", err)
+	}
+	if serverErr.Op != "update peers" {
+		t.Errorf("expected update peers op, got %q", serverErr.Op)
+	}
+}
+
+func TestTopKeys(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Set(ctx, "hot", []byte("v")); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+	}
+	if _, err := c.Set(ctx, "cold", []byte("v")); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	keys, err := c.TopKeys(ctx, 1)
+	if err != nil {
+		t.Fatalf("top keys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Key != "hot" {
+		t.Errorf("expected hot as the top key, got %+v", keys)
+	}
+}
+
+func TestDialClient_UnreachableFailsFast(t *testing.T) {
+	// grab a free port and close it so nothing is listening
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	timeout := 200 * time.Millisecond
+	start := time.Now()
+	_, err = DialClient(addr, timeout)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected dialing an unreachable endpoint to fail")
+	}
+	if elapsed > timeout+time.Second {
+		t.Errorf("expected dial to give up after about %v, took %v", timeout, elapsed)
+	}
+}
+
+func TestDialClient_Reachable(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	c, err := DialClient("passthrough:///bufnet", time.Second, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("expected dial to succeed, got %v", err)
+	}
+	c.Close()
+}
+
+func TestReady(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	ready, err := c.Ready(context.Background())
+	if err != nil || !ready {
+		t.Errorf("expected single node at w=1 to be ready, got %v (%v)", ready, err)
+	}
+}
+
+func TestTime_Monotonic(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	var last hlc.HLC
+	for i := 0; i < 100; i++ {
+		resp, err := c.Time(context.Background())
+		if err != nil {
+			t.Fatalf("time failed: %v", err)
+		}
+		ts := hlc.FromProto(resp.Hlc)
+		if !ts.HappensAfter(last) {
+			t.Fatalf("expected %v after %v", ts, last)
+		}
+		if resp.WallTime == 0 {
+			t.Fatal("expected wall time to be set")
+		}
+		last = ts
+	}
+}
+
+// getServer answers Get after delay, or never when delay is negative
+type getServer struct {
+	proto.UnimplementedACPServiceServer
+	delay     time.Duration
+	value     string
+	cancelled chan struct{}
+}
+
+func (s *getServer) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	var wait <-chan time.Time
+	if s.delay >= 0 {
+		wait = time.After(s.delay)
+	}
+	select {
+	case <-wait:
+		return &proto.GetResponse{Found: true, Value: []byte(s.value)}, nil
+	case <-ctx.Done():
+		close(s.cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func TestLookup_HedgesToSecondEndpoint(t *testing.T) {
+	servers := map[string]*getServer{
+		"slow": {delay: -1, value: "slow", cancelled: make(chan struct{})},
+		"fast": {delay: 0, value: "fast", cancelled: make(chan struct{})},
+	}
+	listeners := make(map[string]*bufconn.Listener)
+	for name, s := range servers {
+		lis := bufconn.Listen(1 << 20)
+		grpcServer := grpc.NewServer()
+		proto.RegisterACPServiceServer(grpcServer, s)
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		listeners[name] = lis
+	}
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return listeners[addr].DialContext(ctx)
+	})
+
+	c, err := NewClient("passthrough:///slow", dialer)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.EnableHedging(50*time.Millisecond, []string{"passthrough:///fast"}, dialer); err != nil {
+		t.Fatalf("failed to enable hedging: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	entry, err := c.Lookup(ctx, "key1")
+	if err != nil {
+		t.Fatalf("expected hedged lookup to succeed, got %v", err)
+	}
+	if string(entry.Value) != "fast" {
+		t.Errorf("expected the hedge's value, got %q", entry.Value)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the hedge to be sent after the delay, returned after %v", elapsed)
+	}
+
+	// the slow endpoint's get is cancelled once the hedge wins
+	select {
+	case <-servers["slow"].cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the losing get to be cancelled")
+	}
+}
+
+func TestLookup_EmptyValueIsPresent(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := c.Set(ctx, "empty", []byte{}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	entry, err := c.Lookup(ctx, "empty")
+	if err != nil {
+		t.Fatalf("expected an empty value to be found, got %v", err)
+	}
+	if entry.Value == nil || len(entry.Value) != 0 {
+		t.Errorf("expected a non-nil zero length value, got %#v", entry.Value)
+	}
+
+	resp, err := c.Get(ctx, "empty")
+	if err != nil || !resp.Found || resp.State != proto.KeyState_KEY_STATE_PRESENT {
+		t.Errorf("expected empty key to be present, got err=%v resp=%v", err, resp)
+	}
+
+	resp, err = c.Get(ctx, "absent")
+	if err != nil || resp.Found || resp.State != proto.KeyState_KEY_STATE_ABSENT {
+		t.Errorf("expected missing key to be absent, got err=%v resp=%v", err, resp)
+	}
+	if _, err := c.Lookup(ctx, "absent"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing key, got %v", err)
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/rachitkumar205/acp-kv/internal/storage"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func TestThreeNodeCluster(t *testing.T) {
@@ -94,17 +96,14 @@ func TestQuorumEnforcement(t *testing.T) {
 
 	// put should only succeed with local node (w=2 but only 1 node is up so the op should fail)
 	ctx := context.Background()
-	putResp, err := srv.Put(ctx, &proto.PutRequest{
+	_, err = srv.Put(ctx, &proto.PutRequest{
 		Key:   "test-key",
 		Value: []byte("test-value"),
 	})
-	if err != nil {
-		t.Fatalf("PUT failed with error: %v", err)
-	}
 
-	// no peers and w=2, should fail
-	if putResp.Success {
-		t.Error("expected PUT to fail due to insufficient nodes, but it somehow succeeded")
+	// no peers and w=2, should fail with Unavailable
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected PUT to fail with Unavailable due to insufficient nodes, got %v", err)
 	}
 
 	t.Logf("quorum enforcement working: PUT correctly failed with insufficient nodes")